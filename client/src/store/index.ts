@@ -131,7 +131,11 @@ export default new Vuex.Store({
       await roomsApi.roomRestart(roomId)
     },
     async ROOMS_RECREATE({ commit }: ActionContext<State, State>, roomId: string) {
-      const res = await roomsApi.roomRecreate(roomId)
+      // recreate only settings we have seen
+      const settings = await roomsApi.roomSettings(roomId)
+      const res = await roomsApi.roomRecreate(roomId, undefined, undefined, {
+        headers: { 'If-Match': settings.headers['etag'] },
+      })
       commit('ROOMS_DEL', roomId)
       commit('ROOMS_PUT', res.data)
       return res.data
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	nekoRooms "github.com/m1k1o/neko-rooms"
+)
+
+func init() {
+	command := &cobra.Command{
+		Use:   "doctor",
+		Short: "check rooms for common issues",
+		Long:  `check rooms for port collisions, missing routes, version skew, incomplete rollouts and disk pressure, using config of serve command`,
+		Run:   nekoRooms.Service.DoctorCommand,
+	}
+
+	command.Flags().Bool("fix", false, "recreate rooms with repairable issues before checking")
+	command.Flags().Bool("json", false, "print issues as JSON")
+
+	root.AddCommand(command)
+}
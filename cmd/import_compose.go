@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	nekoRooms "github.com/m1k1o/neko-rooms"
+)
+
+func init() {
+	command := &cobra.Command{
+		Use:   "import-compose <project>",
+		Short: "import neko services of docker compose project as rooms",
+		Long:  `scan docker compose project for hand-managed neko containers and convert them to rooms, using config of serve command`,
+		Args:  cobra.ExactArgs(1),
+		Run:   nekoRooms.Service.ImportComposeCommand,
+	}
+
+	command.Flags().Bool("apply", false, "convert services to rooms, otherwise only proposed rooms are shown")
+	command.Flags().StringSlice("services", []string{}, "only import given services")
+	command.Flags().StringToString("names", map[string]string{}, "room names by service, e.g. browser=my-room")
+	command.Flags().Bool("remove", false, "remove original containers, otherwise they are only stopped")
+
+	root.AddCommand(command)
+}
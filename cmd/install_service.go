@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const serviceUnit = `[Unit]
+Description=neko-rooms
+Documentation=https://github.com/m1k1o/neko-rooms
+After=network-online.target docker.service
+Wants=network-online.target
+Requires=docker.service
+%s
+[Service]
+Type=notify
+ExecStart=%s serve%s
+User=%s
+Group=%s
+SupplementaryGroups=docker
+Restart=on-failure
+RestartSec=5
+KillSignal=SIGINT
+TimeoutStopSec=30
+
+# hardening
+NoNewPrivileges=true
+PrivateTmp=true
+PrivateDevices=true
+ProtectSystem=strict
+ProtectHome=true
+ProtectKernelTunables=true
+ProtectKernelModules=true
+ProtectKernelLogs=true
+ProtectControlGroups=true
+ProtectClock=true
+ProtectHostname=true
+RestrictNamespaces=true
+RestrictRealtime=true
+RestrictSUIDSGID=true
+LockPersonality=true
+MemoryDenyWriteExecute=true
+SystemCallArchitectures=native
+CapabilityBoundingSet=
+RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6
+%s
+[Install]
+WantedBy=multi-user.target
+`
+
+const socketUnit = `[Unit]
+Description=neko-rooms socket
+
+[Socket]
+ListenStream=%s
+
+[Install]
+WantedBy=sockets.target
+`
+
+type unitFile struct {
+	name    string
+	content string
+}
+
+func init() {
+	var dir, user, configFile, socket, storage string
+
+	command := &cobra.Command{
+		Use:   "install-service",
+		Short: "generate systemd unit files",
+		Long:  `generate hardened systemd service (and optionally socket) unit file for running neko-rooms without docker`,
+		Run: func(cmd *cobra.Command, args []string) {
+			binary, err := os.Executable()
+			if err != nil {
+				log.Fatal().Err(err).Msg("unable to get executable path")
+			}
+
+			var requires, flags, writable string
+			if socket != "" {
+				requires = "Requires=neko-rooms.socket\n"
+			}
+			if configFile != "" {
+				flags = " --config " + configFile
+			}
+			if storage != "" {
+				writable = "ReadWritePaths=" + storage + "\n"
+			}
+
+			units := []unitFile{
+				{"neko-rooms.service", fmt.Sprintf(serviceUnit, requires, binary, flags, user, user, writable)},
+			}
+			if socket != "" {
+				units = append(units, unitFile{"neko-rooms.socket", fmt.Sprintf(socketUnit, socket)})
+			}
+
+			names := []string{}
+			for _, unit := range units {
+				unitPath := filepath.Join(dir, unit.name)
+				if err := os.WriteFile(unitPath, []byte(unit.content), 0644); err != nil {
+					log.Fatal().Err(err).Str("path", unitPath).Msg("unable to write unit file")
+				}
+
+				log.Info().Str("path", unitPath).Msg("unit file written")
+				names = append(names, unit.name)
+			}
+
+			enable := "neko-rooms.service"
+			if socket != "" {
+				enable = "neko-rooms.socket"
+			}
+
+			fmt.Printf("installed %s, run:\n  systemctl daemon-reload\n  systemctl enable --now %s\n", strings.Join(names, ", "), enable)
+		},
+	}
+
+	command.Flags().StringVar(&dir, "dir", "/etc/systemd/system", "directory, where unit files are written")
+	command.Flags().StringVar(&user, "user", "neko-rooms", "user and group, that the service runs as (must be able to access docker)")
+	command.Flags().StringVar(&configFile, "config-file", "/etc/neko_rooms/neko_rooms.yaml", "configuration file passed to the service (empty to use defaults)")
+	command.Flags().StringVar(&socket, "socket", "", "address for systemd socket activation (e.g. 8080), server bind address is then ignored")
+	command.Flags().StringVar(&storage, "storage", "", "storage path, that the service is allowed to write to")
+
+	root.AddCommand(command)
+}
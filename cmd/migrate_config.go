@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	nekoRooms "github.com/m1k1o/neko-rooms"
+)
+
+// flags of serve command are added, so that deprecated flags can be migrated
+var migrateConfig = &cobra.Command{
+	Use:   "migrate-config",
+	Short: "migrate deprecated config items",
+	Long:  `read configuration of serve command from config file, envs and flags, replace deprecated config items and write it in current config file format`,
+	Run:   nekoRooms.Service.MigrateConfigCommand,
+}
+
+func init() {
+	migrateConfig.Flags().String("output", "", "write migrated config to this file instead of stdout")
+
+	root.AddCommand(migrateConfig)
+}
@@ -115,6 +115,7 @@ func init() {
 			Bool("debug", viper.GetBool("debug")).
 			Str("logging", viper.GetString("logs")).
 			Str("config", file).
+			Str("profile", viper.GetString("profile")).
 			Logger()
 
 		if file == "" {
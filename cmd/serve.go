@@ -34,5 +34,7 @@ func init() {
 		}
 	}
 
+	migrateConfig.Flags().AddFlagSet(command.PersistentFlags())
+
 	root.AddCommand(command)
 }
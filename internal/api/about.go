@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// About returns build information together with enabled features and docker version.
+func (manager *ApiManagerCtx) About(ctx context.Context) types.About {
+	about := manager.about
+	about.Backend = "docker"
+	about.Features = manager.rooms.Features()
+
+	if manager.config.PolicyUrl != "" {
+		about.Features = append(about.Features, "policy")
+	}
+
+	if manager.config.DeeplinkToken != "" {
+		about.Features = append(about.Features, "deeplink")
+	}
+
+	if manager.setup != nil {
+		about.Features = append(about.Features, "setup")
+	}
+
+	version, err := manager.rooms.DockerVersion(ctx)
+	if err != nil {
+		manager.logger.Warn().Err(err).Msg("unable to get docker version")
+	} else {
+		about.DockerVersion = version
+	}
+
+	return about
+}
+
+func (manager *ApiManagerCtx) aboutGet(w http.ResponseWriter, r *http.Request) {
+	response := manager.About(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
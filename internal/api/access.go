@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// max access log entries kept per room
+const accessLogSize = 100
+
+type accessLog struct {
+	mu      sync.Mutex
+	entries map[string][]types.RoomAccess
+}
+
+func newAccessLog() *accessLog {
+	return &accessLog{
+		entries: map[string][]types.RoomAccess{},
+	}
+}
+
+func (a *accessLog) add(roomId string, access types.RoomAccess) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := append(a.entries[roomId], access)
+	if len(entries) > accessLogSize {
+		entries = entries[len(entries)-accessLogSize:]
+	}
+
+	a.entries[roomId] = entries
+}
+
+func (a *accessLog) get(roomId string) []types.RoomAccess {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// return copy, so that it can be safely serialized
+	entries := make([]types.RoomAccess, len(a.entries[roomId]))
+	copy(entries, a.entries[roomId])
+	return entries
+}
+
+// size returns number of rooms with recorded access.
+func (a *accessLog) size() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.entries)
+}
+
+// requestActor returns identity of the user, as authenticated by basic auth,
+// proxy auth or trusted user header.
+func requestActor(r *http.Request) string {
+	if actor := types.Actor(r.Context()); actor != "" {
+		return actor
+	}
+
+	return "anonymous"
+}
+
+// logCredentialsAccess records access to room credentials, log is kept
+// by room uuid so that it survives recreates.
+func (manager *ApiManagerCtx) logCredentialsAccess(roomUUID, actor, ip, resource string) {
+	access := types.RoomAccess{
+		Time:     time.Now(),
+		Actor:    actor,
+		IP:       ip,
+		Resource: resource,
+	}
+
+	manager.access.add(roomUUID, access)
+
+	manager.logger.Info().
+		Str("uuid", roomUUID).
+		Str("actor", access.Actor).
+		Str("ip", access.IP).
+		Str("resource", access.Resource).
+		Msg("room credentials accessed")
+}
+
+// logRoomsCredentialsAccess records access to credentials of all rooms, that
+// are exported at once, optionally only running ones.
+func (manager *ApiManagerCtx) logRoomsCredentialsAccess(r *http.Request, resource string, onlyRunning bool) error {
+	entries, err := manager.rooms.List(r.Context(), nil)
+	if err != nil {
+		return err
+	}
+
+	actor := requestActor(r)
+	for _, entry := range entries {
+		if onlyRunning && !entry.Running {
+			continue
+		}
+
+		manager.logCredentialsAccess(entry.UUID, actor, manager.directory.clientIP(r), resource)
+	}
+
+	return nil
+}
+
+func (manager *ApiManagerCtx) roomGetAccessLog(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	entry, err := manager.rooms.GetEntry(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			manager.logger.Error().Err(err).Msg("access log: failed to get room entry")
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	response := manager.access.get(entry.UUID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
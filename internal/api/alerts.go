@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) alertsList(w http.ResponseWriter, r *http.Request) {
+	response := manager.rooms.Alerts()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) alertsSilence(w http.ResponseWriter, r *http.Request) {
+	request := types.RoomAlertSilence{}
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	if err := manager.rooms.SilenceAlert(request); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) roomGetAliases(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	entry, err := manager.rooms.GetEntry(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	response := entry.Aliases
+	if response == nil {
+		response = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// roomSetAliases replaces all aliases of a room, the room needs to be recreated.
+func (manager *ApiManagerCtx) roomSetAliases(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	aliases := []string{}
+	if err := manager.decodeRequest(w, r, &aliases); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	settings, err := manager.rooms.GetSettings(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	settings.Aliases = aliases
+	if err := manager.checkPolicy(r, "update", roomId, settings); err != nil {
+		manager.policyError(w, err)
+		return
+	}
+
+	ID, err := manager.rooms.SetAliases(r.Context(), roomId, aliases)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			manager.logger.Error().Err(err).Msg("aliases: failed to set room aliases")
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	response, err := manager.rooms.GetEntry(r.Context(), ID)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("aliases: failed to get room entry")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
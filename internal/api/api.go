@@ -1,33 +1,72 @@
 package api
 
 import (
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/m1k1o/neko-rooms/internal/config"
 	"github.com/m1k1o/neko-rooms/internal/types"
 )
 
 type ApiManagerCtx struct {
 	logger zerolog.Logger
+	config *config.Admin
 	rooms  types.RoomManager
 	pull   types.PullManager
+	setup  types.SetupManager // nil when setup mode is disabled
+	access *accessLog
+	about  types.About
+
+	started time.Time
+
+	idempotency *idempotencyCache
+	queue       *roomQueue
+	directory   *directory
+	eventHub    *eventHub
 }
 
-func New(rooms types.RoomManager, pull types.PullManager) *ApiManagerCtx {
+func New(rooms types.RoomManager, pull types.PullManager, setup types.SetupManager, config *config.Admin, about types.About) *ApiManagerCtx {
+	logger := log.With().Str("module", "api").Logger()
+
 	return &ApiManagerCtx{
-		logger: log.With().Str("module", "api").Logger(),
+		logger: logger,
+		config: config,
 		rooms:  rooms,
 		pull:   pull,
+		setup:  setup,
+		access: newAccessLog(),
+		about:  about,
+
+		started: time.Now(),
+
+		idempotency: newIdempotencyCache(),
+		queue:       newRoomQueue(),
+		directory:   newDirectory(config),
+		eventHub:    newEventHub(logger, rooms, pull),
 	}
 }
 
 func (manager *ApiManagerCtx) Mount(r chi.Router) {
+	r.Use(manager.limitRequest)
+
 	//
 	// config
 	//
 
+	r.Get("/about", manager.aboutGet)
 	r.Get("/config/rooms", manager.configRooms)
+	r.Get("/diagnostics", manager.diagnostics)
+
+	//
+	// setup
+	//
+
+	if manager.setup != nil {
+		r.Route("/setup", manager.mountSetup)
+	}
 
 	//
 	// pull
@@ -45,27 +84,118 @@ func (manager *ApiManagerCtx) Mount(r chi.Router) {
 	//
 
 	r.Get("/rooms", manager.roomsList)
-	r.Post("/rooms", manager.roomCreate)
+	r.Post("/rooms", manager.idempotent(manager.roomCreate))
+	r.Post("/rooms/migrate", manager.idempotent(manager.withPolicy("migrate", manager.roomsMigrate)))
+	r.Post("/rooms/bulk", manager.idempotent(manager.roomsBulk))
+	r.Post("/quick", manager.idempotent(manager.roomQuick))
+	r.Post("/demo", manager.roomDemo)
+	r.Post("/rooms/restore", manager.idempotent(manager.withPolicy("create", manager.roomRestore)))
+	r.Get("/rooms/restore/verify", manager.archiveVerifications)
+	r.Post("/rooms/restore/verify", manager.archiveVerify)
+	r.Get("/rooms/tiering", manager.roomsTiering)
+	r.Get("/import/compose", manager.composeImports)
+	r.Post("/import/compose", manager.idempotent(manager.withPolicy("create", manager.composeImport)))
+
+	r.Get("/alerts", manager.alertsList)
+	r.Post("/alerts/silences", manager.alertsSilence)
+
+	r.Get("/doctor", manager.doctorCheck)
+	r.Post("/doctor/repair", manager.withPolicy("repair", manager.doctorRepair))
 
 	r.Route("/rooms/{roomId}", func(r chi.Router) {
 		r.Get("/", manager.roomGetEntry)
 		r.Get("/by-name", manager.roomGetEntryByName)
 
 		r.Get("/settings", manager.roomGetSettings)
+		r.Get("/access-log", manager.roomGetAccessLog)
 		r.Get("/stats", manager.roomGetStats)
-
-		r.Delete("/", manager.roomGenericAction(manager.rooms.Remove))
-		r.Post("/start", manager.roomGenericAction(manager.rooms.Start))
-		r.Post("/stop", manager.roomGenericAction(manager.rooms.Stop))
-		r.Post("/restart", manager.roomGenericAction(manager.rooms.Restart))
-		r.Post("/recreate", manager.roomRecreate)
+		r.Post("/exec", manager.withPolicy("exec", manager.roomExec))
+		r.Get("/terminal", manager.withPolicy("terminal", manager.roomTerminal))
+		r.Get("/stats/webrtc", manager.roomGetWebRTCStats)
+		r.Get("/stats/webrtc/history", manager.roomGetWebRTCStatsHistory)
+		r.Get("/aliases", manager.roomGetAliases)
+		r.Put("/aliases", manager.idempotent(manager.roomSetAliases))
+
+		r.Get("/notes", manager.roomGetNotes)
+		r.Post("/notes", manager.idempotent(manager.roomAddNote))
+		r.Delete("/notes/{noteId}", manager.roomRemoveNote)
+
+		r.Delete("/", manager.withPolicy("remove", manager.roomGenericAction(manager.rooms.Remove)))
+		r.Post("/start", manager.withPolicy("start", manager.roomGenericAction(manager.rooms.Start)))
+		r.Post("/stop", manager.withPolicy("stop", manager.roomGenericAction(manager.rooms.Stop)))
+		r.Post("/restart", manager.withPolicy("restart", manager.roomGenericAction(manager.rooms.Restart)))
+		r.Post("/pause", manager.withPolicy("pause", manager.roomGenericAction(manager.rooms.Pause)))
+		r.Post("/unpause", manager.withPolicy("unpause", manager.roomGenericAction(manager.rooms.Unpause)))
+		r.Post("/clone", manager.idempotent(manager.withPolicy("create", manager.roomClone)))
+		r.Put("/name", manager.idempotent(manager.roomRename))
+		r.Post("/snapshot", manager.idempotent(manager.withPolicy("snapshot", manager.roomSnapshot)))
+		r.Post("/recreate", manager.idempotent(manager.roomRecreate))
+		r.Post("/diff", manager.roomDiff)
+		r.Post("/archive", manager.idempotent(manager.withPolicy("archive", manager.roomArchive)))
+
+		r.Get("/broadcast", manager.roomGetBroadcast)
+		r.Post("/broadcast", manager.withPolicy("broadcast", manager.roomStartBroadcast))
+		r.Delete("/broadcast", manager.withPolicy("broadcast", manager.roomGenericAction(manager.rooms.StopBroadcast)))
 	})
 
 	r.Get("/docker-compose.yaml", manager.dockerCompose)
+	r.Get("/export", manager.roomsExport)
+	r.Get("/backup", manager.roomsBackup)
+	r.Get("/archives", manager.archivesList)
+	r.Get("/archives/{archive}", manager.archiveDownload)
+	r.Get("/bootstrap", manager.bootstrap)
+
+	//
+	// logs
+	//
+
+	r.Get("/logs/search", manager.logsSearch)
+
+	//
+	// rollouts
+	//
+
+	r.Get("/rollouts", manager.rolloutsList)
+	r.Post("/rollouts", manager.idempotent(manager.withPolicy("rollout", manager.rolloutCreate)))
+	r.Get("/rollouts/{rolloutId}", manager.rolloutAction(manager.rooms.GetRollout))
+	r.Post("/rollouts/{rolloutId}/promote", manager.idempotent(manager.withPolicy("rollout", manager.rolloutAction(manager.rooms.PromoteRollout))))
+	r.Post("/rollouts/{rolloutId}/rollback", manager.idempotent(manager.withPolicy("rollout", manager.rolloutAction(manager.rooms.RollbackRollout))))
+
+	//
+	// profiles
+	//
+
+	r.Get("/profiles", manager.profilesList)
+	r.Post("/profiles", manager.idempotent(manager.withPolicy("profile", manager.profileSave)))
+	r.Get("/profiles/{profileName}", manager.profileGet)
+	r.Delete("/profiles/{profileName}", manager.withPolicy("profile", manager.profileRemove))
+
+	//
+	// snapshots
+	//
+
+	r.Get("/snapshots", manager.snapshotsList)
+	r.Delete("/snapshots/{snapshotTag}", manager.snapshotRemove)
+
+	//
+	// sessions
+	//
+
+	r.Get("/sessions", manager.sessionsList)
+
+	//
+	// queue
+	//
+
+	r.Get("/queue", manager.queueList)
+	r.Get("/queue/{queueId}", manager.queueGet)
+	r.Delete("/queue/{queueId}", manager.queueRemove)
 
 	//
 	// events
 	//
 
 	r.Get("/events", manager.events)
+	r.Get("/events/ws", manager.eventsWs)
+	r.Get("/events/sse", manager.eventsSse)
 }
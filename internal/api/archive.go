@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) roomArchive(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	response, err := manager.rooms.Archive(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			manager.logger.Error().Err(err).Msg("archive: failed to archive room")
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) roomRestore(w http.ResponseWriter, r *http.Request) {
+	var start bool
+	if s := r.URL.Query().Get("start"); s != "" {
+		var err error
+		start, err = strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+
+	var request struct {
+		Archive string `json:"archive"`
+	}
+
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	// restored room takes more space, same as new room
+	if manager.rooms.DiskPressure() {
+		http.Error(w, "disk usage is above threshold", 500)
+		return
+	}
+
+	ID, err := manager.rooms.Restore(r.Context(), request.Archive)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("restore: failed to restore room")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if start {
+		if err := manager.rooms.Start(r.Context(), ID); err != nil {
+			manager.logger.Error().Err(err).Msg("restore: failed to start room")
+			http.Error(w, err.Error(), 500)
+			return
+		}
+	}
+
+	response, err := manager.rooms.GetEntry(r.Context(), ID)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("restore: failed to get room entry")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) archiveVerify(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Archive string `json:"archive"`
+	}
+
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	response, err := manager.rooms.VerifyArchive(request.Archive)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) archiveVerifications(w http.ResponseWriter, r *http.Request) {
+	response := manager.rooms.ArchiveVerifications()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
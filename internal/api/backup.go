@@ -0,0 +1,163 @@
+package api
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// writtenWriter remembers, whether anything was written, so that error can
+// still be reported with status code.
+type writtenWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (ww *writtenWriter) Write(p []byte) (int, error) {
+	ww.written = true
+	return ww.w.Write(p)
+}
+
+// compressWriter wraps writer with requested compression and returns file
+// extension of the compressed stream.
+func compressWriter(w io.Writer, compression string) (io.WriteCloser, string, error) {
+	switch compression {
+	case "", "gzip":
+		return gzip.NewWriter(w), ".gz", nil
+	case "deflate":
+		fw, err := flate.NewWriter(w, flate.DefaultCompression)
+		return fw, ".deflate", err
+	case "none":
+		return nopWriteCloser{w}, "", nil
+	default:
+		return nil, "", fmt.Errorf("invalid compression, must be one of: gzip, deflate, none")
+	}
+}
+
+// streamDownload streams output of fn to client as compressed file. Nothing is
+// buffered, so when fn fails after it has written data, the stream is cut off
+// without closing the compression and the client gets incomplete file.
+func (manager *ApiManagerCtx) streamDownload(w http.ResponseWriter, r *http.Request, name, contentType string, fn func(ctx context.Context, w io.Writer) error) {
+	ww := &writtenWriter{w: w}
+
+	cw, ext, err := compressWriter(ww, r.URL.Query().Get("compression"))
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	switch ext {
+	case ".gz":
+		contentType = "application/gzip"
+	case ".deflate":
+		contentType = "application/octet-stream"
+	}
+
+	filename := fmt.Sprintf("neko-rooms-%s-%s%s", name, time.Now().UTC().Format("20060102-150405"), ext)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+
+	if err := fn(r.Context(), cw); err != nil {
+		manager.logger.Error().Err(err).Str("download", name).Msg("failed to stream download")
+		if !ww.written {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	if err := cw.Close(); err != nil {
+		manager.logger.Error().Err(err).Str("download", name).Msg("failed to finish download")
+	}
+}
+
+func (manager *ApiManagerCtx) roomsExport(w http.ResponseWriter, r *http.Request) {
+	if err := manager.logRoomsCredentialsAccess(r, "export", false); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	manager.streamDownload(w, r, "export.jsonl", "application/x-ndjson", manager.rooms.ExportRooms)
+}
+
+func (manager *ApiManagerCtx) roomsBackup(w http.ResponseWriter, r *http.Request) {
+	var storage bool
+	if s := r.URL.Query().Get("storage"); s != "" {
+		var err error
+		storage, err = strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+
+	if err := manager.logRoomsCredentialsAccess(r, "backup", false); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	manager.streamDownload(w, r, "backup.tar", "application/x-tar", func(ctx context.Context, w io.Writer) error {
+		return manager.rooms.Backup(ctx, w, storage)
+	})
+}
+
+func (manager *ApiManagerCtx) archivesList(w http.ResponseWriter, r *http.Request) {
+	response, err := manager.rooms.ListArchives()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// archiveDownload serves archive with support for range requests, so that
+// interrupted downloads can be resumed.
+func (manager *ApiManagerCtx) archiveDownload(w http.ResponseWriter, r *http.Request) {
+	file, roomUUID, err := manager.rooms.OpenArchive(chi.URLParam(r, "archive"))
+	if err != nil {
+		if errors.Is(err, types.ErrArchiveNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	// settings in archive contain room credentials
+	if roomUUID != "" {
+		manager.logCredentialsAccess(roomUUID, requestActor(r), manager.directory.clientIP(r), "archive")
+	}
+
+	// archives are not modified, size and time identify them for If-Range
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+info.Name()+"\"")
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) roomGetBroadcast(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	response, err := manager.rooms.GetBroadcast(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) roomStartBroadcast(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	var request types.RoomBroadcastTarget
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	// stream key must not be logged
+	if err := manager.rooms.StartBroadcast(r.Context(), roomId, request); err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			manager.logger.Error().Err(err).Str("id", roomId).Msg("broadcast: failed to start broadcast")
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
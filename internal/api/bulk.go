@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// maximum number of rooms, that are handled at the same time
+const bulkConcurrency = 8
+
+func (manager *ApiManagerCtx) bulkAction(action types.RoomBulkAction) func(ctx context.Context, id string) error {
+	switch action {
+	case types.RoomBulkStart:
+		return manager.rooms.Start
+	case types.RoomBulkStop:
+		return manager.rooms.Stop
+	case types.RoomBulkRestart:
+		return manager.rooms.Restart
+	case types.RoomBulkRemove:
+		return manager.rooms.Remove
+	}
+	return nil
+}
+
+// roomsBulk runs the same action on multiple rooms concurrently. Failure of one
+// room does not stop the others, result of every room is reported.
+func (manager *ApiManagerCtx) roomsBulk(w http.ResponseWriter, r *http.Request) {
+	var request types.RoomBulkRequest
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	action := manager.bulkAction(request.Action)
+	if action == nil {
+		http.Error(w, "invalid action, must be one of: start, stop, restart, remove", 400)
+		return
+	}
+
+	if len(request.IDs) == 0 {
+		http.Error(w, "no rooms specified", 400)
+		return
+	}
+
+	seen := map[string]struct{}{}
+	for _, id := range request.IDs {
+		if _, ok := seen[id]; ok {
+			http.Error(w, fmt.Sprintf("room %s specified multiple times", id), 400)
+			return
+		}
+		seen[id] = struct{}{}
+	}
+
+	results := make([]types.RoomBulkResult, len(request.IDs))
+	sem := make(chan struct{}, bulkConcurrency)
+
+	var wg sync.WaitGroup
+	for i, id := range request.IDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := types.RoomBulkResult{
+				ID:     id,
+				Status: http.StatusNoContent,
+			}
+
+			// bulk actions have the same names as policy actions
+			err := manager.checkPolicy(r, string(request.Action), id, nil)
+			if err == nil {
+				err = action(r.Context(), id)
+			}
+
+			if err != nil {
+				switch {
+				case errors.Is(err, types.ErrRoomNotFound):
+					result.Status = 404
+				case errors.Is(err, errPolicyDenied):
+					result.Status = 403
+				default:
+					result.Status = 500
+				}
+				result.Error = err.Error()
+			}
+
+			results[i] = result
+		}(i, id)
+	}
+	wg.Wait()
+
+	manager.logger.Info().
+		Str("action", string(request.Action)).
+		Int("rooms", len(request.IDs)).
+		Msg("bulk: action finished")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	callbackWaitTimeout = 5 * time.Minute
+	callbackSendTimeout = 10 * time.Second
+)
+
+func parseCallbackUrl(callbackUrl string) error {
+	u, err := url.Parse(callbackUrl)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("callback url must use http or https scheme")
+	}
+
+	return nil
+}
+
+// roomCallback subscribes to room events and sends one-shot callback
+// when room becomes ready or fails. It must be called before room is started.
+func (manager *ApiManagerCtx) roomCallback(roomId string, callbackUrl string) (fail func(err error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), callbackWaitTimeout)
+	events, errs := manager.rooms.Events(ctx)
+
+	failCh := make(chan error, 1)
+
+	go func() {
+		err := waitRoomReady(roomId, events, errs, failCh)
+
+		// events are broadcasted synchronously, listener must be removed
+		// and drained before sending callback, that can take a while
+		cancel()
+		go func() {
+			for {
+				select {
+				case <-events:
+				case _, ok := <-errs:
+					if !ok {
+						return
+					}
+				}
+			}
+		}()
+
+		status := types.RoomCallbackReady
+		if err != nil {
+			status = types.RoomCallbackFailed
+		}
+
+		manager.sendCallback(roomId, callbackUrl, status, err)
+	}()
+
+	return func(err error) {
+		select {
+		case failCh <- err:
+		default:
+		}
+	}
+}
+
+// waitRoomReady waits for room to become ready and returns error, when it fails.
+func waitRoomReady(roomId string, events <-chan types.RoomEvent, errs <-chan error, failCh <-chan error) error {
+	for {
+		select {
+		case err := <-failCh:
+			return err
+		case err := <-errs:
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("room did not become ready in %s", callbackWaitTimeout)
+			}
+			return err
+		case e := <-events:
+			if e.ID != roomId {
+				continue
+			}
+
+			switch e.Action {
+			case types.RoomEventReady:
+				return nil
+			case types.RoomEventStopped:
+				return fmt.Errorf("room stopped before it became ready")
+			case types.RoomEventDestroyed:
+				return fmt.Errorf("room destroyed before it became ready")
+			}
+		}
+	}
+}
+
+func (manager *ApiManagerCtx) sendCallback(roomId string, callbackUrl string, status types.RoomCallbackStatus, callbackErr error) {
+	logger := manager.logger.With().Str("id", roomId).Str("callback", callbackUrl).Logger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), callbackSendTimeout)
+	defer cancel()
+
+	payload := types.RoomCallback{
+		Status: status,
+	}
+
+	if callbackErr != nil {
+		payload.Error = callbackErr.Error()
+	}
+
+	// entry and settings are optional, room could have been destroyed
+	if entry, err := manager.rooms.GetEntry(ctx, roomId); err == nil {
+		payload.Entry = entry
+	}
+	if settings, err := manager.rooms.GetSettings(ctx, roomId); err == nil {
+		payload.Settings = settings
+		manager.logCredentialsAccess(settings.UUID, "callback", callbackUrl, "callback")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Err(err).Msg("callback: failed to marshal payload")
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackUrl, bytes.NewReader(data))
+	if err != nil {
+		logger.Err(err).Msg("callback: failed to create request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logger.Err(err).Msg("callback: failed to send request")
+		return
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		logger.Warn().Int("status", res.StatusCode).Msg("callback: unexpected response status")
+		return
+	}
+
+	logger.Info().Str("status", string(payload.Status)).Msg("callback: sent")
+}
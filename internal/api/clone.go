@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) roomClone(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	request := types.RoomCloneRequest{}
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	// clone takes more space, same as new room
+	if manager.rooms.DiskPressure() {
+		http.Error(w, fmt.Errorf("disk usage is above threshold: %w", types.ErrNotEnoughCapacity).Error(), 500)
+		return
+	}
+
+	ID, err := manager.rooms.Clone(r.Context(), roomId, request)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			manager.logger.Error().Err(err).Str("id", roomId).Msg("clone: failed to clone room")
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	if request.Start {
+		if err := manager.rooms.Start(r.Context(), ID); err != nil {
+			manager.logger.Error().Err(err).Msg("clone: failed to start room")
+			http.Error(w, err.Error(), 500)
+			return
+		}
+	}
+
+	response, err := manager.rooms.GetEntry(r.Context(), ID)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("clone: failed to get room entry")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// deeplinkAuthorized checks deep link token, links can not carry headers so it
+// is accepted in query as well.
+func (manager *ApiManagerCtx) deeplinkAuthorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(manager.config.DeeplinkToken)) == 1
+}
+
+// deeplinkCreate creates room from template room and starts it. When the room
+// already exists, it is only started, so that the same link can be opened
+// repeatedly (e.g. from calendar invite).
+func (manager *ApiManagerCtx) deeplinkCreate(w http.ResponseWriter, r *http.Request) {
+	if !manager.deeplinkAuthorized(r) {
+		http.Error(w, "invalid token", 401)
+		return
+	}
+
+	query := r.URL.Query()
+	template := query.Get("template")
+	name := query.Get("name")
+
+	redirect := false
+	if val := query.Get("redirect"); val != "" {
+		var err error
+		if redirect, err = strconv.ParseBool(val); err != nil {
+			http.Error(w, "invalid redirect, must be boolean", 400)
+			return
+		}
+	}
+
+	if template == "" {
+		http.Error(w, "template is required", 400)
+		return
+	}
+
+	// template room itself is not started
+	if name == template {
+		http.Error(w, "name must differ from template", 400)
+		return
+	}
+
+	var ID string
+	if name != "" {
+		entry, err := manager.rooms.GetEntryByName(r.Context(), name)
+		if err == nil {
+			// token must not give access to credentials of unrelated rooms
+			if entry.Template != template {
+				http.Error(w, "room with this name already exists and was not created from template", 409)
+				return
+			}
+
+			ID = entry.ID
+		} else if !errors.Is(err, types.ErrRoomNotFound) {
+			manager.logger.Error().Err(err).Str("name", name).Msg("deeplink: failed to get room")
+			http.Error(w, err.Error(), 500)
+			return
+		}
+	}
+
+	if ID == "" {
+		tmpl, err := manager.rooms.GetEntryByName(r.Context(), template)
+		if err != nil {
+			if errors.Is(err, types.ErrRoomNotFound) {
+				http.Error(w, "template not found", 404)
+			} else {
+				manager.logger.Error().Err(err).Str("template", template).Msg("deeplink: failed to get template")
+				http.Error(w, err.Error(), 500)
+			}
+			return
+		}
+
+		// clone takes more space, same as new room
+		if manager.rooms.DiskPressure() {
+			http.Error(w, fmt.Errorf("disk usage is above threshold: %w", types.ErrNotEnoughCapacity).Error(), 500)
+			return
+		}
+
+		if err := manager.checkPolicy(r, "create", "", nil); err != nil {
+			manager.policyError(w, err)
+			return
+		}
+
+		ID, err = manager.rooms.Clone(r.Context(), tmpl.ID, types.RoomCloneRequest{
+			Name:     name,
+			Template: true,
+		})
+		if err != nil {
+			manager.logger.Error().Err(err).Str("template", template).Msg("deeplink: failed to create room")
+			http.Error(w, err.Error(), 500)
+			return
+		}
+
+		manager.logger.Info().
+			Str("id", ID).
+			Str("template", template).
+			Str("name", name).
+			Msg("deeplink: room created")
+	}
+
+	if err := manager.rooms.Start(r.Context(), ID); err != nil {
+		manager.logger.Error().Err(err).Msg("deeplink: failed to start room")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	entry, err := manager.rooms.GetEntry(r.Context(), ID)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("deeplink: failed to get room entry")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	settings, err := manager.rooms.GetSettings(r.Context(), ID)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("deeplink: failed to get room settings")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	// deep link request is not authenticated by admin, token is the only actor
+	manager.logCredentialsAccess(entry.UUID, "deeplink", manager.directory.clientIP(r), "deeplink")
+
+	response := types.QuickRoom{
+		ID:        entry.ID,
+		Name:      entry.Name,
+		URL:       entry.URL,
+		InviteURL: entry.URL + "?pwd=" + url.QueryEscape(settings.UserPass),
+		Expires:   entry.Expires,
+	}
+
+	// link contains password, it must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+
+	if redirect {
+		http.Redirect(w, r, response.InviteURL, http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
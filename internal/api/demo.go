@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// roomDemo creates quick room with enforced lifetime and reduced resources,
+// it is the only action allowed for demo token.
+func (manager *ApiManagerCtx) roomDemo(w http.ResponseWriter, r *http.Request) {
+	if manager.config.DemoToken == "" {
+		http.Error(w, "demo rooms are disabled", 404)
+		return
+	}
+
+	request := types.QuickRoomRequest{}
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	maxLifetime := manager.config.DemoLifetimeSec
+	if request.Lifetime <= 0 || request.Lifetime > maxLifetime {
+		request.Lifetime = maxLifetime
+	}
+
+	settings, err := manager.quickSettings(request)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	settings.Demo = true
+	settings.MaxConnections = 2
+	// one-shot demo must not come back after reboot
+	settings.RestartPolicy = "no"
+	settings.Resources.NanoCPUs = manager.config.DemoNanoCPUs
+	settings.Resources.Memory = manager.config.DemoMemory
+	settings.Resources.ShmSize = 1e9
+	settings.Envs = map[string]string{}
+	for key, val := range manager.config.DemoEnvs {
+		settings.Envs[key] = val
+	}
+
+	rooms, err := manager.rooms.List(r.Context(), nil)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("demo: failed to list rooms")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	demoRooms := 0
+	for _, room := range rooms {
+		if room.Demo {
+			demoRooms++
+		}
+	}
+
+	if manager.config.DemoMaxRooms > 0 && demoRooms >= manager.config.DemoMaxRooms {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "maximum number of demo rooms reached", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := manager.checkPolicy(r, "create", "", settings); err != nil {
+		manager.policyError(w, err)
+		return
+	}
+
+	ID, err := manager.createRoom(r.Context(), *settings)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("demo: failed to create room")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if err := manager.rooms.Start(r.Context(), ID); err != nil {
+		manager.logger.Error().Err(err).Msg("demo: failed to start room")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	entry, err := manager.rooms.GetEntry(r.Context(), ID)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("demo: failed to get room entry")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	manager.logger.Info().
+		Str("id", entry.ID).
+		Str("name", entry.Name).
+		Time("expires", *settings.Expires).
+		Msg("demo room created")
+
+	manager.logCredentialsAccess(entry.UUID, requestActor(r), manager.directory.clientIP(r), "demo")
+
+	response := types.QuickRoom{
+		ID:        entry.ID,
+		Name:      entry.Name,
+		URL:       entry.URL,
+		InviteURL: entry.URL + "?pwd=" + url.QueryEscape(settings.UserPass),
+		Expires:   entry.Expires,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
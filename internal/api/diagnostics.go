@@ -0,0 +1,52 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) diagnostics(w http.ResponseWriter, r *http.Request) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	response := types.Diagnostics{
+		Time:       time.Now(),
+		Uptime:     time.Since(manager.started).Seconds(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: types.DiagnosticsMemory{
+			HeapAlloc:    memStats.HeapAlloc,
+			HeapInuse:    memStats.HeapInuse,
+			HeapObjects:  memStats.HeapObjects,
+			StackInuse:   memStats.StackInuse,
+			Sys:          memStats.Sys,
+			NumGC:        memStats.NumGC,
+			PauseTotalNs: memStats.PauseTotalNs,
+			NextGC:       memStats.NextGC,
+		},
+		Caches: map[string]int{
+			"idempotency": manager.idempotency.size(),
+			"access_log":  manager.access.size(),
+			"queue":       len(manager.queue.list()),
+		},
+		Events: manager.rooms.Diagnostics(),
+	}
+
+	// full goroutine dump is large, so it is only included on request
+	if r.URL.Query().Get("stacks") == "1" {
+		var buf bytes.Buffer
+		if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		response.Stacks = buf.String()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
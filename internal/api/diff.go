@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// settings fields, that can be updated without recreating the container
+var inPlaceSettings = map[string]struct{}{
+	"resources.cpu_shares": {},
+	"resources.nano_cpus":  {},
+	"resources.memory":     {},
+}
+
+// isInPlaceChange returns true, if change can be applied without recreate. Limits
+// can only be changed, not removed in place.
+func isInPlaceChange(field string, proposed any) bool {
+	if _, ok := inPlaceSettings[field]; !ok {
+		return false
+	}
+
+	val, ok := proposed.(float64)
+	return ok && val > 0
+}
+
+// settings fields, whose values are not exposed in diff
+var secretSettings = map[string]struct{}{
+	"user_pass":          {},
+	"admin_pass":         {},
+	"broadcast_pipeline": {}, // contains stream key
+}
+
+// isSecretSetting returns true, if value of flattened settings field must not be exposed.
+func isSecretSetting(field string) bool {
+	if _, ok := secretSettings[field]; ok {
+		return true
+	}
+
+	if env, ok := strings.CutPrefix(field, "envs."); ok {
+		return types.SecretEnvRegex.MatchString(env) || strings.Contains(strings.ToUpper(env), "BROADCAST")
+	}
+
+	return false
+}
+
+// flattenSettings converts settings to map of field paths and their JSON values,
+// nested objects are flattened using dot notation, arrays are kept as a whole.
+func flattenSettings(settings types.RoomSettings) (map[string]any, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{}
+
+	var flatten func(prefix string, obj map[string]any)
+	flatten = func(prefix string, obj map[string]any) {
+		for key, val := range obj {
+			if nested, ok := val.(map[string]any); ok {
+				flatten(prefix+key+".", nested)
+				continue
+			}
+			result[prefix+key] = val
+		}
+	}
+	flatten("", obj)
+
+	return result, nil
+}
+
+// copySettings returns deep copy of settings, so that request can be decoded on top of it.
+func copySettings(settings *types.RoomSettings) (types.RoomSettings, error) {
+	var result types.RoomSettings
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+func diffSettings(current, proposed types.RoomSettings) (*types.RoomSettingsDiff, error) {
+	currentFields, err := flattenSettings(current)
+	if err != nil {
+		return nil, err
+	}
+
+	proposedFields, err := flattenSettings(proposed)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]struct{}{}
+	for field := range currentFields {
+		fields[field] = struct{}{}
+	}
+	for field := range proposedFields {
+		fields[field] = struct{}{}
+	}
+
+	diff := &types.RoomSettingsDiff{
+		Changes: []types.RoomSettingsChange{},
+	}
+
+	for field := range fields {
+		currentVal, proposedVal := currentFields[field], proposedFields[field]
+		if reflect.DeepEqual(currentVal, proposedVal) {
+			continue
+		}
+
+		change := types.RoomSettingsChange{
+			Field:    field,
+			Current:  currentVal,
+			Proposed: proposedVal,
+			Recreate: !isInPlaceChange(field, proposedVal),
+		}
+
+		if isSecretSetting(field) {
+			change.Current = nil
+			change.Proposed = nil
+		}
+
+		diff.Changes = append(diff.Changes, change)
+		diff.Recreate = diff.Recreate || change.Recreate
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Field < diff.Changes[j].Field
+	})
+
+	return diff, nil
+}
+
+func (manager *ApiManagerCtx) roomDiff(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	current, err := manager.rooms.GetSettings(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			manager.logger.Error().Err(err).Msg("diff: failed to get room settings")
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	// proposed settings are applied on top of a copy of current settings, same as in recreate
+	proposed, err := copySettings(current)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("diff: failed to copy room settings")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if err := manager.decodeRequest(w, r, &proposed); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	response, err := diffSettings(*current, proposed)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("diff: failed to compare settings")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func TestDiffSettings(t *testing.T) {
+	current := types.RoomSettings{
+		Name:      "foo",
+		UserPass:  "user",
+		Resources: types.RoomResources{Memory: 1 << 30, NanoCPUs: 1e9},
+		Envs:      map[string]string{"TZ": "UTC", "API_TOKEN": "secret"},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(settings *types.RoomSettings)
+		field    string
+		recreate bool
+		secret   bool
+	}{
+		{"memory is changed in place", func(s *types.RoomSettings) { s.Resources.Memory = 2 << 30 }, "resources.memory", false, false},
+		{"cpus are changed in place", func(s *types.RoomSettings) { s.Resources.NanoCPUs = 2e9 }, "resources.nano_cpus", false, false},
+		{"limit removal requires recreate", func(s *types.RoomSettings) { s.Resources.Memory = 0 }, "resources.memory", true, false},
+		{"name requires recreate", func(s *types.RoomSettings) { s.Name = "bar" }, "name", true, false},
+		{"password is redacted", func(s *types.RoomSettings) { s.UserPass = "other" }, "user_pass", true, true},
+		{"secret env is redacted", func(s *types.RoomSettings) { s.Envs["API_TOKEN"] = "other" }, "envs.API_TOKEN", true, true},
+		{"plain env is shown", func(s *types.RoomSettings) { s.Envs["TZ"] = "CET" }, "envs.TZ", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proposed, err := copySettings(&current)
+			if err != nil {
+				t.Fatalf("copySettings failed: %v", err)
+			}
+			tt.modify(&proposed)
+
+			diff, err := diffSettings(current, proposed)
+			if err != nil {
+				t.Fatalf("diffSettings failed: %v", err)
+			}
+
+			if len(diff.Changes) != 1 || diff.Changes[0].Field != tt.field {
+				t.Fatalf("diffSettings returned %+v, expected single change of %s", diff.Changes, tt.field)
+			}
+
+			change := diff.Changes[0]
+			if change.Recreate != tt.recreate || diff.Recreate != tt.recreate {
+				t.Errorf("recreate = %v (diff %v), expected %v", change.Recreate, diff.Recreate, tt.recreate)
+			}
+
+			if secret := change.Current == nil && change.Proposed == nil; secret != tt.secret {
+				t.Errorf("redacted = %v, expected %v", secret, tt.secret)
+			}
+		})
+	}
+}
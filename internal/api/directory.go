@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const directoryRateWindow = time.Minute
+
+type directory struct {
+	config *config.Admin
+
+	mu      sync.Mutex
+	rooms   []types.DirectoryRoom
+	expires time.Time
+
+	clientsMu sync.Mutex
+	clients   map[string]*directoryClient // by ip
+}
+
+type directoryClient struct {
+	requests int
+	reset    time.Time
+}
+
+func newDirectory(config *config.Admin) *directory {
+	return &directory{
+		config:  config,
+		clients: map[string]*directoryClient{},
+	}
+}
+
+// allow counts request of client in fixed window and returns false, when the
+// limit is reached, together with time, when the window is reset.
+func (d *directory) allow(ip string) (bool, time.Time) {
+	d.clientsMu.Lock()
+	defer d.clientsMu.Unlock()
+
+	now := time.Now()
+	for key, client := range d.clients {
+		if now.After(client.reset) {
+			delete(d.clients, key)
+		}
+	}
+
+	client, ok := d.clients[ip]
+	if !ok {
+		client = &directoryClient{
+			reset: now.Add(directoryRateWindow),
+		}
+		d.clients[ip] = client
+	}
+
+	if client.requests >= d.config.DirectoryRateLimit {
+		return false, client.reset
+	}
+
+	client.requests++
+	return true, client.reset
+}
+
+// list returns cached directory, it is refreshed when expired. Requests made
+// while refreshing wait for the result.
+func (d *directory) list(ctx context.Context, refresh func(ctx context.Context) ([]types.DirectoryRoom, error)) ([]types.DirectoryRoom, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.rooms != nil && time.Now().Before(d.expires) {
+		return d.rooms, nil
+	}
+
+	rooms, err := refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.rooms = rooms
+	d.expires = time.Now().Add(time.Duration(d.config.DirectoryCacheSec) * time.Second)
+	return rooms, nil
+}
+
+// directoryRooms returns running rooms marked as listed, with their occupancy.
+func (manager *ApiManagerCtx) directoryRooms(ctx context.Context) ([]types.DirectoryRoom, error) {
+	entries, err := manager.rooms.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := []types.DirectoryRoom{}
+	for _, entry := range entries {
+		if !entry.Listed || !entry.Running || !entry.Profile.IsNeko() {
+			continue
+		}
+
+		settings, err := manager.rooms.GetSettings(ctx, entry.ID)
+		if err != nil {
+			manager.logger.Debug().Err(err).Str("id", entry.ID).Msg("directory: failed to get room settings")
+			continue
+		}
+
+		room := types.DirectoryRoom{
+			Name:           entry.Name,
+			Series:         entry.Series,
+			Description:    entry.Description,
+			Tags:           entry.Tags,
+			MaxConnections: entry.MaxConnections,
+			JoinURL:        entry.URL + "?pwd=" + url.QueryEscape(settings.UserPass),
+		}
+
+		// room could be starting, it is listed without occupancy
+		statsCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if stats, err := manager.rooms.GetStats(statsCtx, entry.ID); err == nil {
+			room.Connections = stats.Connections
+		}
+		cancel()
+
+		rooms = append(rooms, room)
+	}
+
+	rooms = directorySeries(rooms)
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].Name < rooms[j].Name
+	})
+
+	return rooms, nil
+}
+
+// directorySeries keeps only least full room of each series, so that newcomers
+// are spread across rooms of the series.
+func directorySeries(rooms []types.DirectoryRoom) []types.DirectoryRoom {
+	best := map[string]int{} // series -> index of least full room
+	for i, room := range rooms {
+		if room.Series == "" {
+			continue
+		}
+
+		j, ok := best[room.Series]
+		if !ok || directoryLessFull(room, rooms[j]) {
+			best[room.Series] = i
+		}
+	}
+
+	result := []types.DirectoryRoom{}
+	for i, room := range rooms {
+		if j, ok := best[room.Series]; room.Series == "" || ok && i == j {
+			result = append(result, room)
+		}
+	}
+
+	return result
+}
+
+// directoryLessFull returns true, when room a should be preferred over room b.
+func directoryLessFull(a, b types.DirectoryRoom) bool {
+	aFull := a.MaxConnections > 0 && a.Connections >= uint32(a.MaxConnections)
+	bFull := b.MaxConnections > 0 && b.Connections >= uint32(b.MaxConnections)
+	if aFull != bFull {
+		return !aFull
+	}
+
+	return a.Connections < b.Connections
+}
+
+// trusted returns true, if address belongs to a trusted proxy.
+func (d *directory) trusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range d.config.DirectoryTrusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientIP returns address of the client. X-Forwarded-For is walked from the
+// closest hop, only as long as the request was forwarded by trusted proxies.
+func (d *directory) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0 && d.trusted(ip); i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+	}
+
+	return ip
+}
+
+// allowRequest applies rate limit of directory to the request.
+func (manager *ApiManagerCtx) allowRequest(w http.ResponseWriter, r *http.Request) bool {
+	ok, reset := manager.directory.allow(manager.directory.clientIP(r))
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+	}
+
+	return ok
+}
+
+func (manager *ApiManagerCtx) directoryList(w http.ResponseWriter, r *http.Request) {
+	if !manager.allowRequest(w, r) {
+		return
+	}
+
+	// cache is shared by all clients, so it does not depend on request context
+	rooms, err := manager.directory.list(context.Background(), manager.directoryRooms)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("directory: failed to list rooms")
+		http.Error(w, "failed to list rooms", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(manager.config.DirectoryCacheSec))
+	json.NewEncoder(w).Encode(rooms)
+}
+
+// directoryJoin redirects newcomers to the least full room of the series.
+func (manager *ApiManagerCtx) directoryJoin(w http.ResponseWriter, r *http.Request) {
+	series := chi.URLParam(r, "series")
+
+	if !manager.allowRequest(w, r) {
+		return
+	}
+
+	rooms, err := manager.directory.list(context.Background(), manager.directoryRooms)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("directory: failed to list rooms")
+		http.Error(w, "failed to list rooms", 500)
+		return
+	}
+
+	for _, room := range rooms {
+		if room.Series == series {
+			w.Header().Set("Cache-Control", "no-store")
+			http.Redirect(w, r, room.JoinURL, http.StatusFound)
+			return
+		}
+	}
+
+	http.Error(w, "series not found", 404)
+}
+
+// MountPublic mounts endpoints served without admin auth.
+func (manager *ApiManagerCtx) MountPublic(r chi.Router) {
+	if manager.config.DirectoryEnabled {
+		r.Get("/directory", manager.directoryList)
+		r.Get("/directory/{series}", manager.directoryJoin)
+	}
+
+	if manager.config.DeeplinkToken != "" {
+		r.Get("/create", manager.deeplinkCreate)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func TestDirectorySeries(t *testing.T) {
+	tests := []struct {
+		name     string
+		rooms    []types.DirectoryRoom
+		expected []string
+	}{
+		{
+			name: "rooms without series are kept",
+			rooms: []types.DirectoryRoom{
+				{Name: "a", Connections: 5},
+				{Name: "b", Connections: 1},
+			},
+			expected: []string{"a", "b"},
+		},
+		{
+			name: "least full room of series is kept",
+			rooms: []types.DirectoryRoom{
+				{Name: "a", Series: "s", Connections: 3, MaxConnections: 10},
+				{Name: "b"},
+				{Name: "c", Series: "s", Connections: 1, MaxConnections: 10},
+				{Name: "d", Series: "s", Connections: 2, MaxConnections: 10},
+			},
+			expected: []string{"b", "c"},
+		},
+		{
+			name: "full room is not preferred",
+			rooms: []types.DirectoryRoom{
+				{Name: "a", Series: "s", Connections: 2, MaxConnections: 2},
+				{Name: "b", Series: "s", Connections: 7},
+			},
+			expected: []string{"b"},
+		},
+		{
+			name: "first room wins on tie",
+			rooms: []types.DirectoryRoom{
+				{Name: "a", Series: "s", Connections: 1},
+				{Name: "b", Series: "s", Connections: 1},
+			},
+			expected: []string{"a"},
+		},
+		{
+			name: "series are independent",
+			rooms: []types.DirectoryRoom{
+				{Name: "a", Series: "s", Connections: 1},
+				{Name: "b", Series: "t", Connections: 4},
+				{Name: "c", Series: "t", Connections: 3},
+				{Name: "d", Series: "s", Connections: 0},
+			},
+			expected: []string{"c", "d"},
+		},
+		{
+			name:     "no rooms",
+			rooms:    []types.DirectoryRoom{},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{}
+			for _, room := range directorySeries(tt.rooms) {
+				names = append(names, room.Name)
+			}
+
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("directorySeries() = %v, expected %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDirectoryClientIP(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	d := newDirectory(&config.Admin{
+		DirectoryTrusted: []*net.IPNet{trusted},
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{"direct client", "1.2.3.4:1234", nil, "1.2.3.4"},
+		{"untrusted proxy is not followed", "1.2.3.4:1234", []string{"5.6.7.8"}, "1.2.3.4"},
+		{"trusted proxy", "10.0.0.1:1234", []string{"5.6.7.8"}, "5.6.7.8"},
+		{"spoofed hops are ignored", "10.0.0.1:1234", []string{"9.9.9.9, 5.6.7.8"}, "5.6.7.8"},
+		{"chain of trusted proxies", "10.0.0.1:1234", []string{"5.6.7.8, 10.0.0.2"}, "5.6.7.8"},
+		{"multiple headers", "10.0.0.1:1234", []string{"9.9.9.9", "5.6.7.8"}, "5.6.7.8"},
+		{"trusted proxy without header", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"invalid hop", "10.0.0.1:1234", []string{"unknown"}, "10.0.0.1"},
+		{"only trusted hops", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/-/directory", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, val := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", val)
+			}
+
+			if got := d.clientIP(r); got != tt.expected {
+				t.Errorf("clientIP() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
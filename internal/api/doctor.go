@@ -0,0 +1,31 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// doctorCheck runs checks of rooms and host and returns prioritized issues.
+func (manager *ApiManagerCtx) doctorCheck(w http.ResponseWriter, r *http.Request) {
+	response, err := manager.rooms.Doctor(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// doctorRepair recreates rooms with repairable issues.
+func (manager *ApiManagerCtx) doctorRepair(w http.ResponseWriter, r *http.Request) {
+	response, err := manager.rooms.DoctorRepair(r.Context())
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("doctor: failed to repair rooms")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
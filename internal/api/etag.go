@@ -0,0 +1,82 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// settingsETag returns strong ETag, that changes when room settings change.
+func settingsETag(settings *types.RoomSettings) (string, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+
+	return dataETag(data), nil
+}
+
+func dataETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ifMatch returns true, if request has If-Match header that matches etag.
+func ifMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, val := range strings.Split(header, ",") {
+		val = strings.TrimSpace(val)
+		if val == "*" || val == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifNoneMatch returns true, if request has If-None-Match header that matches etag.
+func ifNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, val := range strings.Split(header, ",") {
+		// weak comparison is used for conditional GET
+		val = strings.TrimPrefix(strings.TrimSpace(val), "W/")
+		if val == "*" || val == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeJSONWithETag writes json response with its ETag, or responds with
+// 304 Not Modified when client already has the current version.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, response any) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	etag := dataETag(data)
+	w.Header().Set("ETag", etag)
+
+	if ifNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -5,8 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
 )
 
+const eventsWriteTimeout = 10 * time.Second
+
 func (manager *ApiManagerCtx) events(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
@@ -65,3 +71,135 @@ func (manager *ApiManagerCtx) events(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// eventsWs pushes room and pull events as JSON messages over websocket.
+func (manager *ApiManagerCtx) eventsWs(w http.ResponseWriter, r *http.Request) {
+	websocket.Server{
+		Handshake: sameOriginHandshake,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			events, unsubscribe := manager.eventHub.subscribe()
+			defer unsubscribe()
+
+			// messages from client are not expected, reading detects closed connection
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+
+				var message []byte
+				for {
+					if err := websocket.Message.Receive(ws, &message); err != nil {
+						return
+					}
+				}
+			}()
+
+			for {
+				select {
+				case <-closed:
+					return
+				case e, ok := <-events:
+					if !ok {
+						// dropped for being too slow, client should reconnect
+						return
+					}
+
+					ws.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+					if err := websocket.JSON.Send(ws, e); err != nil {
+						manager.logger.Debug().Err(err).Msg("events: failed to send websocket message")
+						return
+					}
+				}
+			}
+		},
+	}.ServeHTTP(w, r)
+}
+
+// eventsSse streams the same events as eventsWs using server-sent events. Kept
+// events missed since Last-Event-ID are sent first, when some of them are no
+// longer kept, reset event tells client to reload rooms.
+func (manager *ApiManagerCtx) eventsSse(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		// allows resuming for clients, that can not set headers
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	var epoch string
+	var lastSeq uint64
+	if lastEventID != "" {
+		var err error
+		if epoch, lastSeq, err = parseEventID(lastEventID); err != nil {
+			http.Error(w, "invalid Last-Event-ID, must be event id", 400)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Connection does not support streaming", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	backlog, complete, events, unsubscribe := manager.eventHub.resume(epoch, lastSeq)
+	defer unsubscribe()
+
+	if lastEventID != "" && !complete {
+		fmt.Fprintf(w, "event: reset\n")
+		fmt.Fprintf(w, "data: {}\n\n")
+	}
+
+	send := func(e types.Event) bool {
+		jsonData, err := json.Marshal(e)
+		if err != nil {
+			manager.logger.Err(err).Msg("failed to marshal event")
+			return true
+		}
+
+		fmt.Fprintf(w, "id: %s\n", e.ID)
+		fmt.Fprintf(w, "event: %s\n", e.Type)
+		_, err = fmt.Fprintf(w, "data: %s\n\n", jsonData)
+		return err == nil
+	}
+
+	// new clients start with new events
+	if lastEventID != "" {
+		for _, e := range backlog {
+			if !send(e) {
+				return
+			}
+		}
+	}
+
+	flusher.Flush()
+
+	// ping every 1 minute
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprintf(w, ": ping\n\n")
+			flusher.Flush()
+		case e, ok := <-events:
+			if !ok {
+				// dropped for being too slow, client reconnects with last event id
+				return
+			}
+
+			if !send(e) {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
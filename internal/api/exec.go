@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) roomExec(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	request := types.RoomExecRequest{}
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	response, err := manager.rooms.Exec(r.Context(), roomId, request.Command)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else if errors.Is(err, types.ErrExecNotAllowed) {
+			http.Error(w, err.Error(), 403)
+		} else if errors.Is(err, types.ErrExecTimeout) {
+			http.Error(w, err.Error(), 504)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	manager.logger.Info().
+		Str("room", roomId).
+		Str("command", response.Command).
+		Int("exit_code", response.ExitCode).
+		Str("actor", requestActor(r)).
+		Msg("exec: command run inside room")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
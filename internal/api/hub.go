@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	// how many events can wait for slow listener, before it is dropped
+	eventHubBuffer = 64
+	// how many last events are kept for resuming listeners
+	eventHubHistory = 256
+)
+
+// eventHub merges room events and pull progress into one stream of numbered
+// events. Sources are subscribed once, when the first listener arrives, so
+// that slow listeners can not block room events of others.
+type eventHub struct {
+	logger zerolog.Logger
+	rooms  types.RoomManager
+	pull   types.PullManager
+	epoch  string // distinguishes sequences of different runs
+
+	mu        sync.Mutex
+	running   bool
+	seq       uint64
+	history   []types.Event
+	listeners map[chan types.Event]struct{}
+}
+
+func newEventHub(logger zerolog.Logger, rooms types.RoomManager, pull types.PullManager) *eventHub {
+	return &eventHub{
+		logger:    logger,
+		rooms:     rooms,
+		pull:      pull,
+		epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		listeners: map[chan types.Event]struct{}{},
+	}
+}
+
+// parseEventID splits event id to epoch and sequence number. Ids without
+// epoch are accepted, so that they can be recognized as from another run.
+func parseEventID(id string) (string, uint64, error) {
+	epoch, seq := "", id
+	if i := strings.LastIndex(id, "-"); i >= 0 {
+		epoch, seq = id[:i], id[i+1:]
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid event id: %w", err)
+	}
+
+	return epoch, n, nil
+}
+
+// subscribe returns channel of events, it is closed when listener is dropped
+// for being too slow. Returned function must be called to unsubscribe.
+func (h *eventHub) subscribe() (<-chan types.Event, func()) {
+	_, _, ch, unsubscribe := h.resume(h.epoch, 0)
+	return ch, unsubscribe
+}
+
+// resume subscribes and returns kept events following the last event seen by
+// listener. It is not ok, when some events are missing, because they are no
+// longer kept or the event is from another run.
+func (h *eventHub) resume(epoch string, lastSeq uint64) ([]types.Event, bool, <-chan types.Event, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ok := epoch == h.epoch && lastSeq <= h.seq
+	if ok && len(h.history) > 0 && lastSeq+1 < h.history[0].Seq {
+		ok = false
+	}
+
+	backlog := []types.Event{}
+	for _, event := range h.history {
+		if ok && event.Seq > lastSeq {
+			backlog = append(backlog, event)
+		}
+	}
+
+	if !h.running {
+		h.running = true
+		go h.run()
+	}
+
+	ch := make(chan types.Event, eventHubBuffer)
+	h.listeners[ch] = struct{}{}
+
+	return backlog, ok, ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.listeners[ch]; ok {
+			delete(h.listeners, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *eventHub) publish(event types.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event.Seq = h.seq
+	event.ID = h.epoch + "-" + strconv.FormatUint(h.seq, 10)
+	event.Time = time.Now()
+
+	h.history = append(h.history, event)
+	if len(h.history) > eventHubHistory {
+		h.history = h.history[len(h.history)-eventHubHistory:]
+	}
+
+	for ch := range h.listeners {
+		select {
+		case ch <- event:
+		default:
+			h.logger.Warn().Msg("events: listener is too slow, dropping it")
+			delete(h.listeners, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *eventHub) run() {
+	pullCh := make(chan string)
+	h.pull.Subscribe(pullCh)
+
+	roomEvents, errs := h.rooms.Events(context.Background())
+
+	for {
+		select {
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+
+			h.logger.Err(err).Msg("events: room events closed")
+		case e := <-roomEvents:
+			h.publish(types.Event{
+				Type:   types.EventRoom,
+				RoomID: e.ID,
+				Name:   e.ContainerLabels["m1k1o.neko_rooms.name"],
+				Action: e.Action,
+			})
+		case data := <-pullCh:
+			layer := types.PullLayer{}
+			if err := json.Unmarshal([]byte(data), &layer); err != nil {
+				h.logger.Debug().Err(err).Msg("events: failed to parse pull progress")
+				continue
+			}
+
+			h.publish(types.Event{
+				Type: types.EventPull,
+				Pull: &layer,
+			})
+		}
+	}
+}
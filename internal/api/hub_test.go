@@ -0,0 +1,103 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func TestParseEventID(t *testing.T) {
+	tests := []struct {
+		id    string
+		epoch string
+		seq   uint64
+		err   bool
+	}{
+		{"lx2k9c3f1m-42", "lx2k9c3f1m", 42, false},
+		{"lx2k9c3f1m-0", "lx2k9c3f1m", 0, false},
+		{"42", "", 42, false}, // from before epochs were used
+		{"lx2k9c3f1m-", "", 0, true},
+		{"lx2k9c3f1m-x", "", 0, true},
+		{"", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		epoch, seq, err := parseEventID(tt.id)
+		if (err != nil) != tt.err {
+			t.Errorf("parseEventID(%q) error = %v, expected error %v", tt.id, err, tt.err)
+			continue
+		}
+		if epoch != tt.epoch || seq != tt.seq {
+			t.Errorf("parseEventID(%q) = %q, %d, expected %q, %d", tt.id, epoch, seq, tt.epoch, tt.seq)
+		}
+	}
+}
+
+func TestEventHubResume(t *testing.T) {
+	tests := []struct {
+		name      string
+		published int
+		epoch     string
+		lastSeq   uint64
+		ok        bool
+		backlog   []uint64
+	}{
+		{"missed events", 5, "epoch", 2, true, []uint64{3, 4, 5}},
+		{"nothing missed", 5, "epoch", 5, true, []uint64{}},
+		{"nothing published", 0, "epoch", 0, true, []uint64{}},
+		{"another epoch", 5, "other", 2, false, []uint64{}},
+		{"without epoch", 5, "", 2, false, []uint64{}},
+		{"sequence from the future", 5, "epoch", 10, false, []uint64{}},
+		{"oldest kept event", eventHubHistory + 10, "epoch", 10, true, nil},
+		{"events no longer kept", eventHubHistory + 10, "epoch", 9, false, []uint64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// sources are not needed, hub is marked as running
+			hub := &eventHub{
+				epoch:     "epoch",
+				running:   true,
+				listeners: map[chan types.Event]struct{}{},
+			}
+
+			for i := 0; i < tt.published; i++ {
+				hub.publish(types.Event{Type: types.EventRoom})
+			}
+
+			backlog, ok, _, unsubscribe := hub.resume(tt.epoch, tt.lastSeq)
+			defer unsubscribe()
+
+			if ok != tt.ok {
+				t.Errorf("resume ok = %v, expected %v", ok, tt.ok)
+			}
+
+			// whole history is expected, when backlog is not specified
+			if tt.backlog == nil {
+				if len(backlog) != eventHubHistory {
+					t.Errorf("resume returned %d events, expected %d", len(backlog), eventHubHistory)
+				}
+				return
+			}
+
+			seqs := []uint64{}
+			for _, e := range backlog {
+				seqs = append(seqs, e.Seq)
+				if e.ID != hub.epoch+"-"+strconv.FormatUint(e.Seq, 10) {
+					t.Errorf("event id %q does not contain epoch and sequence", e.ID)
+				}
+			}
+
+			if len(seqs) != len(tt.backlog) {
+				t.Fatalf("resume returned %v, expected %v", seqs, tt.backlog)
+			}
+			for i := range seqs {
+				if seqs[i] != tt.backlog[i] {
+					t.Errorf("resume returned %v, expected %v", seqs, tt.backlog)
+					break
+				}
+			}
+		})
+	}
+}
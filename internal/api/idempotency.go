@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// how long are responses to idempotent requests kept
+const idempotencyTTL = 10 * time.Minute
+
+type idempotencyEntry struct {
+	hash    string // of request query and body
+	done    chan struct{}
+	expires time.Time
+
+	status int
+	header http.Header
+	body   []byte
+}
+
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{
+		entries: map[string]*idempotencyEntry{},
+	}
+}
+
+// acquire returns existing entry for the key, or creates new one. When new
+// entry is created, caller is responsible for finishing or releasing it.
+func (c *idempotencyCache) acquire(key, hash string) (entry *idempotencyEntry, created bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// remove expired entries
+	now := time.Now()
+	for k, e := range c.entries {
+		if !e.expires.IsZero() && now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	if entry, ok := c.entries[key]; ok {
+		return entry, false
+	}
+
+	entry = &idempotencyEntry{
+		hash: hash,
+		done: make(chan struct{}),
+	}
+	c.entries[key] = entry
+	return entry, true
+}
+
+func (c *idempotencyCache) finish(entry *idempotencyEntry) {
+	c.mu.Lock()
+	entry.expires = time.Now().Add(idempotencyTTL)
+	c.mu.Unlock()
+
+	close(entry.done)
+}
+
+// release removes entry, so that the request can be retried.
+func (c *idempotencyCache) release(key string, entry *idempotencyEntry) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+
+	close(entry.done)
+}
+
+func (c *idempotencyCache) size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func writeRecorded(w http.ResponseWriter, status int, header http.Header, body []byte) {
+	for key, val := range header {
+		w.Header()[key] = val
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// requestHash returns hash of request query and body, body is kept readable.
+func requestHash(r *http.Request) (string, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	h := sha256.New()
+	h.Write([]byte(r.URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// idempotent deduplicates requests with the same Idempotency-Key header. Retried
+// request receives the response of the original request, if it is still in progress,
+// it waits for it. Server errors are not cached, so that the request can be retried.
+// Reusing the key for a different request is rejected.
+func (manager *ApiManagerCtx) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey := r.Header.Get("Idempotency-Key")
+		if idempotencyKey == "" {
+			next(w, r)
+			return
+		}
+
+		hash, err := requestHash(r)
+		if err != nil {
+			http.Error(w, err.Error(), decodeErrorStatus(err))
+			return
+		}
+
+		// keys are scoped per endpoint and user
+		key := r.Method + " " + r.URL.Path + " " + requestActor(r) + " " + idempotencyKey
+
+		entry, created := manager.idempotency.acquire(key, hash)
+		if !created {
+			if entry.hash != hash {
+				http.Error(w, "idempotency key was already used for a different request", http.StatusUnprocessableEntity)
+				return
+			}
+
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				return
+			}
+
+			// original request was released, retry it
+			if entry.status == 0 {
+				manager.idempotent(next)(w, r)
+				return
+			}
+
+			w.Header().Set("Idempotent-Replayed", "true")
+			writeRecorded(w, entry.status, entry.header, entry.body)
+			return
+		}
+
+		rec := &responseRecorder{
+			header: http.Header{},
+		}
+
+		defer func() {
+			if rec.status == 0 || rec.status >= 500 {
+				manager.idempotency.release(key, entry)
+			}
+		}()
+
+		next(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		writeRecorded(w, rec.status, rec.header, rec.body.Bytes())
+
+		if rec.status < 500 {
+			entry.status = rec.status
+			entry.header = rec.header
+			entry.body = rec.body.Bytes()
+			manager.idempotency.finish(entry)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	cache := newIdempotencyCache()
+
+	// first request creates entry
+	entry, created := cache.acquire("a", "hash")
+	if !created {
+		t.Fatalf("acquire should have created entry for a")
+	}
+
+	// retried request waits for the same entry
+	waiter, created := cache.acquire("a", "hash")
+	if created || waiter != entry {
+		t.Fatalf("acquire should have returned existing entry for a")
+	}
+
+	// finished entry is kept and its waiters are woken up
+	cache.finish(entry)
+	select {
+	case <-waiter.done:
+	default:
+		t.Errorf("finish should have closed done channel")
+	}
+	if _, created := cache.acquire("a", "hash"); created {
+		t.Errorf("finished entry should have been kept for a")
+	}
+
+	// released entry is removed and its waiters are woken up
+	entry, _ = cache.acquire("b", "hash")
+	cache.release("b", entry)
+	select {
+	case <-entry.done:
+	default:
+		t.Errorf("release should have closed done channel")
+	}
+	if _, created := cache.acquire("b", "hash"); !created {
+		t.Errorf("released entry should have been removed for b")
+	}
+
+	// expired entries are removed
+	entry, _ = cache.acquire("c", "hash")
+	cache.finish(entry)
+	entry.expires = time.Now().Add(-time.Second)
+	if _, created := cache.acquire("c", "hash"); !created {
+		t.Errorf("expired entry should have been removed for c")
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		status   int
+		calls    int32
+		replayed bool
+	}{
+		{"without key", "", http.StatusCreated, 2, false},
+		{"replayed", "key", http.StatusCreated, 1, true},
+		{"client error is replayed", "key", http.StatusConflict, 1, true},
+		{"server error is retried", "key", http.StatusInternalServerError, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &ApiManagerCtx{idempotency: newIdempotencyCache()}
+
+			var calls atomic.Int32
+			handler := manager.idempotent(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				http.Error(w, "response", tt.status)
+			})
+
+			var res *httptest.ResponseRecorder
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/api/rooms", nil)
+				if tt.key != "" {
+					req.Header.Set("Idempotency-Key", tt.key)
+				}
+
+				res = httptest.NewRecorder()
+				handler(res, req)
+
+				if res.Code != tt.status {
+					t.Errorf("request %d: status = %d, expected %d", i, res.Code, tt.status)
+				}
+			}
+
+			if got := calls.Load(); got != tt.calls {
+				t.Errorf("handler called %d times, expected %d", got, tt.calls)
+			}
+
+			if replayed := res.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.replayed {
+				t.Errorf("replayed = %v, expected %v", replayed, tt.replayed)
+			}
+		})
+	}
+}
+
+func TestIdempotentDifferentRequest(t *testing.T) {
+	manager := &ApiManagerCtx{idempotency: newIdempotencyCache()}
+
+	var calls atomic.Int32
+	handler := manager.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		body   string
+		status int
+	}{
+		{"original", "", `{"name":"foo"}`, http.StatusCreated},
+		{"retried", "", `{"name":"foo"}`, http.StatusCreated},
+		{"different body", "", `{"name":"bar"}`, http.StatusUnprocessableEntity},
+		{"different query", "?start=true", `{"name":"foo"}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/rooms"+tt.query, strings.NewReader(tt.body))
+		req.Header.Set("Idempotency-Key", "key")
+
+		res := httptest.NewRecorder()
+		handler(res, req)
+
+		if res.Code != tt.status {
+			t.Errorf("%s: status = %d, expected %d", tt.name, res.Code, tt.status)
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, expected 1", got)
+	}
+}
+
+func TestIdempotentWaiter(t *testing.T) {
+	manager := &ApiManagerCtx{idempotency: newIdempotencyCache()}
+
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+
+	var calls atomic.Int32
+	handler := manager.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-proceed
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/rooms", nil)
+		req.Header.Set("Idempotency-Key", "key")
+		res := httptest.NewRecorder()
+		handler(res, req)
+		return res
+	}
+
+	go request()
+	<-started
+
+	// retried request waits for the original one in progress
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- request() }()
+
+	select {
+	case <-done:
+		t.Fatalf("retried request should have waited for original request")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(proceed)
+
+	res := <-done
+	if res.Code != http.StatusCreated || res.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retried request should have been replayed, got %d", res.Code)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, expected 1", got)
+	}
+}
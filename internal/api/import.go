@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// composeImports lists neko services of compose projects, that can be imported as rooms.
+func (manager *ApiManagerCtx) composeImports(w http.ResponseWriter, r *http.Request) {
+	response, err := manager.rooms.ComposeImports(r.Context(), r.URL.Query().Get("project"))
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// composeImport converts services of compose project to rooms.
+func (manager *ApiManagerCtx) composeImport(w http.ResponseWriter, r *http.Request) {
+	request := types.RoomImportRequest{}
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	if request.Project == "" {
+		http.Error(w, "project must be specified", 400)
+		return
+	}
+
+	response, err := manager.rooms.ImportCompose(r.Context(), request)
+	if err != nil {
+		manager.logger.Error().Err(err).Str("project", request.Project).Msg("import: failed to import compose project")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
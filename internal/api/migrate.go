@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// roomsMigrate recreates all rooms, whose labels do not match current config
+// or labels schema. When dry_run is set, only list of changes is returned.
+func (manager *ApiManagerCtx) roomsMigrate(w http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	if s := r.URL.Query().Get("dry_run"); s != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+
+	var response []types.RoomMigration
+	var err error
+	if dryRun {
+		response, err = manager.rooms.Migrations(r.Context())
+	} else {
+		response, err = manager.rooms.Migrate(r.Context())
+	}
+
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("migrate: failed to migrate rooms")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const policyTimeout = 5 * time.Second
+
+var errPolicyDenied = errors.New("denied by policy")
+
+type policyCapacity struct {
+	Rooms        int  `json:"rooms"`
+	Running      int  `json:"running"`
+	DiskPressure bool `json:"disk_pressure"`
+}
+
+type policyInput struct {
+	Action   string              `json:"action"` // e.g. create, update, remove, start or stop
+	Actor    string              `json:"actor"`
+	RoomID   string              `json:"room_id,omitempty"`
+	Settings *types.RoomSettings `json:"settings,omitempty"`
+	Capacity policyCapacity      `json:"capacity"`
+}
+
+// checkPolicy asks Open Policy Agent, whether the action is allowed. Decision can
+// be either boolean or an object with allow and optional reason fields.
+func (manager *ApiManagerCtx) checkPolicy(r *http.Request, action, roomId string, settings *types.RoomSettings) error {
+	if manager.config.PolicyUrl == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), policyTimeout)
+	defer cancel()
+
+	rooms, err := manager.rooms.List(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	capacity := policyCapacity{
+		Rooms:        len(rooms),
+		DiskPressure: manager.rooms.DiskPressure(),
+	}
+	for _, room := range rooms {
+		if room.Running {
+			capacity.Running++
+		}
+	}
+
+	// passwords are not shared with policy agent
+	if settings != nil {
+		redacted := *settings
+		redacted.UserPass = ""
+		redacted.AdminPass = ""
+		settings = &redacted
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"input": policyInput{
+			Action:   action,
+			Actor:    requestActor(r),
+			RoomID:   roomId,
+			Settings: settings,
+			Capacity: capacity,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, manager.config.PolicyUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("policy request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("policy request failed with status %d", res.StatusCode)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return fmt.Errorf("invalid policy response: %w", err)
+	}
+
+	// undefined decision is treated as deny
+	if len(response.Result) == 0 {
+		return errPolicyDenied
+	}
+
+	var allow bool
+	if err := json.Unmarshal(response.Result, &allow); err == nil {
+		if !allow {
+			return errPolicyDenied
+		}
+		return nil
+	}
+
+	var decision struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(response.Result, &decision); err != nil {
+		return fmt.Errorf("invalid policy decision: %w", err)
+	}
+
+	if !decision.Allow {
+		if decision.Reason != "" {
+			return fmt.Errorf("%w: %s", errPolicyDenied, decision.Reason)
+		}
+		return errPolicyDenied
+	}
+
+	return nil
+}
+
+// policyError writes error returned by checkPolicy.
+func (manager *ApiManagerCtx) policyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errPolicyDenied) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	manager.logger.Error().Err(err).Msg("policy: failed to evaluate policy")
+	http.Error(w, err.Error(), 500)
+}
+
+// withPolicy checks policy for actions without request body.
+func (manager *ApiManagerCtx) withPolicy(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := manager.checkPolicy(r, action, chi.URLParam(r, "roomId"), nil); err != nil {
+			manager.policyError(w, err)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// profileErrorStatus maps profile errors to http status codes.
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, types.ErrProfileNotFound), errors.Is(err, types.ErrRoomNotFound):
+		return 404
+	case errors.Is(err, types.ErrProfileForbidden):
+		return 403
+	case errors.Is(err, types.ErrProfileQuota):
+		return 409
+	default:
+		return 500
+	}
+}
+
+func (manager *ApiManagerCtx) profilesList(w http.ResponseWriter, r *http.Request) {
+	// only own profiles are listed, unless all are requested
+	owner := requestActor(r)
+	if r.URL.Query().Get("all") == "true" {
+		owner = ""
+	}
+
+	response, err := manager.rooms.ListProfiles(owner)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) profileSave(w http.ResponseWriter, r *http.Request) {
+	var request types.BrowserProfileRequest
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	// snapshot takes more space, same as new room
+	if manager.rooms.DiskPressure() {
+		http.Error(w, "disk usage is above threshold", 500)
+		return
+	}
+
+	response, err := manager.rooms.SaveProfile(r.Context(), request.RoomID, request, requestActor(r))
+	if err != nil {
+		status := profileErrorStatus(err)
+		if status == 500 {
+			manager.logger.Error().Err(err).Msg("profiles: failed to save profile")
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) profileGet(w http.ResponseWriter, r *http.Request) {
+	response, err := manager.rooms.GetProfile(chi.URLParam(r, "profileName"))
+	if err != nil {
+		http.Error(w, err.Error(), profileErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) profileRemove(w http.ResponseWriter, r *http.Request) {
+	err := manager.rooms.RemoveProfile(chi.URLParam(r, "profileName"), requestActor(r))
+	if err != nil {
+		http.Error(w, err.Error(), profileErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
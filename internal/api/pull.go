@@ -11,8 +11,8 @@ import (
 func (manager *ApiManagerCtx) pullStart(w http.ResponseWriter, r *http.Request) {
 	request := types.PullStart{}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, err.Error(), 400)
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
 		return
 	}
 
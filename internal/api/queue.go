@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// how often is queue retried, when no room was destroyed in the meantime
+const queueRetryInterval = 30 * time.Second
+
+type queueItem struct {
+	types.RoomQueueItem
+
+	settings    types.RoomSettings
+	start       bool
+	callbackUrl string
+}
+
+type roomQueue struct {
+	mu      sync.Mutex
+	items   []*queueItem
+	running bool
+}
+
+func newRoomQueue() *roomQueue {
+	return &roomQueue{
+		items: []*queueItem{},
+	}
+}
+
+func (q *roomQueue) list() []types.RoomQueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]types.RoomQueueItem, len(q.items))
+	for i, item := range q.items {
+		result[i] = item.RoomQueueItem
+		result[i].Position = i + 1
+	}
+	return result
+}
+
+func (q *roomQueue) get(id string) (types.RoomQueueItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, item := range q.items {
+		if item.ID == id {
+			result := item.RoomQueueItem
+			result.Position = i + 1
+			return result, true
+		}
+	}
+	return types.RoomQueueItem{}, false
+}
+
+func (q *roomQueue) remove(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, item := range q.items {
+		if item.ID == id {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (q *roomQueue) head() (*queueItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		// worker exits when queue is empty
+		q.running = false
+		return nil, false
+	}
+	return q.items[0], true
+}
+
+// enqueue adds request to the queue and starts worker, if it is not running.
+func (manager *ApiManagerCtx) enqueue(settings types.RoomSettings, start bool, callbackUrl string) (types.RoomQueueItem, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return types.RoomQueueItem{}, err
+	}
+
+	item := &queueItem{
+		RoomQueueItem: types.RoomQueueItem{
+			ID:      hex.EncodeToString(id),
+			Name:    settings.Name,
+			Created: time.Now(),
+		},
+
+		settings:    settings,
+		start:       start,
+		callbackUrl: callbackUrl,
+	}
+
+	q := manager.queue
+	q.mu.Lock()
+	q.items = append(q.items, item)
+	result := item.RoomQueueItem
+	result.Position = len(q.items)
+
+	if !q.running {
+		q.running = true
+		go manager.queueWorker()
+	}
+	q.mu.Unlock()
+
+	manager.logger.Info().
+		Str("queue_id", result.ID).
+		Str("name", result.Name).
+		Int("position", result.Position).
+		Msg("queue: room enqueued")
+
+	return result, nil
+}
+
+// queueWorker creates queued rooms in order, as soon as capacity is available.
+func (manager *ApiManagerCtx) queueWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs := manager.rooms.Events(ctx)
+
+	// retry when any room is destroyed
+	trigger := make(chan struct{}, 1)
+	go func() {
+		// drain events until listener is removed
+		for {
+			select {
+			case e := <-events:
+				if e.Action != types.RoomEventDestroyed {
+					continue
+				}
+
+				select {
+				case trigger <- struct{}{}:
+				default:
+				}
+			case _, ok := <-errs:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	defer cancel()
+
+	ticker := time.NewTicker(queueRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			item, ok := manager.queue.head()
+			if !ok {
+				return
+			}
+
+			if !manager.queueProcess(item) {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-trigger:
+		}
+	}
+}
+
+// queueProcess tries to create queued room, returns false if there is still not enough capacity.
+func (manager *ApiManagerCtx) queueProcess(item *queueItem) bool {
+	logger := manager.logger.With().Str("queue_id", item.ID).Str("name", item.Name).Logger()
+	ctx := context.Background()
+
+	ID, err := manager.createRoom(ctx, item.settings)
+	if errors.Is(err, types.ErrNotEnoughCapacity) {
+		return false
+	}
+
+	// item could have been cancelled in the meantime
+	if !manager.queue.remove(item.ID) {
+		if err == nil {
+			logger.Warn().Str("id", ID).Msg("queue: item was cancelled, removing created room")
+			if err := manager.rooms.Remove(ctx, ID); err != nil {
+				logger.Err(err).Msg("queue: failed to remove room")
+			}
+		}
+		return true
+	}
+
+	if err != nil {
+		logger.Err(err).Msg("queue: failed to create room")
+		if item.callbackUrl != "" {
+			manager.sendCallback("", item.callbackUrl, types.RoomCallbackFailed, err)
+		}
+		return true
+	}
+
+	logger.Info().Str("id", ID).Msg("queue: room created")
+
+	// notify when room becomes ready or fails
+	callbackFail := func(err error) {}
+	if item.callbackUrl != "" {
+		if item.start {
+			callbackFail = manager.roomCallback(ID, item.callbackUrl)
+		} else {
+			go manager.sendCallback(ID, item.callbackUrl, types.RoomCallbackCreated, nil)
+		}
+	}
+
+	if item.start {
+		if err := manager.rooms.Start(ctx, ID); err != nil {
+			logger.Err(err).Msg("queue: failed to start room")
+			callbackFail(err)
+		}
+	}
+
+	return true
+}
+
+func (manager *ApiManagerCtx) queueList(w http.ResponseWriter, r *http.Request) {
+	response := manager.queue.list()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) queueGet(w http.ResponseWriter, r *http.Request) {
+	queueId := chi.URLParam(r, "queueId")
+
+	response, ok := manager.queue.get(queueId)
+	if !ok {
+		http.Error(w, "queue item not found", 404)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) queueRemove(w http.ResponseWriter, r *http.Request) {
+	queueId := chi.URLParam(r, "queueId")
+
+	if !manager.queue.remove(queueId) {
+		http.Error(w, "queue item not found", 404)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
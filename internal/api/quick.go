@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/policies"
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// quickSettings returns settings of a room, that opens given url in the first
+// whitelisted browser image with known browser policy.
+func (manager *ApiManagerCtx) quickSettings(request types.QuickRoomRequest) (*types.RoomSettings, error) {
+	u, err := url.Parse(request.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url, must be http or https url")
+	}
+
+	if request.Lifetime < 0 {
+		return nil, fmt.Errorf("invalid lifetime, must not be negative")
+	}
+
+	config := manager.rooms.Config()
+	if !config.StorageEnabled {
+		return nil, fmt.Errorf("storage needs to be enabled, because url is set using browser policy")
+	}
+
+	var browserPolicy *types.BrowserPolicy
+	var nekoImage string
+	for _, image := range config.NekoImages {
+		if policyType, policyPath, ok := policies.ForImage(image); ok {
+			nekoImage = image
+			browserPolicy = &types.BrowserPolicy{
+				Type: policyType,
+				Path: policyPath,
+				Content: types.BrowserPolicyContent{
+					Extensions: []types.BrowserPolicyExtension{},
+					Homepage:   request.URL,
+				},
+			}
+			break
+		}
+	}
+
+	if browserPolicy == nil {
+		return nil, fmt.Errorf("no browser image with known browser policy is available")
+	}
+
+	userPass, err := utils.NewUID(16)
+	if err != nil {
+		return nil, err
+	}
+
+	adminPass, err := utils.NewUID(16)
+	if err != nil {
+		return nil, err
+	}
+
+	// same defaults as when creating a room
+	settings := &config.Defaults
+	settings.NekoImage = nekoImage
+	settings.ImplicitControl = true
+	settings.UserPass = userPass
+	settings.AdminPass = adminPass
+	settings.BrowserPolicy = browserPolicy
+
+	if request.Lifetime > 0 {
+		expires := time.Now().Add(time.Duration(request.Lifetime) * time.Second).Truncate(time.Second)
+		settings.Expires = &expires
+	}
+
+	return settings, nil
+}
+
+func (manager *ApiManagerCtx) roomQuick(w http.ResponseWriter, r *http.Request) {
+	request := types.QuickRoomRequest{}
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	settings, err := manager.quickSettings(request)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	if err := manager.checkPolicy(r, "create", "", settings); err != nil {
+		manager.policyError(w, err)
+		return
+	}
+
+	ID, err := manager.createRoom(r.Context(), *settings)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("quick: failed to create room")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if err := manager.rooms.Start(r.Context(), ID); err != nil {
+		manager.logger.Error().Err(err).Msg("quick: failed to start room")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	entry, err := manager.rooms.GetEntry(r.Context(), ID)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("quick: failed to get room entry")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	manager.logCredentialsAccess(entry.UUID, requestActor(r), manager.directory.clientIP(r), "quick")
+
+	response := types.QuickRoom{
+		ID:        entry.ID,
+		Name:      entry.Name,
+		URL:       entry.URL,
+		InviteURL: entry.URL + "?pwd=" + url.QueryEscape(settings.UserPass),
+		Expires:   entry.Expires,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) roomRename(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	request := types.RoomRenameRequest{}
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	settings, err := manager.rooms.GetSettings(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	settings.Name = request.Name
+	if err := manager.checkPolicy(r, "update", roomId, settings); err != nil {
+		manager.policyError(w, err)
+		return
+	}
+
+	ID, err := manager.rooms.Rename(r.Context(), roomId, request.Name)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else if errors.Is(err, types.ErrRoomNameTaken) {
+			http.Error(w, err.Error(), 409)
+		} else {
+			manager.logger.Error().Err(err).Str("id", roomId).Msg("rename: failed to rename room")
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	response, err := manager.rooms.GetEntry(r.Context(), ID)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("rename: failed to get room entry")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// limitRequest enforces max request body size and JSON content type.
+func (manager *ApiManagerCtx) limitRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if manager.config.MaxBodySize > 0 {
+			if r.ContentLength > manager.config.MaxBodySize {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, manager.config.MaxBodySize)
+		}
+
+		// only requests with body need to have content type
+		if r.ContentLength > 0 || len(r.TransferEncoding) > 0 {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeRequest decodes JSON request body. Unknown fields are rejected, logged
+// or ignored, depending on json mode.
+func (manager *ApiManagerCtx) decodeRequest(w http.ResponseWriter, r *http.Request, v any) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return io.EOF
+	}
+
+	if manager.config.JsonMode == "lenient" {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(v)
+	// there is no specific error type for unknown fields
+	if err == nil || !strings.HasPrefix(err.Error(), "json: unknown field ") || manager.config.JsonMode == "strict" {
+		return err
+	}
+
+	manager.logger.Warn().Err(err).Str("path", r.URL.Path).Msg("request contains unknown field")
+	w.Header().Add("Warning", fmt.Sprintf("299 - %q", err.Error()))
+
+	return json.Unmarshal(data, v)
+}
+
+// decodeErrorStatus returns http status code for request decoding error.
+func decodeErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) rolloutsList(w http.ResponseWriter, r *http.Request) {
+	response := manager.rooms.Rollouts(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) rolloutCreate(w http.ResponseWriter, r *http.Request) {
+	var request types.RoomRolloutRequest
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	response, err := manager.rooms.CreateRollout(r.Context(), request)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("rollout: failed to create rollout")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) rolloutAction(action func(ctx context.Context, id string) (*types.RoomRollout, error)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rolloutId := chi.URLParam(r, "rolloutId")
+
+		response, err := action(r.Context(), rolloutId)
+		if err != nil {
+			if errors.Is(err, types.ErrRolloutNotFound) {
+				http.Error(w, err.Error(), 404)
+			} else {
+				http.Error(w, err.Error(), 500)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
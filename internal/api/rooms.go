@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -13,11 +15,73 @@ import (
 
 	"github.com/m1k1o/neko-rooms/internal/room"
 	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
 )
 
+// roomMatches returns true, if room has all tags and contains text in its name, description,
+// contact or tags.
+func roomMatches(entry types.RoomEntry, tags []string, text string) bool {
+	for _, tag := range tags {
+		if in, _ := utils.ArrayIn(tag, entry.Tags); !in {
+			return false
+		}
+	}
+
+	if text == "" {
+		return true
+	}
+
+	text = strings.ToLower(text)
+	for _, val := range append([]string{entry.Name, entry.Description, entry.Contact}, entry.Tags...) {
+		if strings.Contains(strings.ToLower(val), text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortRooms sorts rooms in place. By last activity, rooms that were not used for
+// the longest time (or never) come first, so that stale rooms are easy to find.
+func sortRooms(entries []types.RoomEntry, sortBy string) {
+	switch sortBy {
+	case "name":
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Name < entries[j].Name
+		})
+	case "created":
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Created.Before(entries[j].Created)
+		})
+	case "last_activity":
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i].LastActivity, entries[j].LastActivity
+			if a == nil || b == nil {
+				return a == nil && b != nil
+			}
+			return a.Before(*b)
+		})
+	}
+}
+
 func (manager *ApiManagerCtx) roomsList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// metadata filters, labels can not contain underscore so they do not collide
+	filterTags := query["filter_tag"]
+	filterText := query.Get("filter_text")
+	query.Del("filter_tag")
+	query.Del("filter_text")
+
+	sortBy := query.Get("sort_by")
+	query.Del("sort_by")
+	if sortBy != "" && sortBy != "name" && sortBy != "created" && sortBy != "last_activity" {
+		http.Error(w, "invalid sort_by, must be one of name, created or last_activity", 400)
+		return
+	}
+
 	labelsMap := map[string]string{}
-	for key, value := range r.URL.Query() {
+	for key, value := range query {
 		key = strings.ToLower(key)
 
 		if !room.CheckLabelKey(key) {
@@ -28,12 +92,29 @@ func (manager *ApiManagerCtx) roomsList(w http.ResponseWriter, r *http.Request)
 		labelsMap[key] = value[0]
 	}
 
-	response, err := manager.rooms.List(r.Context(), labelsMap)
+	entries, err := manager.rooms.List(r.Context(), labelsMap)
 	if err != nil {
 		http.Error(w, err.Error(), 500)
 		return
 	}
 
+	response := make([]types.RoomEntry, 0, len(entries))
+	for _, entry := range entries {
+		if roomMatches(entry, filterTags, filterText) {
+			response = append(response, entry)
+		}
+	}
+
+	sortRooms(response, sortBy)
+
+	if err := writeJSONWithETag(w, r, response); err != nil {
+		manager.logger.Error().Err(err).Msg("list: failed to write response")
+	}
+}
+
+func (manager *ApiManagerCtx) roomsTiering(w http.ResponseWriter, r *http.Request) {
+	response := manager.rooms.Tiering()
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -49,29 +130,75 @@ func (manager *ApiManagerCtx) roomCreate(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	var queue bool
+	if s := r.URL.Query().Get("queue"); s != "" {
+		var err error
+		queue, err = strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+
 	// Default values
-	request := types.RoomSettings{
-		MaxConnections: 10,
-		Resources: types.RoomResources{
-			ShmSize: 2 * 1e9,
-		},
+	request := types.RoomCreateRequest{
+		RoomSettings: manager.rooms.Config().Defaults,
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, err.Error(), 400)
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	callbackUrl := request.CallbackUrl
+	if callbackUrl != "" {
+		if err := parseCallbackUrl(callbackUrl); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+
+	settings := request.RoomSettings
+	if err := manager.checkPolicy(r, "create", "", &settings); err != nil {
+		manager.policyError(w, err)
+		return
+	}
+
+	ID, err := manager.createRoom(r.Context(), settings)
+	if err != nil && queue && errors.Is(err, types.ErrNotEnoughCapacity) {
+		response, err := manager.enqueue(settings, start, callbackUrl)
+		if err != nil {
+			manager.logger.Error().Err(err).Msg("create: failed to enqueue room")
+			http.Error(w, err.Error(), 500)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(response)
 		return
 	}
 
-	ID, err := manager.rooms.Create(r.Context(), request)
 	if err != nil {
 		manager.logger.Error().Err(err).Msg("create: failed to create room")
 		http.Error(w, err.Error(), 500)
 		return
 	}
 
+	// notify when room becomes ready or fails
+	callbackFail := func(err error) {}
+	if callbackUrl != "" {
+		if start {
+			callbackFail = manager.roomCallback(ID, callbackUrl)
+		} else {
+			go manager.sendCallback(ID, callbackUrl, types.RoomCallbackCreated, nil)
+		}
+	}
+
 	if start {
 		if err := manager.rooms.Start(r.Context(), ID); err != nil {
 			manager.logger.Error().Err(err).Msg("create: failed to start room")
+			callbackFail(err)
 			http.Error(w, err.Error(), 500)
 			return
 		}
@@ -88,6 +215,16 @@ func (manager *ApiManagerCtx) roomCreate(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// createRoom creates new room, unless there is disk pressure. Recreating
+// existing rooms is allowed, as it does not take more space.
+func (manager *ApiManagerCtx) createRoom(ctx context.Context, settings types.RoomSettings) (string, error) {
+	if manager.rooms.DiskPressure() {
+		return "", fmt.Errorf("disk usage is above threshold: %w", types.ErrNotEnoughCapacity)
+	}
+
+	return manager.rooms.Create(ctx, settings)
+}
+
 func (manager *ApiManagerCtx) roomRecreate(w http.ResponseWriter, r *http.Request) {
 	roomId := chi.URLParam(r, "roomId")
 
@@ -125,9 +262,52 @@ func (manager *ApiManagerCtx) roomRecreate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// settings could have been changed by someone else in the meantime
+	if r.Header.Get("If-Match") == "" {
+		http.Error(w, "If-Match header with settings etag is required", http.StatusPreconditionRequired)
+		return
+	}
+
+	etag, err := settingsETag(settings)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("recreate: failed to compute etag")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if !ifMatch(r, etag) {
+		http.Error(w, "room settings have been changed", http.StatusPreconditionFailed)
+		return
+	}
+
+	current, err := copySettings(settings)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("recreate: failed to copy room settings")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
 	// optional settings payload
-	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil && !errors.Is(err, io.EOF) {
-		http.Error(w, err.Error(), 400)
+	if err := manager.decodeRequest(w, r, &settings); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	if err := manager.checkPolicy(r, "update", roomId, settings); err != nil {
+		manager.policyError(w, err)
+		return
+	}
+
+	diff, err := diffSettings(current, *settings)
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("recreate: failed to compare settings")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	// limits are changed without recreating the container
+	if len(diff.Changes) > 0 && !diff.Recreate {
+		manager.roomUpdateInPlace(w, r, roomId, settings.Resources, start)
 		return
 	}
 
@@ -163,6 +343,38 @@ func (manager *ApiManagerCtx) roomRecreate(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
+// roomUpdateInPlace applies changed resources to the running container and
+// brings the room to the requested state, same as recreate would.
+func (manager *ApiManagerCtx) roomUpdateInPlace(w http.ResponseWriter, r *http.Request, roomId string, resources types.RoomResources, start bool) {
+	if err := manager.rooms.UpdateResources(r.Context(), roomId, resources); err != nil {
+		manager.logger.Error().Err(err).Msg("recreate: failed to update room resources")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	entry, err := manager.rooms.GetEntry(r.Context(), roomId)
+	if err == nil && entry.Running != start {
+		if start {
+			err = manager.rooms.Start(r.Context(), roomId)
+		} else {
+			err = manager.rooms.Stop(r.Context(), roomId)
+		}
+
+		if err == nil {
+			entry, err = manager.rooms.GetEntry(r.Context(), roomId)
+		}
+	}
+
+	if err != nil {
+		manager.logger.Error().Err(err).Msg("recreate: failed to update room state")
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
 func (manager *ApiManagerCtx) roomGetEntry(w http.ResponseWriter, r *http.Request) {
 	roomId := chi.URLParam(r, "roomId")
 
@@ -176,8 +388,9 @@ func (manager *ApiManagerCtx) roomGetEntry(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	if err := writeJSONWithETag(w, r, response); err != nil {
+		manager.logger.Error().Err(err).Msg("entry: failed to write response")
+	}
 }
 
 func (manager *ApiManagerCtx) roomGetEntryByName(w http.ResponseWriter, r *http.Request) {
@@ -194,8 +407,9 @@ func (manager *ApiManagerCtx) roomGetEntryByName(w http.ResponseWriter, r *http.
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	if err := writeJSONWithETag(w, r, response); err != nil {
+		manager.logger.Error().Err(err).Msg("entry: failed to write response")
+	}
 }
 
 func (manager *ApiManagerCtx) roomGetSettings(w http.ResponseWriter, r *http.Request) {
@@ -211,8 +425,12 @@ func (manager *ApiManagerCtx) roomGetSettings(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	manager.logCredentialsAccess(response.UUID, requestActor(r), manager.directory.clientIP(r), "settings")
+
+	// etag of settings is also used in If-Match header when recreating
+	if err := writeJSONWithETag(w, r, response); err != nil {
+		manager.logger.Error().Err(err).Msg("settings: failed to write response")
+	}
 }
 
 func (manager *ApiManagerCtx) roomGetStats(w http.ResponseWriter, r *http.Request) {
@@ -232,6 +450,40 @@ func (manager *ApiManagerCtx) roomGetStats(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
+func (manager *ApiManagerCtx) roomGetWebRTCStats(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	response, err := manager.rooms.GetWebRTCStats(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) roomGetWebRTCStatsHistory(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	response, err := manager.rooms.GetWebRTCStatsHistory(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func (manager *ApiManagerCtx) roomGenericAction(action func(ctx context.Context, id string) error) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		roomId := chi.URLParam(r, "roomId")
@@ -251,7 +503,38 @@ func (manager *ApiManagerCtx) roomGenericAction(action func(ctx context.Context,
 }
 
 func (manager *ApiManagerCtx) dockerCompose(w http.ResponseWriter, r *http.Request) {
-	response, err := manager.rooms.ExportAsDockerCompose(r.Context())
+	query := r.URL.Query()
+
+	opts := types.ComposeOptions{
+		IncludeStopped: true, // default value
+		Version:        "3.8",
+	}
+
+	for name, val := range map[string]*bool{
+		"include_stopped":  &opts.IncludeStopped,
+		"secrets_env_file": &opts.SecretsEnvFile,
+		"pin_digests":      &opts.PinDigests,
+	} {
+		if s := query.Get(name); s != "" {
+			var err error
+			*val, err = strconv.ParseBool(s)
+			if err != nil {
+				http.Error(w, err.Error(), 400)
+				return
+			}
+		}
+	}
+
+	if query.Has("version") {
+		opts.Version = query.Get("version")
+	}
+
+	if err := manager.logRoomsCredentialsAccess(r, "docker-compose", !opts.IncludeStopped); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	response, err := manager.rooms.ExportAsDockerCompose(r.Context(), opts)
 	if err != nil {
 		http.Error(w, err.Error(), 500)
 		return
@@ -260,3 +543,19 @@ func (manager *ApiManagerCtx) dockerCompose(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Content-Type", "text/yaml")
 	w.Write(response)
 }
+
+func (manager *ApiManagerCtx) bootstrap(w http.ResponseWriter, r *http.Request) {
+	format := types.BootstrapCompose
+	if f := r.URL.Query().Get("format"); f != "" {
+		format = types.BootstrapFormat(f)
+	}
+
+	response, err := manager.rooms.Bootstrap(format)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/yaml")
+	w.Write(response)
+}
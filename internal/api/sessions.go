@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// how many rooms are asked for stats at once
+const sessionsConcurrency = 4
+
+// sessionsList aggregates connected users of all running rooms. It can be filtered
+// by room (id, uuid or name) and by owner (room contact).
+func (manager *ApiManagerCtx) sessionsList(w http.ResponseWriter, r *http.Request) {
+	filterRoom := r.URL.Query().Get("room")
+	filterOwner := r.URL.Query().Get("owner")
+
+	entries, err := manager.rooms.List(r.Context(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	response := []types.RoomSession{}
+
+	sem := make(chan struct{}, sessionsConcurrency)
+	for _, entry := range entries {
+		if !entry.Running {
+			continue
+		}
+
+		if filterRoom != "" && filterRoom != entry.ID && filterRoom != entry.UUID && filterRoom != entry.Name {
+			continue
+		}
+
+		if filterOwner != "" && filterOwner != entry.Contact {
+			continue
+		}
+
+		wg.Add(1)
+		go func(entry types.RoomEntry) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			stats, err := manager.rooms.GetStats(r.Context(), entry.ID)
+			if err != nil {
+				// room could be starting or not respond, it should not fail whole list
+				manager.logger.Warn().Err(err).Str("id", entry.ID).Msg("sessions: failed to get room stats")
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, member := range stats.Members {
+				response = append(response, types.RoomSession{
+					RoomID:   entry.ID,
+					RoomName: entry.Name,
+					Owner:    entry.Contact,
+
+					ID:             member.ID,
+					Name:           member.Name,
+					Admin:          member.Admin,
+					HasControl:     stats.Host != "" && stats.Host == member.ID,
+					ConnectedSince: member.ConnectedSince,
+				})
+			}
+		}(entry)
+	}
+
+	wg.Wait()
+
+	sort.Slice(response, func(i, j int) bool {
+		if response[i].RoomName != response[j].RoomName {
+			return response[i].RoomName < response[j].RoomName
+		}
+		return response[i].Name < response[j].Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (manager *ApiManagerCtx) mountSetup(r chi.Router) {
+	r.Use(manager.setupAuth)
+
+	r.Get("/", manager.setupState)
+	r.Get("/networks", manager.setupNetworks)
+
+	r.Post("/docker", setupStep(manager, func(ctx context.Context, _ struct{}) (*types.SetupDocker, error) {
+		return manager.setup.Docker(ctx)
+	}))
+	r.Post("/network", setupStep(manager, manager.setup.Network))
+	r.Post("/domain", setupStep(manager, manager.setup.Domain))
+	r.Post("/reachability", setupStep(manager, manager.setup.Reachability))
+	r.Post("/admin", setupStep(manager, manager.setup.Admin))
+}
+
+// setupAuth allows only requests with setup code, it is sent in separate header,
+// so that it can be combined with admin auth.
+func (manager *ApiManagerCtx) setupAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !manager.setup.Authorize(r.Header.Get("X-Setup-Code")) {
+			http.Error(w, types.ErrSetupUnauthorized.Error(), 401)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (manager *ApiManagerCtx) setupState(w http.ResponseWriter, r *http.Request) {
+	response := manager.setup.State()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) setupNetworks(w http.ResponseWriter, r *http.Request) {
+	response, err := manager.setup.Networks(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// setupStep decodes optional request of setup step and responds with its result.
+func setupStep[Req any, Res any](manager *ApiManagerCtx, step func(ctx context.Context, request Req) (*Res, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request Req
+		if err := manager.decodeRequest(w, r, &request); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, err.Error(), decodeErrorStatus(err))
+			return
+		}
+
+		response, err := step(r.Context(), request)
+		if err != nil {
+			if errors.Is(err, types.ErrSetupCompleted) || errors.Is(err, types.ErrSetupStepOrder) {
+				http.Error(w, err.Error(), 409)
+			} else {
+				http.Error(w, err.Error(), 500)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
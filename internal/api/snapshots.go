@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// snapshotErrorStatus maps snapshot errors to http status codes.
+func snapshotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, types.ErrSnapshotNotFound), errors.Is(err, types.ErrRoomNotFound):
+		return 404
+	case errors.Is(err, types.ErrSnapshotInUse):
+		return 409
+	default:
+		return 500
+	}
+}
+
+func (manager *ApiManagerCtx) snapshotsList(w http.ResponseWriter, r *http.Request) {
+	response, err := manager.rooms.ListSnapshots(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) roomSnapshot(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	var request types.RoomSnapshotRequest
+	if err := manager.decodeRequest(w, r, &request); err != nil {
+		http.Error(w, err.Error(), decodeErrorStatus(err))
+		return
+	}
+
+	// snapshot takes more space, same as new room
+	if manager.rooms.DiskPressure() {
+		http.Error(w, "disk usage is above threshold", 500)
+		return
+	}
+
+	response, err := manager.rooms.Snapshot(r.Context(), roomId, request)
+	if err != nil {
+		status := snapshotErrorStatus(err)
+		if status == 500 {
+			manager.logger.Error().Err(err).Str("id", roomId).Msg("snapshots: failed to create snapshot")
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (manager *ApiManagerCtx) snapshotRemove(w http.ResponseWriter, r *http.Request) {
+	err := manager.rooms.RemoveSnapshot(r.Context(), chi.URLParam(r, "snapshotTag"))
+	if err != nil {
+		http.Error(w, err.Error(), snapshotErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/websocket"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// message sent by terminal client
+type terminalMessage struct {
+	Type string `json:"type"` // input or resize
+	Data string `json:"data,omitempty"`
+	types.RoomTerminalSize
+}
+
+// sameOriginHandshake allows only same origin, so that other sites can not open
+// websockets using credentials of logged in admin.
+func sameOriginHandshake(config *websocket.Config, r *http.Request) error {
+	origin, err := url.Parse(r.Header.Get("Origin"))
+	if err != nil || origin.Host == "" {
+		return fmt.Errorf("missing origin")
+	}
+
+	if !strings.EqualFold(origin.Host, r.Host) {
+		return fmt.Errorf("origin not allowed")
+	}
+
+	config.Origin = origin
+	return nil
+}
+
+func (manager *ApiManagerCtx) roomTerminal(w http.ResponseWriter, r *http.Request) {
+	roomId := chi.URLParam(r, "roomId")
+
+	entry, err := manager.rooms.GetEntry(r.Context(), roomId)
+	if err != nil {
+		if errors.Is(err, types.ErrRoomNotFound) {
+			http.Error(w, err.Error(), 404)
+		} else {
+			http.Error(w, err.Error(), 500)
+		}
+		return
+	}
+
+	if !manager.rooms.Config().TerminalEnabled {
+		http.Error(w, types.ErrTerminalDisabled.Error(), 403)
+		return
+	}
+
+	actor := requestActor(r)
+	manager.logCredentialsAccess(entry.UUID, actor, manager.directory.clientIP(r), "terminal")
+
+	websocket.Server{
+		Handshake: sameOriginHandshake,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// output is sent as binary frames, it does not need to be valid utf-8
+			ws.PayloadType = websocket.BinaryFrame
+
+			stdin, stdinWriter := io.Pipe()
+			resize := make(chan types.RoomTerminalSize, 1)
+
+			go func() {
+				defer stdinWriter.Close()
+
+				for {
+					var message terminalMessage
+					if err := websocket.JSON.Receive(ws, &message); err != nil {
+						return
+					}
+
+					switch message.Type {
+					case "input":
+						if _, err := stdinWriter.Write([]byte(message.Data)); err != nil {
+							return
+						}
+					case "resize":
+						// only latest size matters
+						select {
+						case <-resize:
+						default:
+						}
+						resize <- message.RoomTerminalSize
+					}
+				}
+			}()
+
+			err := manager.rooms.Terminal(r.Context(), entry.ID, types.RoomTerminalSession{
+				Actor:  actor,
+				Stdin:  stdin,
+				Stdout: ws,
+				Resize: resize,
+			})
+
+			// unblock input, if shell exited first
+			stdin.Close()
+
+			if err != nil {
+				manager.logger.Error().Err(err).Str("id", entry.ID).Msg("terminal: session failed")
+				ws.Write([]byte("\r\n" + err.Error() + "\r\n"))
+			}
+		},
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Deprecation of config item, that is still in use.
+type Deprecation struct {
+	Key         string `json:"key"`
+	Replacement string `json:"replacement,omitempty"`
+	Message     string `json:"message"`
+}
+
+func (s *Room) deprecated(key, replacement, message string) {
+	log.Warn().Str("key", key).Str("replacement", replacement).Msg(message)
+
+	s.Deprecations = append(s.Deprecations, Deprecation{
+		Key:         key,
+		Replacement: replacement,
+		Message:     message,
+	})
+}
+
+// Settings returns config items, that are explicitly set by configuration file,
+// envs or flags, defaults are not included.
+func Settings() map[string]any {
+	values := map[string]any{}
+	for _, key := range viper.AllKeys() {
+		// chosen on command line, not part of configuration
+		if key == "config" || key == "profile" {
+			continue
+		}
+
+		if viper.IsSet(key) {
+			values[key] = viper.Get(key)
+		}
+	}
+	return values
+}
+
+// MigrateDeprecated replaces deprecated config items by their replacements,
+// with the same effect as they had.
+func (s *Room) MigrateDeprecated(values map[string]any) {
+	for _, deprecation := range s.Deprecations {
+		delete(values, deprecation.Key)
+
+		// replacement takes precedence, deprecated item was ignored
+		if _, ok := values[deprecation.Replacement]; ok {
+			continue
+		}
+
+		switch deprecation.Replacement {
+		case "instance.network":
+			values[deprecation.Replacement] = s.InstanceNetwork
+		case "instance.url":
+			instanceUrl := s.GetInstanceUrl()
+			values[deprecation.Replacement] = instanceUrl.String()
+		}
+	}
+}
+
+// Nested converts config items with dotted keys to nested sections.
+func Nested(values map[string]any) map[string]any {
+	config := map[string]any{}
+	nest(config, values)
+	return config
+}
+
+func nest(config map[string]any, values map[string]any) {
+	for key, value := range values {
+		parts := strings.Split(key, ".")
+
+		section := config
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := section[part].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				section[part] = next
+			}
+			section = next
+		}
+
+		section[parts[len(parts)-1]] = value
+	}
+}
+
+// WriteFile writes config items with dotted keys as YAML configuration file.
+// When merging, other values of existing file are kept. File can contain
+// credentials, it is readable only by owner.
+func WriteFile(file string, values map[string]any, merge bool) error {
+	config := map[string]any{}
+
+	if merge {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			if err := yaml.Unmarshal(data, &config); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	nest(config, values)
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return err
+	}
+
+	// mode of existing file is not changed by write
+	return os.Chmod(file, 0600)
+}
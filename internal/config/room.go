@@ -1,11 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"net/url"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	dockerNames "github.com/docker/docker/daemon/names"
 	"github.com/rs/zerolog/log"
@@ -19,20 +21,98 @@ type Traefik struct {
 	Entrypoint   string
 	Certresolver string
 	Port         string // deprecated
+	Api          string // used to check actual routers
 }
 
+const (
+	AlertRuleRoomUnhealthy = "room_unhealthy"
+	AlertRuleCrashLoop     = "crash_loop"
+	AlertRuleHostMemory    = "host_memory"
+	AlertRuleDiskUsage     = "disk_usage"
+)
+
+// alert rule, evaluated periodically
+type AlertRule struct {
+	Kind       string
+	Threshold  float64 // percent, for host_memory and disk_usage
+	ForSec     int     // condition must hold for this long
+	SilenceSec int     // after notification, same alert is not sent again for this long
+}
+
+// parseAlertRule parses rule in format kind=host_memory;threshold=90;for=5m;silence=1h
+func parseAlertRule(rule string) (AlertRule, error) {
+	var alertRule AlertRule
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		switch key {
+		case "kind":
+			alertRule.Kind = value
+		case "threshold":
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return alertRule, fmt.Errorf("invalid threshold: %w", err)
+			}
+			alertRule.Threshold = threshold
+		case "for", "silence":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return alertRule, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if key == "for" {
+				alertRule.ForSec = int(duration.Seconds())
+			} else {
+				alertRule.SilenceSec = int(duration.Seconds())
+			}
+		default:
+			return alertRule, fmt.Errorf("unknown key: %s", key)
+		}
+	}
+
+	switch alertRule.Kind {
+	case AlertRuleRoomUnhealthy, AlertRuleCrashLoop:
+	case AlertRuleHostMemory, AlertRuleDiskUsage:
+		if alertRule.Threshold <= 0 {
+			return alertRule, fmt.Errorf("missing threshold for %s", alertRule.Kind)
+		}
+	default:
+		return alertRule, fmt.Errorf("unknown kind: %s", alertRule.Kind)
+	}
+
+	return alertRule, nil
+}
+
+const (
+	NameStrategyRandom     = "random"
+	NameStrategySlug       = "slug"
+	NameStrategySequential = "sequential"
+	NameStrategyRequest    = "request"
+)
+
 type Room struct {
 	Mux    bool
 	EprMin uint16
 	EprMax uint16
 
-	NAT1To1IPs           []string
-	NekoImages           []string
-	NekoPrivilegedImages []string
-	PathPrefix           string
-	Labels               []string
-	WaitEnabled          bool
-	StopTimeoutSec       int
+	NAT1To1IPs            []string
+	NekoImages            []string
+	NekoPrivilegedImages  []string
+	SnapshotsRepository   string
+	PathPrefix            string
+	Labels                []string
+	WaitEnabled           bool
+	LazyStart             bool
+	StopTimeoutSec        int
+	MigrateOnStartup      bool
+	ImportComposeProjects []string
+	Prepull               bool
+	DockerMaxOperations   int
+	StartStaggerMs        int
+	StartJitterMs         int
+	NameStrategy          string
+
+	Offline           bool
+	OfflineRegistries []string
 
 	StorageEnabled  bool
 	StorageInternal string
@@ -40,11 +120,82 @@ type Room struct {
 
 	MountsWhitelist []string
 
+	DefaultMaxConnections uint16
+	DefaultScreen         string
+	DefaultVideoCodec     string
+	DefaultAudioCodec     string
+	DefaultShmSize        int64
+	DefaultMemory         int64
+	DefaultNanoCPUs       int64
+	DefaultDomainname     string
+
+	ProfilesMaxPerOwner int
+
+	ArchivesVerifyIntervalSec int
+
+	ActivityIntervalSec int
+	IdleTimeoutSec      int
+
+	WebRTCStatsIntervalSec int
+	WebRTCStatsHistory     int
+
+	TieringMode        string // off, suggest or apply
+	TieringIntervalSec int
+	TieringWindow      int
+	TieringHeadroom    float64
+	TieringHysteresis  float64
+	TieringMinMemory   int64
+	TieringMinNanoCPUs int64
+
+	HttpProxy  string
+	HttpsProxy string
+	NoProxy    string
+
+	TransferClipboard bool
+	TransferFiles     bool
+
+	DNSWhitelist        []string
+	ExtraHostsWhitelist []string
+
 	InstanceName    string
 	InstanceUrl     *url.URL
 	InstanceNetwork string
 
+	WebhookUrl string
+
+	OOMMemoryIncrement int64
+	OOMMemoryMax       int64
+
+	WatchdogTimeoutSec int
+	WatchdogRetry      bool
+
+	DiskThreshold int
+	DiskPaths     []string
+
+	AlertsIntervalSec int
+	AlertRules        []AlertRule
+
+	HooksPreCreate        string // local script or URL
+	HooksPostStart        string // local script or URL
+	HooksPostStartFailure string // ignore or stop
+	HooksTimeoutSec       int
+
+	ExecCommands   map[string]string // name -> shell command
+	ExecTimeoutSec int
+
+	TerminalEnabled bool
+	TerminalShell   string
+	TerminalRecord  bool
+
+	DoctorIntervalSec int
+
+	AutoscaleIntervalSec int
+	AutoscaleMaxRooms    int
+	AutoscaleRetireSec   int
+
 	Traefik Traefik
+
+	Deprecations []Deprecation // in use, found when config is set
 }
 
 func (Room) Init(cmd *cobra.Command) error {
@@ -86,6 +237,11 @@ func (Room) Init(cmd *cobra.Command) error {
 		return err
 	}
 
+	cmd.PersistentFlags().String("snapshots.repository", "neko-rooms-snapshots", "repository of images, that rooms are snapshotted to")
+	if err := viper.BindPFlag("snapshots.repository", cmd.PersistentFlags().Lookup("snapshots.repository")); err != nil {
+		return err
+	}
+
 	cmd.PersistentFlags().String("path_prefix", "/", "path prefix that is added to every room path")
 	if err := viper.BindPFlag("path_prefix", cmd.PersistentFlags().Lookup("path_prefix")); err != nil {
 		return err
@@ -101,11 +257,36 @@ func (Room) Init(cmd *cobra.Command) error {
 		return err
 	}
 
+	cmd.PersistentFlags().Bool("lazy_start", false, "route stopped rooms to neko-rooms and start them, when their page is visited")
+	if err := viper.BindPFlag("lazy_start", cmd.PersistentFlags().Lookup("lazy_start")); err != nil {
+		return err
+	}
+
 	cmd.PersistentFlags().Int("stop_timeout", 10, "timeout in seconds for stopping the room with SIGTERM, after that SIGKILL is used (0 to disable, -1 to wait forever)")
 	if err := viper.BindPFlag("stop_timeout", cmd.PersistentFlags().Lookup("stop_timeout")); err != nil {
 		return err
 	}
 
+	cmd.PersistentFlags().Bool("migrate_on_startup", false, "recreate rooms, that do not match current config or labels schema, on startup")
+	if err := viper.BindPFlag("migrate_on_startup", cmd.PersistentFlags().Lookup("migrate_on_startup")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("import.compose_projects", []string{}, "docker compose projects, whose neko services are offered for import as rooms on startup")
+	if err := viper.BindPFlag("import.compose_projects", cmd.PersistentFlags().Lookup("import.compose_projects")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Bool("prepull", false, "pull all neko images ahead of time and keep checking them periodically")
+	if err := viper.BindPFlag("prepull", cmd.PersistentFlags().Lookup("prepull")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("name_strategy", NameStrategyRandom, "how are names of rooms created without a name generated: random, slug, sequential or request (name is required)")
+	if err := viper.BindPFlag("name_strategy", cmd.PersistentFlags().Lookup("name_strategy")); err != nil {
+		return err
+	}
+
 	// Data
 
 	cmd.PersistentFlags().Bool("storage.enabled", true, "whether storage is enabled, where peristent containers data will be stored")
@@ -128,6 +309,43 @@ func (Room) Init(cmd *cobra.Command) error {
 		return err
 	}
 
+	// Network
+
+	cmd.PersistentFlags().String("http_proxy.http", "", "outbound HTTP proxy for all rooms, that do not specify their own (e.g. http://proxy:3128)")
+	if err := viper.BindPFlag("http_proxy.http", cmd.PersistentFlags().Lookup("http_proxy.http")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("http_proxy.https", "", "outbound HTTPS proxy for all rooms, that do not specify their own")
+	if err := viper.BindPFlag("http_proxy.https", cmd.PersistentFlags().Lookup("http_proxy.https")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("http_proxy.no_proxy", "", "comma separated hosts, that are not accessed using proxy")
+	if err := viper.BindPFlag("http_proxy.no_proxy", cmd.PersistentFlags().Lookup("http_proxy.no_proxy")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Bool("transfer.clipboard", true, "allow clipboard access in rooms, that do not specify their own policy")
+	if err := viper.BindPFlag("transfer.clipboard", cmd.PersistentFlags().Lookup("transfer.clipboard")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Bool("transfer.files", false, "allow file upload and download in rooms, that do not specify their own policy")
+	if err := viper.BindPFlag("transfer.files", cmd.PersistentFlags().Lookup("transfer.files")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("dns.whitelist", []string{}, "whitelisted DNS servers for rooms (empty to allow any)")
+	if err := viper.BindPFlag("dns.whitelist", cmd.PersistentFlags().Lookup("dns.whitelist")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("extra_hosts.whitelist", []string{}, "whitelisted hostnames, that can be added to rooms as extra hosts, '*.domain.tld' matches subdomains (empty to allow any)")
+	if err := viper.BindPFlag("extra_hosts.whitelist", cmd.PersistentFlags().Lookup("extra_hosts.whitelist")); err != nil {
+		return err
+	}
+
 	// Instance
 
 	cmd.PersistentFlags().String("instance.name", "neko-rooms", "unique instance name (if running muliple on the same host)")
@@ -145,6 +363,284 @@ func (Room) Init(cmd *cobra.Command) error {
 		return err
 	}
 
+	// Webhook
+
+	cmd.PersistentFlags().String("webhook.url", "", "webhook URL, where room alerts are sent as POST requests (e.g. crash loop)")
+	if err := viper.BindPFlag("webhook.url", cmd.PersistentFlags().Lookup("webhook.url")); err != nil {
+		return err
+	}
+
+	// OOM
+
+	cmd.PersistentFlags().Int64("oom.memory_increment", 0, "when room is killed because of out of memory, recreate it with memory limit increased by this amount of bytes (0 to disable)")
+	if err := viper.BindPFlag("oom.memory_increment", cmd.PersistentFlags().Lookup("oom.memory_increment")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int64("oom.memory_max", 0, "maximum memory limit in bytes, that can be set by increments (0 for unlimited)")
+	if err := viper.BindPFlag("oom.memory_max", cmd.PersistentFlags().Lookup("oom.memory_max")); err != nil {
+		return err
+	}
+
+	// Docker
+
+	cmd.PersistentFlags().Int("docker.max_operations", 0, "maximum number of concurrent heavy docker operations (room creation, image pull), excess operations wait in order (0 for unlimited)")
+	if err := viper.BindPFlag("docker.max_operations", cmd.PersistentFlags().Lookup("docker.max_operations")); err != nil {
+		return err
+	}
+
+	// Start
+
+	cmd.PersistentFlags().Int("start.stagger", 0, "minimum delay between room starts in milliseconds, rooms with higher priority are started first (0 to disable)")
+	if err := viper.BindPFlag("start.stagger", cmd.PersistentFlags().Lookup("start.stagger")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("start.jitter", 0, "maximum random delay in milliseconds added to start stagger")
+	if err := viper.BindPFlag("start.jitter", cmd.PersistentFlags().Lookup("start.jitter")); err != nil {
+		return err
+	}
+
+	// Defaults
+
+	cmd.PersistentFlags().Uint16("defaults.max_connections", 10, "default max connections of new rooms, when not specified")
+	if err := viper.BindPFlag("defaults.max_connections", cmd.PersistentFlags().Lookup("defaults.max_connections")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("defaults.screen", "1280x720@30", "default screen of new rooms, when not specified")
+	if err := viper.BindPFlag("defaults.screen", cmd.PersistentFlags().Lookup("defaults.screen")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("defaults.video_codec", "VP8", "default video codec of new rooms, when not specified")
+	if err := viper.BindPFlag("defaults.video_codec", cmd.PersistentFlags().Lookup("defaults.video_codec")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("defaults.audio_codec", "OPUS", "default audio codec of new rooms, when not specified")
+	if err := viper.BindPFlag("defaults.audio_codec", cmd.PersistentFlags().Lookup("defaults.audio_codec")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int64("defaults.shm_size", 2*1e9, "default shared memory size of new rooms in bytes, when not specified")
+	if err := viper.BindPFlag("defaults.shm_size", cmd.PersistentFlags().Lookup("defaults.shm_size")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int64("defaults.memory", 0, "default memory limit of new rooms in bytes, when not specified (0 for unlimited)")
+	if err := viper.BindPFlag("defaults.memory", cmd.PersistentFlags().Lookup("defaults.memory")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Float64("defaults.cpus", 0, "default CPU limit of new rooms, when not specified (0 for unlimited)")
+	if err := viper.BindPFlag("defaults.cpus", cmd.PersistentFlags().Lookup("defaults.cpus")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("defaults.domainname", "", "default domainname of new rooms, when not specified (not set by default, breaks rooms on LXC)")
+	if err := viper.BindPFlag("defaults.domainname", cmd.PersistentFlags().Lookup("defaults.domainname")); err != nil {
+		return err
+	}
+
+	// Profiles
+
+	cmd.PersistentFlags().Int("profiles.max_per_owner", 5, "maximum number of browser profile snapshots per owner (0 for unlimited)")
+	if err := viper.BindPFlag("profiles.max_per_owner", cmd.PersistentFlags().Lookup("profiles.max_per_owner")); err != nil {
+		return err
+	}
+
+	// Archives
+
+	cmd.PersistentFlags().Int("archives.verify_interval", 0, "interval in seconds, in which all archives are verified by a restore drill (0 to disable)")
+	if err := viper.BindPFlag("archives.verify_interval", cmd.PersistentFlags().Lookup("archives.verify_interval")); err != nil {
+		return err
+	}
+
+	// Activity
+
+	cmd.PersistentFlags().Int("activity.interval", 0, "interval in seconds, in which running rooms are polled for last user activity (0 to disable)")
+	if err := viper.BindPFlag("activity.interval", cmd.PersistentFlags().Lookup("activity.interval")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("activity.idle_timeout", 0, "stop running rooms, that had no connected members for given number of seconds, requires activity.interval (0 to disable)")
+	if err := viper.BindPFlag("activity.idle_timeout", cmd.PersistentFlags().Lookup("activity.idle_timeout")); err != nil {
+		return err
+	}
+
+	// WebRTC stats
+
+	cmd.PersistentFlags().Int("webrtc_stats.interval", 0, "interval in seconds, in which webrtc stats are collected from running rooms (0 to disable)")
+	if err := viper.BindPFlag("webrtc_stats.interval", cmd.PersistentFlags().Lookup("webrtc_stats.interval")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("webrtc_stats.history", 60, "number of collected webrtc stats kept per room")
+	if err := viper.BindPFlag("webrtc_stats.history", cmd.PersistentFlags().Lookup("webrtc_stats.history")); err != nil {
+		return err
+	}
+
+	// Tiering
+
+	cmd.PersistentFlags().String("tiering.mode", "off", "right-size resource limits of rooms by their sustained usage: off, suggest (only notify) or apply")
+	if err := viper.BindPFlag("tiering.mode", cmd.PersistentFlags().Lookup("tiering.mode")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("tiering.interval", 60, "interval in seconds, in which usage of running rooms is sampled")
+	if err := viper.BindPFlag("tiering.interval", cmd.PersistentFlags().Lookup("tiering.interval")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("tiering.window", 60, "number of samples, that must be collected before limits are evaluated")
+	if err := viper.BindPFlag("tiering.window", cmd.PersistentFlags().Lookup("tiering.window")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Float64("tiering.headroom", 1.5, "multiplier of peak usage in the window, that is used as new limit")
+	if err := viper.BindPFlag("tiering.headroom", cmd.PersistentFlags().Lookup("tiering.headroom")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Float64("tiering.hysteresis", 0.25, "minimal relative difference between current and new limit, so that limit is changed")
+	if err := viper.BindPFlag("tiering.hysteresis", cmd.PersistentFlags().Lookup("tiering.hysteresis")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int64("tiering.min_memory", 512*1024*1024, "memory limit in bytes, that is never undercut")
+	if err := viper.BindPFlag("tiering.min_memory", cmd.PersistentFlags().Lookup("tiering.min_memory")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Float64("tiering.min_cpus", 0.5, "cpu limit, that is never undercut")
+	if err := viper.BindPFlag("tiering.min_cpus", cmd.PersistentFlags().Lookup("tiering.min_cpus")); err != nil {
+		return err
+	}
+
+	// Offline
+
+	cmd.PersistentFlags().Bool("offline.enabled", false, "air-gapped mode, images can only be pulled from offline registries")
+	if err := viper.BindPFlag("offline.enabled", cmd.PersistentFlags().Lookup("offline.enabled")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("offline.registries", []string{}, "registry mirrors (e.g. registry.local:5000), that are reachable in offline mode")
+	if err := viper.BindPFlag("offline.registries", cmd.PersistentFlags().Lookup("offline.registries")); err != nil {
+		return err
+	}
+
+	// Watchdog
+
+	cmd.PersistentFlags().Int("watchdog.timeout", 0, "seconds after start, within which room must become ready, otherwise it is stopped and marked as failed (0 to disable)")
+	if err := viper.BindPFlag("watchdog.timeout", cmd.PersistentFlags().Lookup("watchdog.timeout")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Bool("watchdog.retry", false, "recreate failed room once with a fresh container")
+	if err := viper.BindPFlag("watchdog.retry", cmd.PersistentFlags().Lookup("watchdog.retry")); err != nil {
+		return err
+	}
+
+	// Disk
+
+	cmd.PersistentFlags().Int("disk.threshold", 0, "disk usage in percent, above which new rooms are not created and dangling images are pruned (0 to disable)")
+	if err := viper.BindPFlag("disk.threshold", cmd.PersistentFlags().Lookup("disk.threshold")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("disk.paths", []string{}, "paths, whose filesystems are monitored for disk usage (storage is monitored by default)")
+	if err := viper.BindPFlag("disk.paths", cmd.PersistentFlags().Lookup("disk.paths")); err != nil {
+		return err
+	}
+
+	// Alerts
+
+	cmd.PersistentFlags().Int("alerts.interval", 30, "interval in seconds, in which alert rules are evaluated")
+	if err := viper.BindPFlag("alerts.interval", cmd.PersistentFlags().Lookup("alerts.interval")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("alerts.rules", []string{}, "alert rules sent to webhook, e.g. kind=host_memory;threshold=90;for=5m;silence=1h (kinds: room_unhealthy, crash_loop, host_memory, disk_usage)")
+	if err := viper.BindPFlag("alerts.rules", cmd.PersistentFlags().Lookup("alerts.rules")); err != nil {
+		return err
+	}
+
+	// Hooks
+
+	cmd.PersistentFlags().String("hooks.pre_create", "", "local script or http(s) URL called with room context before room is created, failure aborts creation, called again with pre_create_failed hook when creation fails afterwards")
+	if err := viper.BindPFlag("hooks.pre_create", cmd.PersistentFlags().Lookup("hooks.pre_create")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("hooks.post_start", "", "local script or http(s) URL called with room context after room is started")
+	if err := viper.BindPFlag("hooks.post_start", cmd.PersistentFlags().Lookup("hooks.post_start")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("hooks.post_start_failure", "ignore", "what happens when post start hook fails: ignore (only alert) or stop (room is stopped)")
+	if err := viper.BindPFlag("hooks.post_start_failure", cmd.PersistentFlags().Lookup("hooks.post_start_failure")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("hooks.timeout", 30, "timeout of hooks in seconds")
+	if err := viper.BindPFlag("hooks.timeout", cmd.PersistentFlags().Lookup("hooks.timeout")); err != nil {
+		return err
+	}
+
+	// Exec
+
+	cmd.PersistentFlags().StringToString("exec.commands", map[string]string{}, "whitelisted commands, that can be run inside rooms by name, e.g. clear_cache='rm -rf /home/neko/.cache/*'")
+	if err := viper.BindPFlag("exec.commands", cmd.PersistentFlags().Lookup("exec.commands")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("exec.timeout", 60, "timeout of commands run inside rooms in seconds")
+	if err := viper.BindPFlag("exec.timeout", cmd.PersistentFlags().Lookup("exec.timeout")); err != nil {
+		return err
+	}
+
+	// Terminal
+
+	cmd.PersistentFlags().Bool("terminal.enabled", false, "allow admins to open interactive terminal inside rooms")
+	if err := viper.BindPFlag("terminal.enabled", cmd.PersistentFlags().Lookup("terminal.enabled")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("terminal.shell", "/bin/bash", "shell started in interactive terminal")
+	if err := viper.BindPFlag("terminal.shell", cmd.PersistentFlags().Lookup("terminal.shell")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Bool("terminal.record", true, "record output of terminal sessions to storage")
+	if err := viper.BindPFlag("terminal.record", cmd.PersistentFlags().Lookup("terminal.record")); err != nil {
+		return err
+	}
+
+	// Doctor
+
+	cmd.PersistentFlags().Int("doctor.interval", 0, "interval in seconds, in which routing of rooms is checked and new issues are alerted (0 to disable)")
+	if err := viper.BindPFlag("doctor.interval", cmd.PersistentFlags().Lookup("doctor.interval")); err != nil {
+		return err
+	}
+
+	// Autoscale
+
+	cmd.PersistentFlags().Int("autoscale.interval", 0, "interval in seconds, in which occupancy of room series is checked and extra rooms are created or retired (0 to disable)")
+	if err := viper.BindPFlag("autoscale.interval", cmd.PersistentFlags().Lookup("autoscale.interval")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("autoscale.max_rooms", 5, "maximum number of rooms in a series, including extra rooms")
+	if err := viper.BindPFlag("autoscale.max_rooms", cmd.PersistentFlags().Lookup("autoscale.max_rooms")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("autoscale.retire_after", 300, "remove extra rooms of a series, that had no connected members for given number of seconds")
+	if err := viper.BindPFlag("autoscale.retire_after", cmd.PersistentFlags().Lookup("autoscale.retire_after")); err != nil {
+		return err
+	}
+
 	// Traefik
 
 	cmd.PersistentFlags().Bool("traefik.enabled", true, "traefik: enabled or disabled")
@@ -167,6 +663,11 @@ func (Room) Init(cmd *cobra.Command) error {
 		return err
 	}
 
+	cmd.PersistentFlags().String("traefik.api", "", "traefik: API URL (e.g. http://traefik:8080), used to detect unreachable rooms and orphan routers")
+	if err := viper.BindPFlag("traefik.api", cmd.PersistentFlags().Lookup("traefik.api")); err != nil {
+		return err
+	}
+
 	cmd.PersistentFlags().String("traefik.network", "traefik", "traefik: docker network name (deprecated, use instance.network)")
 	if err := viper.BindPFlag("traefik.network", cmd.PersistentFlags().Lookup("traefik.network")); err != nil {
 		return err
@@ -210,10 +711,22 @@ func (s *Room) Set() {
 	s.NAT1To1IPs = viper.GetStringSlice("nat1to1")
 	s.NekoImages = viper.GetStringSlice("neko_images")
 	s.NekoPrivilegedImages = viper.GetStringSlice("neko_privileged_images")
+	s.SnapshotsRepository = viper.GetString("snapshots.repository")
 	s.PathPrefix = path.Join("/", path.Clean(viper.GetString("path_prefix")))
 	s.Labels = viper.GetStringSlice("labels")
 	s.WaitEnabled = viper.GetBool("wait_enabled")
+	s.LazyStart = viper.GetBool("lazy_start")
 	s.StopTimeoutSec = viper.GetInt("stop_timeout")
+	s.MigrateOnStartup = viper.GetBool("migrate_on_startup")
+	s.ImportComposeProjects = viper.GetStringSlice("import.compose_projects")
+	s.Prepull = viper.GetBool("prepull")
+
+	s.NameStrategy = viper.GetString("name_strategy")
+	switch s.NameStrategy {
+	case NameStrategyRandom, NameStrategySlug, NameStrategySequential, NameStrategyRequest:
+	default:
+		log.Panic().Msg("invalid `name_strategy`, must be one of: random, slug, sequential, request")
+	}
 
 	s.StorageEnabled = viper.GetBool("storage.enabled")
 	s.StorageInternal = viper.GetString("storage.internal")
@@ -240,6 +753,16 @@ func (s *Room) Set() {
 		}
 	}
 
+	s.HttpProxy = viper.GetString("http_proxy.http")
+	s.HttpsProxy = viper.GetString("http_proxy.https")
+	s.NoProxy = viper.GetString("http_proxy.no_proxy")
+
+	s.TransferClipboard = viper.GetBool("transfer.clipboard")
+	s.TransferFiles = viper.GetBool("transfer.files")
+
+	s.DNSWhitelist = viper.GetStringSlice("dns.whitelist")
+	s.ExtraHostsWhitelist = viper.GetStringSlice("extra_hosts.whitelist")
+
 	s.InstanceName = viper.GetString("instance.name")
 	if !dockerNames.RestrictedNamePattern.MatchString(s.InstanceName) {
 		log.Panic().Msg("invalid `instance.name`, must match " + dockerNames.RestrictedNameChars)
@@ -256,19 +779,126 @@ func (s *Room) Set() {
 
 	s.InstanceNetwork = viper.GetString("instance.network")
 
+	s.WebhookUrl = viper.GetString("webhook.url")
+
+	s.OOMMemoryIncrement = viper.GetInt64("oom.memory_increment")
+	s.OOMMemoryMax = viper.GetInt64("oom.memory_max")
+
+	s.DockerMaxOperations = viper.GetInt("docker.max_operations")
+	s.StartStaggerMs = viper.GetInt("start.stagger")
+	s.StartJitterMs = viper.GetInt("start.jitter")
+
+	s.DefaultMaxConnections = uint16(viper.GetUint("defaults.max_connections"))
+	s.DefaultScreen = viper.GetString("defaults.screen")
+	s.DefaultVideoCodec = viper.GetString("defaults.video_codec")
+	s.DefaultAudioCodec = viper.GetString("defaults.audio_codec")
+	s.DefaultShmSize = viper.GetInt64("defaults.shm_size")
+	s.DefaultMemory = viper.GetInt64("defaults.memory")
+	s.DefaultNanoCPUs = int64(viper.GetFloat64("defaults.cpus") * 1e9)
+	s.DefaultDomainname = viper.GetString("defaults.domainname")
+
+	s.ProfilesMaxPerOwner = viper.GetInt("profiles.max_per_owner")
+	s.ArchivesVerifyIntervalSec = viper.GetInt("archives.verify_interval")
+	s.ActivityIntervalSec = viper.GetInt("activity.interval")
+	s.IdleTimeoutSec = viper.GetInt("activity.idle_timeout")
+
+	s.WebRTCStatsIntervalSec = viper.GetInt("webrtc_stats.interval")
+	s.WebRTCStatsHistory = viper.GetInt("webrtc_stats.history")
+
+	s.TieringMode = viper.GetString("tiering.mode")
+	if s.TieringMode != "off" && s.TieringMode != "suggest" && s.TieringMode != "apply" {
+		log.Panic().Msg("invalid `tiering.mode`, must be one of off, suggest or apply")
+	}
+
+	s.TieringIntervalSec = viper.GetInt("tiering.interval")
+	s.TieringWindow = viper.GetInt("tiering.window")
+	if s.TieringWindow < 2 {
+		s.TieringWindow = 2
+	}
+
+	s.TieringHeadroom = viper.GetFloat64("tiering.headroom")
+	if s.TieringHeadroom < 1 {
+		log.Panic().Msg("invalid `tiering.headroom`, must be at least 1")
+	}
+
+	s.TieringHysteresis = viper.GetFloat64("tiering.hysteresis")
+	s.TieringMinMemory = viper.GetInt64("tiering.min_memory")
+	s.TieringMinNanoCPUs = int64(viper.GetFloat64("tiering.min_cpus") * 1e9)
+
+	s.Offline = viper.GetBool("offline.enabled")
+	s.OfflineRegistries = viper.GetStringSlice("offline.registries")
+
+	s.WatchdogTimeoutSec = viper.GetInt("watchdog.timeout")
+	s.WatchdogRetry = viper.GetBool("watchdog.retry")
+
+	s.DiskThreshold = viper.GetInt("disk.threshold")
+	s.DiskPaths = viper.GetStringSlice("disk.paths")
+	if len(s.DiskPaths) == 0 && s.StorageEnabled {
+		s.DiskPaths = []string{s.StorageInternal}
+	}
+
+	s.AlertsIntervalSec = viper.GetInt("alerts.interval")
+	s.AlertRules = []AlertRule{}
+	for _, rule := range viper.GetStringSlice("alerts.rules") {
+		alertRule, err := parseAlertRule(rule)
+		if err != nil {
+			log.Panic().Err(err).Str("rule", rule).Msg("invalid `alerts.rules`")
+		}
+		s.AlertRules = append(s.AlertRules, alertRule)
+	}
+
+	s.HooksPreCreate = viper.GetString("hooks.pre_create")
+	s.HooksPostStart = viper.GetString("hooks.post_start")
+	s.HooksPostStartFailure = viper.GetString("hooks.post_start_failure")
+	if s.HooksPostStartFailure != "ignore" && s.HooksPostStartFailure != "stop" {
+		log.Panic().Msg("invalid `hooks.post_start_failure`, must be one of ignore or stop")
+	}
+	s.HooksTimeoutSec = viper.GetInt("hooks.timeout")
+
+	s.ExecCommands = viper.GetStringMapString("exec.commands")
+	s.ExecTimeoutSec = viper.GetInt("exec.timeout")
+
+	s.TerminalEnabled = viper.GetBool("terminal.enabled")
+	s.TerminalShell = viper.GetString("terminal.shell")
+	s.TerminalRecord = viper.GetBool("terminal.record")
+
+	s.DoctorIntervalSec = viper.GetInt("doctor.interval")
+
+	s.AutoscaleIntervalSec = viper.GetInt("autoscale.interval")
+	s.AutoscaleMaxRooms = viper.GetInt("autoscale.max_rooms")
+	if s.AutoscaleMaxRooms < 1 {
+		log.Panic().Msg("invalid `autoscale.max_rooms`, must be at least 1")
+	}
+	s.AutoscaleRetireSec = viper.GetInt("autoscale.retire_after")
+
 	s.Traefik.Enabled = viper.GetBool("traefik.enabled")
 	if s.Traefik.Enabled {
 		s.Traefik.Domain = viper.GetString("traefik.domain")
 		s.Traefik.Entrypoint = viper.GetString("traefik.entrypoint")
 		s.Traefik.Certresolver = viper.GetString("traefik.certresolver")
+		s.Traefik.Api = strings.TrimSuffix(viper.GetString("traefik.api"), "/")
 
-		// deprecated
+		// certificate resolver can not be probed, at least warn about subdomains
+		if s.Traefik.Certresolver != "" && strings.HasPrefix(s.Traefik.Domain, "*.") {
+			log.Warn().Msg("rooms as subdomains request certificate for every room, make sure that `traefik.certresolver` can issue them without hitting rate limits, or use wildcard certificate with DNS-01 challenge")
+		}
+
+		// stopped rooms are matched by path, subdomains are not known to the lobby
+		if s.LazyStart && strings.HasPrefix(s.Traefik.Domain, "*.") {
+			log.Warn().Msg("`lazy_start` does not work with rooms as subdomains, stopped rooms will not be started when visited")
+		}
+
+		// deprecated, its default is used when instance network is not set
 		traefikNetwork := viper.GetString("traefik.network")
 		if traefikNetwork != "" {
 			if s.InstanceNetwork != "" {
-				log.Warn().Msg("deprecated `traefik.network` config item is ignored when `instance.network` is set")
+				if viper.IsSet("traefik.network") {
+					s.deprecated("traefik.network", "instance.network", "deprecated `traefik.network` config item is ignored when `instance.network` is set")
+				}
 			} else {
-				log.Warn().Msg("you are using deprecated `traefik.network` config item, you should consider moving to `instance.network`")
+				if viper.IsSet("traefik.network") {
+					s.deprecated("traefik.network", "instance.network", "you are using deprecated `traefik.network` config item, you should consider moving to `instance.network`")
+				}
 				s.InstanceNetwork = traefikNetwork
 			}
 		}
@@ -277,9 +907,9 @@ func (s *Room) Set() {
 		s.Traefik.Port = viper.GetString("traefik.port")
 		if s.Traefik.Port != "" {
 			if s.InstanceUrl != nil {
-				log.Warn().Msg("deprecated `traefik.port` config item is ignored when `instance.url` is set")
+				s.deprecated("traefik.port", "instance.url", "deprecated `traefik.port` config item is ignored when `instance.url` is set")
 			} else {
-				log.Warn().Msg("you are using deprecated `traefik.port` config item, you should consider moving to `instance.url`")
+				s.deprecated("traefik.port", "instance.url", "you are using deprecated `traefik.port` config item, you should consider moving to `instance.url`")
 			}
 		}
 	}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -9,6 +10,7 @@ type Root struct {
 	Debug   bool
 	Logs    bool
 	CfgFile string
+	Profile string
 }
 
 func (Root) Init(cmd *cobra.Command) error {
@@ -27,6 +29,11 @@ func (Root) Init(cmd *cobra.Command) error {
 		return err
 	}
 
+	cmd.PersistentFlags().String("profile", "", "configuration profile from environments section of configuration file, e.g. dev, staging or prod")
+	if err := viper.BindPFlag("profile", cmd.PersistentFlags().Lookup("profile")); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -34,4 +41,16 @@ func (s *Root) Set() {
 	s.Logs = viper.GetBool("logs")
 	s.Debug = viper.GetBool("debug")
 	s.CfgFile = viper.GetString("config")
+
+	// values of profile override configuration file, flags and envs still take precedence
+	s.Profile = viper.GetString("profile")
+	if s.Profile != "" {
+		if !viper.IsSet("environments." + s.Profile) {
+			log.Panic().Msg("invalid `profile`, must be defined in `environments` section of configuration file")
+		}
+
+		if err := viper.MergeConfigMap(viper.GetStringMap("environments." + s.Profile)); err != nil {
+			log.Panic().Err(err).Msg("unable to apply configuration profile")
+		}
+	}
 }
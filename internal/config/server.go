@@ -1,18 +1,48 @@
 package config
 
 import (
+	"io/fs"
+	"net"
 	"path"
+	"runtime"
+	"strconv"
 
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 type Admin struct {
-	Static     string
-	PathPrefix string
-	ProxyAuth  string
-	Username   string
-	Password   string
+	Bind        string
+	Static      string
+	PathPrefix  string
+	ProxyAuth   string
+	UserHeader  string
+	Username    string
+	Password    string
+	MaxBodySize int64
+	JsonMode    string
+	PolicyUrl   string
+
+	DemoToken       string
+	DemoLifetimeSec int
+	DemoMaxRooms    int
+	DemoNanoCPUs    int64
+	DemoMemory      int64
+	DemoEnvs        map[string]string
+
+	DirectoryEnabled   bool
+	DirectoryCacheSec  int
+	DirectoryRateLimit int          // requests per minute per client
+	DirectoryTrusted   []*net.IPNet // proxies, whose X-Forwarded-For is used to identify clients
+
+	DeeplinkToken string
+}
+
+type Setup struct {
+	Enabled    bool
+	Code       string
+	ConfigFile string
 }
 
 type Server struct {
@@ -24,15 +54,29 @@ type Server struct {
 	PProf   bool
 	Metrics bool
 
+	BindExtra      []string
+	BindSocketMode fs.FileMode
+
 	Admin Admin
+	Setup Setup
 }
 
 func (Server) Init(cmd *cobra.Command) error {
-	cmd.PersistentFlags().String("bind", "127.0.0.1:8080", "address/port/socket to serve neko_rooms")
+	cmd.PersistentFlags().String("bind", "127.0.0.1:8080", "address/port/socket to serve neko_rooms, unix sockets as unix:/path/to.sock")
 	if err := viper.BindPFlag("bind", cmd.PersistentFlags().Lookup("bind")); err != nil {
 		return err
 	}
 
+	cmd.PersistentFlags().StringSlice("bind_extra", []string{}, "additional addresses to serve neko_rooms, unix sockets as unix:/path/to.sock")
+	if err := viper.BindPFlag("bind_extra", cmd.PersistentFlags().Lookup("bind_extra")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("bind_socket_mode", "0660", "file mode of unix sockets")
+	if err := viper.BindPFlag("bind_socket_mode", cmd.PersistentFlags().Lookup("bind_socket_mode")); err != nil {
+		return err
+	}
+
 	cmd.PersistentFlags().String("cert", "", "path to the SSL cert used to secure the neko_rooms server")
 	if err := viper.BindPFlag("cert", cmd.PersistentFlags().Lookup("cert")); err != nil {
 		return err
@@ -53,7 +97,7 @@ func (Server) Init(cmd *cobra.Command) error {
 		return err
 	}
 
-	cmd.PersistentFlags().Bool("pprof", false, "enable pprof endpoint available at /debug/pprof")
+	cmd.PersistentFlags().Bool("pprof", false, "enable pprof endpoint available at /debug/pprof, protected by admin auth")
 	if err := viper.BindPFlag("pprof", cmd.PersistentFlags().Lookup("pprof")); err != nil {
 		return err
 	}
@@ -65,6 +109,11 @@ func (Server) Init(cmd *cobra.Command) error {
 
 	// Admin
 
+	cmd.PersistentFlags().String("admin.bind", "", "address/port/socket to serve admin client and API separately, empty serves it together with rooms")
+	if err := viper.BindPFlag("admin.bind", cmd.PersistentFlags().Lookup("admin.bind")); err != nil {
+		return err
+	}
+
 	cmd.PersistentFlags().String("admin.static", "", "path to neko_rooms admin client files to serve")
 	if err := viper.BindPFlag("admin.static", cmd.PersistentFlags().Lookup("admin.static")); err != nil {
 		return err
@@ -80,6 +129,11 @@ func (Server) Init(cmd *cobra.Command) error {
 		return err
 	}
 
+	cmd.PersistentFlags().String("admin.user_header", "", "trusted request header with name of the user authenticated by reverse proxy (e.g. Remote-User), used in audit logs, set only when admin is not reachable bypassing the proxy")
+	if err := viper.BindPFlag("admin.user_header", cmd.PersistentFlags().Lookup("admin.user_header")); err != nil {
+		return err
+	}
+
 	cmd.PersistentFlags().String("admin.username", "admin", "require auth: admin username")
 	if err := viper.BindPFlag("admin.username", cmd.PersistentFlags().Lookup("admin.username")); err != nil {
 		return err
@@ -90,6 +144,99 @@ func (Server) Init(cmd *cobra.Command) error {
 		return err
 	}
 
+	cmd.PersistentFlags().Int64("admin.max_body_size", 1<<20, "maximum size of API request body in bytes")
+	if err := viper.BindPFlag("admin.max_body_size", cmd.PersistentFlags().Lookup("admin.max_body_size")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("admin.json_mode", "warn", "how to handle unknown fields in API requests: strict (reject), warn (log and ignore), lenient (ignore)")
+	if err := viper.BindPFlag("admin.json_mode", cmd.PersistentFlags().Lookup("admin.json_mode")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("admin.policy_url", "", "Open Policy Agent decision URL, evaluated before rooms are created, updated or removed (e.g. http://opa:8181/v1/data/neko_rooms/allow)")
+	if err := viper.BindPFlag("admin.policy_url", cmd.PersistentFlags().Lookup("admin.policy_url")); err != nil {
+		return err
+	}
+
+	// Demo
+
+	cmd.PersistentFlags().String("demo.token", "", "bearer token, that is only allowed to create demo rooms, empty disables demo rooms")
+	if err := viper.BindPFlag("demo.token", cmd.PersistentFlags().Lookup("demo.token")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("demo.lifetime", 1800, "maximum lifetime of demo rooms in seconds, they are removed afterwards")
+	if err := viper.BindPFlag("demo.lifetime", cmd.PersistentFlags().Lookup("demo.lifetime")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("demo.max_rooms", 5, "maximum number of demo rooms existing at the same time")
+	if err := viper.BindPFlag("demo.max_rooms", cmd.PersistentFlags().Lookup("demo.max_rooms")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Float64("demo.cpus", 1, "CPU limit of demo rooms")
+	if err := viper.BindPFlag("demo.cpus", cmd.PersistentFlags().Lookup("demo.cpus")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int64("demo.memory", 1<<30, "memory limit of demo rooms in bytes")
+	if err := viper.BindPFlag("demo.memory", cmd.PersistentFlags().Lookup("demo.memory")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringToString("demo.envs", map[string]string{}, "additional environment variables for demo rooms (e.g. watermark overlay)")
+	if err := viper.BindPFlag("demo.envs", cmd.PersistentFlags().Lookup("demo.envs")); err != nil {
+		return err
+	}
+
+	// Directory
+
+	cmd.PersistentFlags().Bool("directory.enabled", false, "serve public directory of rooms marked as listed at /-/directory, without admin auth")
+	if err := viper.BindPFlag("directory.enabled", cmd.PersistentFlags().Lookup("directory.enabled")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("directory.cache", 10, "how long is public directory cached in seconds")
+	if err := viper.BindPFlag("directory.cache", cmd.PersistentFlags().Lookup("directory.cache")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("directory.rate_limit", 60, "maximum number of requests to public directory per minute per client")
+	if err := viper.BindPFlag("directory.rate_limit", cmd.PersistentFlags().Lookup("directory.rate_limit")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("directory.trusted_proxies", []string{}, "IPs or CIDRs of reverse proxies, whose X-Forwarded-For header is used to identify clients for rate limit and access log")
+	if err := viper.BindPFlag("directory.trusted_proxies", cmd.PersistentFlags().Lookup("directory.trusted_proxies")); err != nil {
+		return err
+	}
+
+	// Deep links
+
+	cmd.PersistentFlags().String("deeplink.token", "", "token required by links creating rooms from template at /-/create, without admin auth, empty disables deep links")
+	if err := viper.BindPFlag("deeplink.token", cmd.PersistentFlags().Lookup("deeplink.token")); err != nil {
+		return err
+	}
+
+	// Setup
+
+	cmd.PersistentFlags().Bool("setup.enabled", false, "serve first-run setup wizard API at /api/setup, authorized by setup code")
+	if err := viper.BindPFlag("setup.enabled", cmd.PersistentFlags().Lookup("setup.enabled")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("setup.code", "", "setup code, random code is generated and logged when empty")
+	if err := viper.BindPFlag("setup.code", cmd.PersistentFlags().Lookup("setup.code")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("setup.config_file", "", "configuration file, that setup writes to, defaults to the used configuration file")
+	if err := viper.BindPFlag("setup.config_file", cmd.PersistentFlags().Lookup("setup.config_file")); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -97,14 +244,81 @@ func (s *Server) Set() {
 	s.Cert = viper.GetString("cert")
 	s.Key = viper.GetString("key")
 	s.Bind = viper.GetString("bind")
+	s.BindExtra = viper.GetStringSlice("bind_extra")
+
+	socketMode, err := strconv.ParseUint(viper.GetString("bind_socket_mode"), 8, 32)
+	if err != nil {
+		log.Panic().Err(err).Msg("invalid `bind_socket_mode`, must be octal file mode")
+	}
+	s.BindSocketMode = fs.FileMode(socketMode)
 	s.Proxy = viper.GetBool("proxy")
 	s.CORS = viper.GetBool("cors")
 	s.PProf = viper.GetBool("pprof")
 	s.Metrics = viper.GetBool("metrics")
 
+	s.Admin.Bind = viper.GetString("admin.bind")
 	s.Admin.Static = viper.GetString("admin.static")
 	s.Admin.PathPrefix = path.Join("/", path.Clean(viper.GetString("admin.path_prefix")))
 	s.Admin.ProxyAuth = viper.GetString("admin.proxy_auth")
+	s.Admin.UserHeader = viper.GetString("admin.user_header")
 	s.Admin.Username = viper.GetString("admin.username")
 	s.Admin.Password = viper.GetString("admin.password")
+	s.Admin.MaxBodySize = viper.GetInt64("admin.max_body_size")
+
+	s.Admin.JsonMode = viper.GetString("admin.json_mode")
+	if s.Admin.JsonMode != "strict" && s.Admin.JsonMode != "warn" && s.Admin.JsonMode != "lenient" {
+		log.Panic().Msg("invalid `admin.json_mode`, must be one of strict, warn or lenient")
+	}
+
+	s.Admin.PolicyUrl = viper.GetString("admin.policy_url")
+
+	s.Admin.DemoToken = viper.GetString("demo.token")
+	s.Admin.DemoLifetimeSec = viper.GetInt("demo.lifetime")
+	if s.Admin.DemoLifetimeSec <= 0 {
+		log.Panic().Msg("invalid `demo.lifetime`, must be positive")
+	}
+	s.Admin.DemoMaxRooms = viper.GetInt("demo.max_rooms")
+	s.Admin.DemoNanoCPUs = int64(viper.GetFloat64("demo.cpus") * 1e9)
+	s.Admin.DemoMemory = viper.GetInt64("demo.memory")
+	s.Admin.DemoEnvs = viper.GetStringMapString("demo.envs")
+
+	s.Admin.DirectoryEnabled = viper.GetBool("directory.enabled")
+	s.Admin.DirectoryCacheSec = viper.GetInt("directory.cache")
+	s.Admin.DirectoryRateLimit = viper.GetInt("directory.rate_limit")
+	if s.Admin.DirectoryRateLimit <= 0 {
+		log.Panic().Msg("invalid `directory.rate_limit`, must be positive")
+	}
+	s.Admin.DirectoryTrusted = []*net.IPNet{}
+	for _, val := range viper.GetStringSlice("directory.trusted_proxies") {
+		// single address is converted to network containing only itself
+		if ip := net.ParseIP(val); ip != nil {
+			bits := 8 * len(ip)
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			val = ip.String() + "/" + strconv.Itoa(bits)
+		}
+
+		_, ipNet, err := net.ParseCIDR(val)
+		if err != nil {
+			log.Panic().Str("proxy", val).Msg("invalid `directory.trusted_proxies`, must be IP or CIDR")
+		}
+		s.Admin.DirectoryTrusted = append(s.Admin.DirectoryTrusted, ipNet)
+	}
+
+	s.Admin.DeeplinkToken = viper.GetString("deeplink.token")
+
+	s.Setup.Enabled = viper.GetBool("setup.enabled")
+	s.Setup.Code = viper.GetString("setup.code")
+	s.Setup.ConfigFile = viper.GetString("setup.config_file")
+	if s.Setup.ConfigFile == "" {
+		s.Setup.ConfigFile = viper.ConfigFileUsed()
+	}
+	if s.Setup.ConfigFile == "" {
+		// default location, where configuration file is looked up
+		s.Setup.ConfigFile = "neko_rooms.yml"
+		if runtime.GOOS == "linux" {
+			s.Setup.ConfigFile = "/etc/neko_rooms/neko_rooms.yml"
+		}
+	}
 }
@@ -6,6 +6,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/m1k1o/neko-rooms/internal/types"
 )
@@ -48,6 +49,25 @@ func Generate(policies types.BrowserPolicyContent) (string, error) {
 	policiesTmpl["ExtensionInstallAllowlist"] = ExtensionInstallAllowlist
 	policiesTmpl["ExtensionInstallBlocklist"] = []any{"*"}
 
+	//
+	// Bookmarks
+	//
+
+	if len(policies.Bookmarks) > 0 {
+		ManagedBookmarks := []any{
+			map[string]any{"toplevel_name": "Bookmarks"},
+		}
+		for _, b := range policies.Bookmarks {
+			ManagedBookmarks = append(ManagedBookmarks, map[string]any{
+				"name": b.Name,
+				"url":  b.URL,
+			})
+		}
+
+		policiesTmpl["ManagedBookmarks"] = ManagedBookmarks
+		policiesTmpl["BookmarkBarEnabled"] = true
+	}
+
 	//
 	// Developer Tools
 	//
@@ -76,6 +96,38 @@ func Generate(policies types.BrowserPolicyContent) (string, error) {
 		policiesTmpl["RestoreOnStartup"] = 5
 	}
 
+	//
+	// Homepage
+	//
+
+	if policies.Homepage != "" {
+		policiesTmpl["HomepageLocation"] = policies.Homepage
+		policiesTmpl["HomepageIsNewTabPage"] = false
+		// Open a list of URLs
+		policiesTmpl["RestoreOnStartup"] = 4
+		policiesTmpl["RestoreOnStartupURLs"] = []string{policies.Homepage}
+	}
+
+	//
+	// Proxy
+	//
+
+	if policies.Proxy != nil {
+		proxyServer := []string{}
+		if policies.Proxy.Http != "" {
+			proxyServer = append(proxyServer, "http="+policies.Proxy.Http)
+		}
+		if policies.Proxy.Https != "" {
+			proxyServer = append(proxyServer, "https="+policies.Proxy.Https)
+		}
+
+		policiesTmpl["ProxySettings"] = map[string]any{
+			"ProxyMode":       "fixed_servers",
+			"ProxyServer":     strings.Join(proxyServer, ";"),
+			"ProxyBypassList": policies.Proxy.NoProxy,
+		}
+	}
+
 	data, err := json.MarshalIndent(policiesTmpl, "", "  ")
 	if err != nil {
 		return "", err
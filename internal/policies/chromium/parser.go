@@ -39,6 +39,35 @@ func Parse(policiesJson string) (*types.BrowserPolicyContent, error) {
 		}
 	}
 
+	//
+	// Bookmarks
+	//
+
+	if bookmarks, ok := policiesTmpl["ManagedBookmarks"].([]any); ok {
+		for _, val := range bookmarks {
+			data, ok := val.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			// skip folder name
+			if _, ok := data["toplevel_name"]; ok {
+				continue
+			}
+
+			name, _ := data["name"].(string)
+			url, _ := data["url"].(string)
+
+			policies.Bookmarks = append(
+				policies.Bookmarks,
+				types.BrowserPolicyBookmark{
+					Name: name,
+					URL:  url,
+				},
+			)
+		}
+	}
+
 	//
 	// Developer Tools
 	//
@@ -55,5 +84,13 @@ func Parse(policiesJson string) (*types.BrowserPolicyContent, error) {
 		policies.PersistentData = val.(float64) == 1
 	}
 
+	//
+	// Homepage
+	//
+
+	if val, ok := policiesTmpl["HomepageLocation"].(string); ok {
+		policies.Homepage = val
+	}
+
 	return &policies, nil
 }
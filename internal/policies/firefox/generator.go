@@ -5,6 +5,7 @@ package firefox
 import (
 	_ "embed"
 	"encoding/json"
+	"net/url"
 
 	"github.com/m1k1o/neko-rooms/internal/types"
 )
@@ -30,14 +31,37 @@ func Generate(policies types.BrowserPolicyContent) (string, error) {
 	}
 
 	for _, e := range policies.Extensions {
+		URL := e.URL
+		if URL == "" {
+			URL = "https://addons.mozilla.org/firefox/downloads/latest/" + url.PathEscape(e.ID) + "/latest.xpi"
+		}
+
 		ExtensionSettings[e.ID] = map[string]any{
-			"install_url":       e.URL,
+			"install_url":       URL,
 			"installation_mode": "force_installed",
 		}
 	}
 
 	policiesTmpl.Policies["ExtensionSettings"] = ExtensionSettings
 
+	//
+	// Bookmarks
+	//
+
+	if len(policies.Bookmarks) > 0 {
+		Bookmarks := []any{}
+		for _, b := range policies.Bookmarks {
+			Bookmarks = append(Bookmarks, map[string]any{
+				"Title":     b.Name,
+				"URL":       b.URL,
+				"Placement": "toolbar",
+			})
+		}
+
+		policiesTmpl.Policies["Bookmarks"] = Bookmarks
+		policiesTmpl.Policies["DisplayBookmarksToolbar"] = true
+	}
+
 	//
 	// Developer Tools
 	//
@@ -64,6 +88,39 @@ func Generate(policies types.BrowserPolicyContent) (string, error) {
 		}
 	}
 
+	//
+	// Homepage
+	//
+
+	if policies.Homepage != "" {
+		policiesTmpl.Policies["Homepage"] = map[string]any{
+			"URL":       policies.Homepage,
+			"StartPage": "homepage",
+		}
+	}
+
+	//
+	// Proxy
+	//
+
+	if policies.Proxy != nil {
+		Proxy := map[string]any{
+			"Mode":        "manual",
+			"Locked":      true,
+			"Passthrough": policies.Proxy.NoProxy,
+		}
+
+		// firefox expects host:port without scheme
+		if u, err := url.Parse(policies.Proxy.Http); err == nil && u.Host != "" {
+			Proxy["HTTPProxy"] = u.Host
+		}
+		if u, err := url.Parse(policies.Proxy.Https); err == nil && u.Host != "" {
+			Proxy["SSLProxy"] = u.Host
+		}
+
+		policiesTmpl.Policies["Proxy"] = Proxy
+	}
+
 	data, err := json.MarshalIndent(policiesTmpl, "", "  ")
 	if err != nil {
 		return "", err
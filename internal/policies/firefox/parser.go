@@ -48,6 +48,30 @@ func Parse(policiesJson string) (*types.BrowserPolicyContent, error) {
 		}
 	}
 
+	//
+	// Bookmarks
+	//
+
+	if bookmarks, ok := policiesTmpl.Policies["Bookmarks"].([]any); ok {
+		for _, val := range bookmarks {
+			data, ok := val.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			name, _ := data["Title"].(string)
+			url, _ := data["URL"].(string)
+
+			policies.Bookmarks = append(
+				policies.Bookmarks,
+				types.BrowserPolicyBookmark{
+					Name: name,
+					URL:  url,
+				},
+			)
+		}
+	}
+
 	//
 	// Developer Tools
 	//
@@ -64,5 +88,13 @@ func Parse(policiesJson string) (*types.BrowserPolicyContent, error) {
 		policies.PersistentData = !val.(bool)
 	}
 
+	//
+	// Homepage
+	//
+
+	if homepage, ok := policiesTmpl.Policies["Homepage"].(map[string]any); ok {
+		policies.Homepage, _ = homepage["URL"].(string)
+	}
+
 	return &policies, nil
 }
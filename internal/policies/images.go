@@ -0,0 +1,38 @@
+package policies
+
+import (
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+type imagePolicy struct {
+	policyType types.BrowserPolicyType
+	path       string
+	images     []string
+}
+
+// keep in sync with browserPolicyConfig in client
+var imagePolicies = []imagePolicy{
+	// firefox esr
+	{types.FirefoxBrowserPolicy, "/usr/lib/firefox-esr/distribution/policies.json", []string{"arm-firefox"}},
+	// firefox (needs to be after firefox-esr, because it matches all remaining firefox images)
+	{types.FirefoxBrowserPolicy, "/usr/lib/firefox/distribution/policies.json", []string{"m1k1o/neko:latest", "firefox"}},
+	{types.ChromiumBrowserPolicy, "/etc/chromium/policies/managed/policies.json", []string{"chromium", "ungoogled-chromium"}},
+	{types.ChromiumBrowserPolicy, "/etc/opt/chrome/policies/managed/policies.json", []string{"google-chrome"}},
+	{types.ChromiumBrowserPolicy, "/etc/brave/policies/managed/policies.json", []string{"brave"}},
+	{types.ChromiumBrowserPolicy, "/etc/opt/edge/policies/managed/policies.json", []string{"microsoft-edge"}},
+}
+
+// ForImage returns browser policy type and path for known browser images.
+func ForImage(image string) (types.BrowserPolicyType, string, bool) {
+	for _, config := range imagePolicies {
+		for _, name := range config.images {
+			if strings.Contains(image, name) {
+				return config.policyType, config.path, true
+			}
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// how long is room fullness cached, so that stats are not requested on every page load
+const roomFullCacheTTL = 5 * time.Second
+
+type roomFullness struct {
+	full    bool
+	checked time.Time
+}
+
+// isRoomPage returns true for page requests of the room itself, not for its assets or websocket.
+func isRoomPage(r *http.Request, cleanPath, prefix string) bool {
+	return r.Method == http.MethodGet &&
+		cleanPath == prefix &&
+		r.Header.Get("Upgrade") == "" &&
+		strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// isRoomFull returns true, if room has reached its maximum number of connections.
+func (p *ProxyManagerCtx) isRoomFull(ctx context.Context, roomId string) bool {
+	p.fullMu.Lock()
+	cached, ok := p.full[roomId]
+	p.fullMu.Unlock()
+
+	if ok && time.Since(cached.checked) < roomFullCacheTTL {
+		return cached.full
+	}
+
+	full := false
+	if entry, err := p.rooms.GetEntry(ctx, roomId); err != nil {
+		p.logger.Warn().Err(err).Str("id", roomId).Msg("unable to get room entry")
+	} else if entry.MaxConnections > 0 {
+		// when using mux, number of connections is not limited by ports
+		stats, err := p.rooms.GetStats(ctx, roomId)
+		if err != nil {
+			p.logger.Warn().Err(err).Str("id", roomId).Msg("unable to get room stats")
+		} else {
+			full = stats.Connections >= uint32(entry.MaxConnections)
+		}
+	}
+
+	p.fullMu.Lock()
+	p.full[roomId] = roomFullness{
+		full:    full,
+		checked: time.Now(),
+	}
+	p.fullMu.Unlock()
+
+	return full
+}
+
+func (p *ProxyManagerCtx) forgetRoomFull(roomId string) {
+	p.fullMu.Lock()
+	delete(p.full, roomId)
+	p.fullMu.Unlock()
+}
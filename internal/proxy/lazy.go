@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	// how long can starting of the room take, before it is given up
+	lazyStartTimeout = 2 * time.Minute
+	// how long is room, that must be kept stopped, not tried again
+	lazyStartRefused = time.Minute
+)
+
+// startRoom starts stopped room in background, when it was visited. Room is
+// started only once, even if it is visited by many clients at the same time.
+// It returns false, when the room was recently refused to be started.
+func (p *ProxyManagerCtx) startRoom(roomId string) bool {
+	p.startingMu.Lock()
+	if until, ok := p.keptStopped[roomId]; ok {
+		if time.Now().Before(until) {
+			p.startingMu.Unlock()
+			return false
+		}
+		delete(p.keptStopped, roomId)
+	}
+	if _, ok := p.starting[roomId]; ok {
+		p.startingMu.Unlock()
+		return true
+	}
+	p.starting[roomId] = struct{}{}
+	p.startingMu.Unlock()
+
+	go func() {
+		defer func() {
+			p.startingMu.Lock()
+			delete(p.starting, roomId)
+			p.startingMu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(p.ctx, lazyStartTimeout)
+		defer cancel()
+
+		err := p.rooms.LazyStart(ctx, roomId)
+		if errors.Is(err, types.ErrRoomKeptStopped) {
+			p.startingMu.Lock()
+			p.keptStopped[roomId] = time.Now().Add(lazyStartRefused)
+			p.startingMu.Unlock()
+
+			p.logger.Info().Err(err).Str("id", roomId).Msg("lazy start: room is not started on visit")
+			return
+		}
+		if err != nil {
+			p.logger.Err(err).Str("id", roomId).Msg("lazy start: unable to start room")
+			return
+		}
+
+		p.logger.Info().Str("id", roomId).Msg("lazy start: room started on visit")
+	}()
+
+	return true
+}
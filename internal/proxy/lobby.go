@@ -88,6 +88,30 @@ func RoomNotRunning(w http.ResponseWriter, r *http.Request, waitEnabled bool) {
 	}
 }
 
+func RoomStarting(w http.ResponseWriter, r *http.Request, waitEnabled bool) {
+	utils.Swal2Response(w, `
+		<div class="swal2-header">
+			<div class="swal2-icon swal2-info">
+				<div class="swal2-icon-content">i</div>
+			</div>
+			<h2 class="swal2-title">Room is starting&hellip;</h2>
+		</div>
+		<div class="swal2-content">
+			<div>The room you are trying to join was not running, it is being started now.</div>
+			<div>You will be taken to the room, once it is ready.</div>
+		</div>
+		<div class="swal2-actions">
+			<div class="swal2-loader"></div>
+		</div>
+	`)
+
+	if waitEnabled {
+		roomWait(w, r)
+	} else {
+		w.Write([]byte(`<meta http-equiv="refresh" content="2">`))
+	}
+}
+
 func RoomNotReady(w http.ResponseWriter, r *http.Request, waitEnabled bool) {
 	utils.Swal2Response(w, `
 		<meta http-equiv="refresh" content="2">
@@ -137,3 +161,24 @@ func RoomReady(w http.ResponseWriter, r *http.Request) {
 		</div>
 	`)
 }
+
+func RoomFull(w http.ResponseWriter, r *http.Request) {
+	utils.Swal2Response(w, `
+		<meta http-equiv="refresh" content="10">
+
+		<div class="swal2-header">
+			<div class="swal2-icon swal2-warning">
+				<div class="swal2-icon-content">!</div>
+			</div>
+			<h2 class="swal2-title">Room is full!</h2>
+		</div>
+		<div class="swal2-content">
+			<div>The room you are trying to join has reached its maximum number of connections.</div>
+			<div>You can wait on this page until someone leaves.</div>
+		</div>
+		<div class="swal2-actions">
+			<div class="swal2-loader"></div>
+			<button type="button" onclick="location = location" class="swal2-confirm swal2-styled" style="margin-top: 1.25em">Reload</button>
+		</div>
+	`)
+}
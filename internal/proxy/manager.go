@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -9,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -40,17 +42,30 @@ type ProxyManagerCtx struct {
 	waitChans   map[string]*wait
 	waitEnabled bool
 
+	fullMu sync.Mutex
+	full   map[string]roomFullness
+
+	lazyStart   bool
+	startingMu  sync.Mutex
+	starting    map[string]struct{}
+	keptStopped map[string]time.Time // rooms refused by lazy start, until when
+
 	rooms    *room.RoomManagerCtx
 	handlers prefix.Tree[*entry]
 }
 
-func New(rooms *room.RoomManagerCtx, waitEnabled bool) *ProxyManagerCtx {
+func New(rooms *room.RoomManagerCtx, waitEnabled bool, lazyStart bool) *ProxyManagerCtx {
 	return &ProxyManagerCtx{
 		logger:    log.With().Str("module", "proxy").Logger(),
 		waitChans: map[string]*wait{},
+		full:      map[string]roomFullness{},
+		starting:  map[string]struct{}{},
+
+		keptStopped: map[string]time.Time{},
 
 		rooms:       rooms,
 		waitEnabled: waitEnabled,
+		lazyStart:   lazyStart,
 		handlers:    prefix.NewTree[*entry](),
 	}
 }
@@ -75,12 +90,12 @@ func (p *ProxyManagerCtx) Start() {
 
 				p.logger.Err(err).Msg("room event error")
 			case msg, ok := <-msgs:
-				enabled, path, port, ok := p.parseLabels(msg.ContainerLabels)
+				enabled, path, target, ok := p.parseLabels(msg.ContainerLabels)
 				if !ok {
 					break
 				}
 
-				host := msg.ID + ":" + port
+				host := msg.ID + ":" + target.port
 
 				p.logger.Info().
 					Str("action", string(msg.Action)).
@@ -88,52 +103,55 @@ func (p *ProxyManagerCtx) Start() {
 					Str("host", host).
 					Msg("got room event")
 
-				// terminate waiting for room ready event
-				if p.waitEnabled && msg.Action == types.RoomEventReady {
-					p.waitMu.Lock()
-					ch, ok := p.waitChans[path]
-					if ok {
-						close(ch.signal)
-						delete(p.waitChans, path)
+				for _, path := range roomPaths(path, msg.ContainerLabels) {
+					// terminate waiting for room ready event
+					if p.waitEnabled && msg.Action == types.RoomEventReady {
+						p.waitMu.Lock()
+						ch, ok := p.waitChans[path]
+						if ok {
+							close(ch.signal)
+							delete(p.waitChans, path)
+						}
+						p.waitMu.Unlock()
 					}
-					p.waitMu.Unlock()
-				}
 
-				p.mu.Lock()
-				switch msg.Action {
-				case types.RoomEventCreated:
-					p.handlers.Insert(path, &entry{
-						id:      msg.ID,
-						running: false,
-					})
-				case types.RoomEventStarted:
-					p.handlers.Insert(path, &entry{
-						id:      msg.ID,
-						running: true,
-						ready:   false,
-					})
-				case types.RoomEventReady:
-					e := &entry{
-						id:      msg.ID,
-						running: true,
-						ready:   true,
+					p.mu.Lock()
+					switch msg.Action {
+					case types.RoomEventCreated:
+						p.handlers.Insert(path, &entry{
+							id:      msg.ID,
+							running: false,
+						})
+					case types.RoomEventStarted:
+						p.handlers.Insert(path, &entry{
+							id:      msg.ID,
+							running: true,
+							ready:   false,
+						})
+					case types.RoomEventReady:
+						e := &entry{
+							id:      msg.ID,
+							running: true,
+							ready:   true,
+						}
+
+						// if proxying is disabled
+						if enabled {
+							e.handler = p.newProxyHandler(path, target.scheme, host)
+						}
+
+						p.handlers.Insert(path, e)
+					case types.RoomEventStopped:
+						p.handlers.Insert(path, &entry{
+							id:      msg.ID,
+							running: false,
+						})
+					case types.RoomEventDestroyed:
+						p.handlers.Remove(path)
+						p.forgetRoomFull(msg.ID)
 					}
-
-					// if proxying is disabled
-					if enabled {
-						e.handler = p.newProxyHandler(path, host)
-					}
-
-					p.handlers.Insert(path, e)
-				case types.RoomEventStopped:
-					p.handlers.Insert(path, &entry{
-						id:      msg.ID,
-						running: false,
-					})
-				case types.RoomEventDestroyed:
-					p.handlers.Remove(path)
+					p.mu.Unlock()
 				}
-				p.mu.Unlock()
 			}
 		}
 	}()
@@ -156,31 +174,38 @@ func (p *ProxyManagerCtx) Refresh() error {
 	p.handlers = prefix.NewTree[*entry]()
 
 	for _, room := range rooms {
-		enabled, path, port, ok := p.parseLabels(room.ContainerLabels)
+		enabled, path, target, ok := p.parseLabels(room.ContainerLabels)
 		if !ok {
 			continue
 		}
 
-		host := room.ID + ":" + port
+		host := room.ID + ":" + target.port
 
-		entry := &entry{
-			id:      room.ID,
-			running: room.Running,
-			ready:   room.IsReady,
-		}
+		for _, path := range roomPaths(path, room.ContainerLabels) {
+			entry := &entry{
+				id:      room.ID,
+				running: room.Running,
+				ready:   room.IsReady,
+			}
 
-		// if proxying is enabled and room is ready
-		if enabled && room.IsReady {
-			entry.handler = p.newProxyHandler(path, host)
-		}
+			// if proxying is enabled and room is ready
+			if enabled && room.IsReady {
+				entry.handler = p.newProxyHandler(path, target.scheme, host)
+			}
 
-		p.handlers.Insert(path, entry)
+			p.handlers.Insert(path, entry)
+		}
 	}
 
 	return nil
 }
 
-func (p *ProxyManagerCtx) parseLabels(labels map[string]string) (enabled bool, path string, port string, ok bool) {
+type proxyTarget struct {
+	scheme string
+	port   string
+}
+
+func (p *ProxyManagerCtx) parseLabels(labels map[string]string) (enabled bool, path string, target proxyTarget, ok bool) {
 	var enabledStr string
 	enabledStr, ok = labels["m1k1o.neko_rooms.proxy.enabled"]
 	if !ok {
@@ -195,15 +220,39 @@ func (p *ProxyManagerCtx) parseLabels(labels map[string]string) (enabled bool, p
 		return
 	}
 
-	port, ok = labels["m1k1o.neko_rooms.proxy.port"]
+	target.port, ok = labels["m1k1o.neko_rooms.proxy.port"]
+	if !ok {
+		return
+	}
+
+	// scheme is optional, defaults to http
+	target.scheme, ok = labels["m1k1o.neko_rooms.proxy.scheme"]
+	if !ok {
+		target.scheme, ok = "http", true
+	}
 	return
 }
 
-func (p *ProxyManagerCtx) newProxyHandler(prefix, host string) http.Handler {
+// roomPaths returns room path followed by paths of all its aliases
+func roomPaths(path string, labels map[string]string) []string {
+	paths := []string{path}
+	if aliases, ok := labels["m1k1o.neko_rooms.proxy.aliases"]; ok && aliases != "" {
+		paths = append(paths, strings.Split(aliases, ",")...)
+	}
+	return paths
+}
+
+func (p *ProxyManagerCtx) newProxyHandler(prefix, scheme, host string) http.Handler {
 	handler := httputil.NewSingleHostReverseProxy(&url.URL{
-		Scheme: "http",
+		Scheme: scheme,
 		Host:   host,
 	})
+	if scheme == "https" {
+		// rooms use self-signed certificates
+		handler.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
 	handler.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
 		p.logger.Err(err).Str("prefix", prefix).Msg("proxy error")
 		http.Error(w, "unable to connect to room", http.StatusBadGateway)
@@ -263,6 +312,8 @@ func (p *ProxyManagerCtx) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		if !ok {
 			RoomNotFound(w, r, p.waitEnabled)
+		} else if !proxy.running && p.lazyStart && isRoomPage(r, cleanPath, prefix) && p.startRoom(proxy.id) {
+			RoomStarting(w, r, p.waitEnabled)
 		} else if !proxy.running {
 			RoomNotRunning(w, r, p.waitEnabled)
 		} else {
@@ -284,6 +335,12 @@ func (p *ProxyManagerCtx) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// friendly page instead of neko rejecting the connection
+	if isRoomPage(r, cleanPath, prefix) && p.isRoomFull(r.Context(), proxy.id) {
+		RoomFull(w, r)
+		return
+	}
+
 	// handle by proxy
 	proxy.handler.ServeHTTP(w, r)
 }
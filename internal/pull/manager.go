@@ -15,12 +15,14 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/m1k1o/neko-rooms/internal/config"
 	"github.com/m1k1o/neko-rooms/internal/types"
 	"github.com/m1k1o/neko-rooms/internal/utils"
 )
 
 type PullManagerCtx struct {
 	logger zerolog.Logger
+	config *config.Room
 	client *dockerClient.Client
 	images []string
 
@@ -31,13 +33,21 @@ type PullManagerCtx struct {
 
 	chansMu sync.Mutex
 	chans   []chan<- string
+
+	prepullCancel func()
+	prepullWg     sync.WaitGroup
+
+	dockerOps utils.Semaphore
 }
 
-func New(client *dockerClient.Client, nekoImages []string) *PullManagerCtx {
+func New(client *dockerClient.Client, config *config.Room, dockerOps utils.Semaphore) *PullManagerCtx {
 	return &PullManagerCtx{
 		logger: log.With().Str("module", "pull").Logger(),
+		config: config,
 		client: client,
-		images: nekoImages,
+		images: config.NekoImages,
+
+		dockerOps: dockerOps,
 	}
 }
 
@@ -79,6 +89,10 @@ func (manager *PullManagerCtx) Start(request types.PullStart) error {
 		return fmt.Errorf("unknown neko image")
 	}
 
+	if err := manager.checkOffline(request.NekoImage); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	if !manager.tryInitialize(cancel) {
 		return fmt.Errorf("pull is already in progess")
@@ -102,9 +116,16 @@ func (manager *PullManagerCtx) Start(request types.PullStart) error {
 		}
 	}
 
+	// wait for other heavy docker operations
+	if err := manager.dockerOps.Acquire(ctx); err != nil {
+		manager.setDone()
+		return err
+	}
+
 	reader, err := manager.client.ImagePull(ctx, request.NekoImage, opts)
 
 	if err != nil {
+		manager.dockerOps.Release()
 		manager.setDone()
 		return err
 	}
@@ -148,6 +169,7 @@ func (manager *PullManagerCtx) Start(request types.PullStart) error {
 		}
 
 		reader.Close()
+		manager.dockerOps.Release()
 		manager.setDone()
 	}()
 
@@ -205,6 +227,11 @@ func (manager *PullManagerCtx) Subscribe(ch chan<- string) func() {
 }
 
 func (manager *PullManagerCtx) Shutdown() error {
+	if manager.prepullCancel != nil {
+		manager.prepullCancel()
+		manager.prepullWg.Wait()
+	}
+
 	manager.chansMu.Lock()
 	for _, ch := range manager.chans {
 		close(ch)
@@ -0,0 +1,38 @@
+package pull
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// imageRegistry returns registry domain of image reference, docker hub is returned as docker.io.
+func imageRegistry(image string) string {
+	i := strings.IndexRune(image, '/')
+	if i == -1 {
+		return "docker.io"
+	}
+
+	// first component is a registry, only if it looks like a hostname
+	domain := image[:i]
+	if !strings.ContainsAny(domain, ".:") && domain != "localhost" {
+		return "docker.io"
+	}
+
+	return domain
+}
+
+// checkOffline returns error, if image can not be pulled in offline mode.
+func (manager *PullManagerCtx) checkOffline(image string) error {
+	if !manager.config.Offline {
+		return nil
+	}
+
+	registry := imageRegistry(image)
+	if in, _ := utils.ArrayIn(registry, manager.config.OfflineRegistries); in {
+		return nil
+	}
+
+	return fmt.Errorf("image %s can not be pulled in offline mode, registry %s is not in offline registries", image, registry)
+}
@@ -0,0 +1,85 @@
+package pull
+
+import (
+	"context"
+	"io"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+)
+
+// how often are images checked
+const prepullInterval = time.Hour
+
+// StartPrepull ensures, that all whitelisted images are pulled ahead of time,
+// so that creating first room does not need to wait for the pull.
+func (manager *PullManagerCtx) StartPrepull() {
+	ctx, cancel := context.WithCancel(context.Background())
+	manager.prepullCancel = cancel
+
+	manager.prepullWg.Add(1)
+	go func() {
+		defer manager.prepullWg.Done()
+
+		ticker := time.NewTicker(prepullInterval)
+		defer ticker.Stop()
+
+		for {
+			manager.prepull(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (manager *PullManagerCtx) prepull(ctx context.Context) {
+	for _, image := range manager.images {
+		logger := manager.logger.With().Str("image", image).Logger()
+
+		_, _, err := manager.client.ImageInspectWithRaw(ctx, image)
+		if err == nil {
+			continue
+		}
+
+		if !dockerClient.IsErrNotFound(err) {
+			logger.Err(err).Msg("prepull: failed to inspect image")
+			continue
+		}
+
+		if err := manager.checkOffline(image); err != nil {
+			logger.Warn().Err(err).Msg("prepull: skipping image")
+			continue
+		}
+
+		// wait for other heavy docker operations
+		if err := manager.dockerOps.Acquire(ctx); err != nil {
+			return
+		}
+
+		logger.Info().Msg("prepull: pulling image")
+
+		reader, err := manager.client.ImagePull(ctx, image, dockerTypes.ImagePullOptions{})
+		if err != nil {
+			manager.dockerOps.Release()
+			logger.Err(err).Msg("prepull: failed to pull image")
+			continue
+		}
+
+		// pull is finished when reader is closed
+		_, err = io.Copy(io.Discard, reader)
+		reader.Close()
+		manager.dockerOps.Release()
+
+		if err != nil {
+			logger.Err(err).Msg("prepull: failed to pull image")
+			continue
+		}
+
+		logger.Info().Msg("prepull: image pulled")
+	}
+}
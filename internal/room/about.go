@@ -0,0 +1,52 @@
+package room
+
+import (
+	"context"
+)
+
+// Features returns names of optional features, that are enabled in config.
+func (manager *RoomManagerCtx) Features() []string {
+	features := []string{}
+	for _, feature := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"mux", manager.config.Mux},
+		{"storage", manager.config.StorageEnabled},
+		{"traefik", manager.config.Traefik.Enabled},
+		{"prepull", manager.config.Prepull},
+		{"webhook", manager.config.WebhookUrl != ""},
+		{"oom_recreate", manager.config.OOMMemoryIncrement > 0},
+		{"disk_pressure", manager.config.DiskThreshold > 0},
+		{"watchdog", manager.config.WatchdogTimeoutSec > 0},
+		{"http_proxy", manager.config.HttpProxy != "" || manager.config.HttpsProxy != ""},
+		{"docker_max_operations", manager.config.DockerMaxOperations > 0},
+		{"offline", manager.config.Offline},
+		{"activity", manager.config.ActivityIntervalSec > 0},
+		{"lazy_start", manager.config.LazyStart},
+		{"idle_shutdown", manager.config.ActivityIntervalSec > 0 && manager.config.IdleTimeoutSec > 0},
+		{"archive_verify", manager.config.ArchivesVerifyIntervalSec > 0},
+		{"webrtc_stats", manager.config.WebRTCStatsIntervalSec > 0},
+		{"hooks", manager.config.HooksPreCreate != "" || manager.config.HooksPostStart != ""},
+		{"alert_rules", len(manager.config.AlertRules) > 0},
+		{"doctor", manager.config.DoctorIntervalSec > 0},
+		{"import_compose", len(manager.config.ImportComposeProjects) > 0},
+		{"autoscale", manager.config.AutoscaleIntervalSec > 0},
+		{"tiering", manager.config.TieringMode != "off"},
+		{"start_stagger", manager.config.StartStaggerMs > 0 || manager.config.StartJitterMs > 0},
+	} {
+		if feature.enabled {
+			features = append(features, feature.name)
+		}
+	}
+	return features
+}
+
+func (manager *RoomManagerCtx) DockerVersion(ctx context.Context) (string, error) {
+	version, err := manager.client.ServerVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return version.Version, nil
+}
@@ -0,0 +1,146 @@
+package room
+
+import (
+	"context"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// watchActivity periodically polls running rooms for their last user activity.
+func (e *events) watchActivity() {
+	if e.config.ActivityIntervalSec <= 0 || e.onActivityPoll == nil {
+		return
+	}
+
+	e.every(time.Duration(e.config.ActivityIntervalSec)*time.Second, func() { e.onActivityPoll(e.ctx) })
+}
+
+// recordActivity stores last activity of room by its uuid, so that it survives
+// recreates. Older activity does not override newer one.
+func (e *events) recordActivity(uuid string, at time.Time) {
+	e.activityMu.Lock()
+	defer e.activityMu.Unlock()
+
+	if last, ok := e.activity[uuid]; !ok || at.After(last) {
+		e.activity[uuid] = at
+	}
+}
+
+func (e *events) LastActivity(uuid string) *time.Time {
+	e.activityMu.Lock()
+	defer e.activityMu.Unlock()
+
+	if at, ok := e.activity[uuid]; ok {
+		return &at
+	}
+	return nil
+}
+
+// lastActivityFromStats returns time, when room was used last time.
+func lastActivityFromStats(stats *types.RoomStats, now time.Time) *time.Time {
+	if stats.Connections > 0 {
+		return &now
+	}
+
+	var last *time.Time
+	for _, at := range []*time.Time{stats.LastUserLeftAt, stats.LastAdminLeftAt} {
+		if at != nil && (last == nil || at.After(*last)) {
+			last = at
+		}
+	}
+
+	return last
+}
+
+func (manager *RoomManagerCtx) pollActivity(ctx context.Context) {
+	entries, err := manager.List(ctx, nil)
+	if err != nil {
+		manager.logger.Err(err).Msg("activity: failed to list rooms")
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.Running || !entry.Profile.IsNeko() {
+			continue
+		}
+
+		// stop early on shutdown
+		if ctx.Err() != nil {
+			return
+		}
+
+		statsCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		stats, err := manager.GetStats(statsCtx, entry.ID)
+		cancel()
+
+		if err != nil {
+			// room could be starting or not respond
+			manager.logger.Debug().Err(err).Str("id", entry.ID).Msg("activity: failed to get room stats")
+			continue
+		}
+
+		if at := lastActivityFromStats(stats, time.Now()); at != nil {
+			manager.events.recordActivity(entry.UUID, *at)
+		}
+
+		if stats.Connections == 0 {
+			manager.stopIdle(ctx, entry)
+		}
+	}
+}
+
+// idleTimeout returns idle timeout of room, room labels override config.
+func (manager *RoomManagerCtx) idleTimeout(entry types.RoomEntry) (time.Duration, error) {
+	labels, err := manager.extractLabels(entry.ContainerLabels)
+	if err != nil {
+		return 0, err
+	}
+
+	timeout := manager.config.IdleTimeoutSec
+	if labels.IdleTimeout != nil {
+		timeout = *labels.IdleTimeout
+	}
+
+	return time.Duration(timeout) * time.Second, nil
+}
+
+// stopIdle stops room, that had no connected members since it was started or
+// since last activity for longer than idle timeout.
+func (manager *RoomManagerCtx) stopIdle(ctx context.Context, entry types.RoomEntry) {
+	logger := manager.logger.With().Str("id", entry.ID).Str("name", entry.Name).Logger()
+
+	timeout, err := manager.idleTimeout(entry)
+	if err != nil || timeout <= 0 {
+		return
+	}
+
+	containerJson, err := manager.inspectContainer(ctx, entry.ID)
+	if err != nil {
+		logger.Debug().Err(err).Msg("activity: failed to inspect room")
+		return
+	}
+
+	// activity from before last start does not count
+	since, err := time.Parse(time.RFC3339Nano, containerJson.State.StartedAt)
+	if err != nil {
+		logger.Debug().Err(err).Msg("activity: failed to parse start time")
+		return
+	}
+
+	if last := manager.events.LastActivity(entry.UUID); last != nil && last.After(since) {
+		since = *last
+	}
+
+	idle := time.Since(since)
+	if idle < timeout {
+		return
+	}
+
+	if err := manager.Stop(ctx, entry.ID); err != nil {
+		logger.Err(err).Msg("activity: failed to stop idle room")
+		return
+	}
+
+	logger.Info().Dur("idle", idle).Msg("activity: idle room stopped")
+}
@@ -0,0 +1,50 @@
+package room
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const alertSendTimeout = 10 * time.Second
+
+// sendAlert posts alert to configured webhook, if any.
+func (e *events) sendAlert(alert types.RoomAlert) {
+	logger := e.logger.With().Str("id", alert.ID).Str("alert", string(alert.Type)).Logger()
+	logger.Warn().Str("name", alert.Name).Msg(alert.Message)
+
+	if e.config.WebhookUrl == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), alertSendTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		logger.Err(err).Msg("alert: failed to marshal payload")
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.WebhookUrl, bytes.NewReader(data))
+	if err != nil {
+		logger.Err(err).Msg("alert: failed to create request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logger.Err(err).Msg("alert: failed to send request")
+		return
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		logger.Warn().Int("status", res.StatusCode).Msg("alert: unexpected response status")
+	}
+}
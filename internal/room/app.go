@@ -0,0 +1,65 @@
+package room
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const appSupervisordPath = "/etc/neko/supervisord/app.conf"
+
+var appPathRegex = regexp.MustCompile(`^/[a-zA-Z0-9._/-]+$`)
+
+func validateApp(app *types.RoomApp) error {
+	if app.Name == "" {
+		return fmt.Errorf("app name must not be empty")
+	}
+
+	if strings.ContainsAny(app.Name, "\n\r") {
+		return fmt.Errorf("app name must be single line")
+	}
+
+	if !appPathRegex.MatchString(app.Path) {
+		return fmt.Errorf("app path must be absolute and match %s", appPathRegex.String())
+	}
+
+	if filepath.Clean(app.Path) != app.Path {
+		return fmt.Errorf("app path must be clean")
+	}
+
+	for _, arg := range app.Args {
+		if strings.ContainsAny(arg, "\n\r") {
+			return fmt.Errorf("app arguments must be single line")
+		}
+	}
+
+	return nil
+}
+
+// generate supervisord program, that is started together with neko
+func generateAppSupervisord(app *types.RoomApp) string {
+	command := []string{app.Path}
+	for _, arg := range app.Args {
+		// quote every argument, supervisord uses shell-like parsing
+		arg = strings.ReplaceAll(arg, `\`, `\\`)
+		arg = strings.ReplaceAll(arg, `"`, `\"`)
+		arg = strings.ReplaceAll(arg, `%`, `%%`)
+		command = append(command, `"`+arg+`"`)
+	}
+
+	return fmt.Sprintf(`[program:app]
+environment=HOME="/home/%%(ENV_USER)s",USER="%%(ENV_USER)s",DISPLAY="%%(ENV_DISPLAY)s"
+command=%s
+stopsignal=INT
+autorestart=true
+priority=800
+user=%%(ENV_USER)s
+stdout_logfile=/var/log/neko/app.log
+stdout_logfile_maxbytes=100MB
+stdout_logfile_backups=10
+redirect_stderr=true
+`, strings.Join(command, " "))
+}
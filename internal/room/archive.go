@@ -0,0 +1,277 @@
+package room
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	dockerNames "github.com/docker/docker/daemon/names"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+const (
+	archivesStoragePath = "./archives"
+
+	archiveSettingsFile   = "settings.json"
+	archivePrivatePrefix  = "private/"
+	archiveTimeFormat     = "20060102-150405"
+	archiveFileNameSuffix = ".tar.gz"
+)
+
+func (manager *RoomManagerCtx) archivePath(name string) (string, error) {
+	// only plain file names are allowed
+	if name == "" || name != filepath.Base(name) || filepath.Ext(name) != ".gz" {
+		return "", fmt.Errorf("invalid archive name")
+	}
+
+	return path.Join(manager.config.StorageInternal, archivesStoragePath, name), nil
+}
+
+// Archive stops the room and stores its settings together with contents of its
+// private storage to a tarball in archives storage.
+func (manager *RoomManagerCtx) Archive(ctx context.Context, id string) (*types.RoomArchive, error) {
+	if !manager.config.StorageEnabled {
+		return nil, fmt.Errorf("rooms cannot be archived, because storage is disabled or unavailable")
+	}
+
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := manager.GetSettings(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// notes are exported together with settings
+	settings.Notes, err = manager.GetNotes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// storage must not be changed while it is archived
+	if entry.Running {
+		if err := manager.Stop(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	created := time.Now()
+	name := entry.Name + "-" + created.UTC().Format(archiveTimeFormat) + archiveFileNameSuffix
+
+	archivePath, err := manager.archivePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(path.Dir(archivePath), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	if err := manager.writeArchive(archivePath, settings); err != nil {
+		os.Remove(archivePath)
+		return nil, err
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.RoomArchive{
+		Archive: name,
+		Size:    info.Size(),
+		Created: created,
+	}, nil
+}
+
+func (manager *RoomManagerCtx) writeArchive(archivePath string, settings *types.RoomSettings) error {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	// settings must be the first entry, so that they can be read before storage is extracted
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    archiveSettingsFile,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	privateStorage := path.Join(manager.config.StorageInternal, privateStoragePath, settings.Name)
+	if _, err := os.Stat(privateStorage); err == nil {
+		if err := utils.WriteTarDir(tw, privateStorage, archivePrivatePrefix); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// Restore creates room from a tarball in archives storage. Private storage of
+// the room with the same name is replaced by contents of the archive.
+func (manager *RoomManagerCtx) Restore(ctx context.Context, name string) (string, error) {
+	if !manager.config.StorageEnabled {
+		return "", fmt.Errorf("rooms cannot be restored, because storage is disabled or unavailable")
+	}
+
+	archivePath, err := manager.archivePath(name)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", types.ErrArchiveNotFound
+		}
+		return "", err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return "", err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil {
+		return "", fmt.Errorf("damaged archive: %w", err)
+	}
+
+	if header.Name != archiveSettingsFile {
+		return "", fmt.Errorf("damaged archive: %s not found", archiveSettingsFile)
+	}
+
+	var settings types.RoomSettings
+	if err := json.NewDecoder(tr).Decode(&settings); err != nil {
+		return "", fmt.Errorf("damaged archive: %w", err)
+	}
+
+	// name is used as storage path, it must be validated before touching storage
+	if settings.Name == "" {
+		return "", fmt.Errorf("damaged archive: room name is missing")
+	}
+
+	if !dockerNames.RestrictedNamePattern.MatchString(settings.Name) {
+		return "", fmt.Errorf("invalid container name, must match %s", dockerNames.RestrictedNameChars)
+	}
+
+	// room must not exist, otherwise its storage would be overwritten
+	if _, err := manager.GetEntryByName(ctx, settings.Name); err == nil {
+		return "", fmt.Errorf("room with this name already exists")
+	} else if !errors.Is(err, types.ErrRoomNotFound) {
+		return "", err
+	}
+
+	privateRoot := path.Join(manager.config.StorageInternal, privateStoragePath)
+	privateStorage := path.Join(privateRoot, settings.Name)
+
+	if err := os.MkdirAll(privateRoot, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	// archive is extracted to a fresh folder, so that links planted in existing
+	// storage by the room can not redirect writes outside of it
+	tmp, err := os.MkdirTemp(privateRoot, ".restore-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return "", err
+	}
+
+	if err := utils.ExtractTarEntries(tr, tmp, archivePrivatePrefix); err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("unable to extract archive: %w", err)
+	}
+
+	if err := utils.ChownR(tmp, privateStorageUid, privateStorageGid); err != nil {
+		return "", err
+	}
+
+	// existing storage is replaced, it is kept aside until the room is created
+	var previous string
+	if _, err := os.Lstat(privateStorage); err == nil {
+		previous = tmp + ".previous"
+		if err := os.Rename(privateStorage, previous); err != nil {
+			return "", err
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	logger := manager.logger.With().Str("name", settings.Name).Logger()
+
+	putBack := func() {
+		if previous == "" {
+			return
+		}
+		if err := os.Rename(previous, privateStorage); err != nil {
+			logger.Err(err).Str("path", previous).Msg("archives: unable to put back previous storage")
+		}
+	}
+
+	if err := os.Rename(tmp, privateStorage); err != nil {
+		putBack()
+		return "", err
+	}
+
+	id, err := manager.Create(ctx, settings)
+	if err != nil {
+		if err := os.RemoveAll(privateStorage); err != nil {
+			logger.Err(err).Msg("archives: unable to remove extracted storage")
+		}
+		putBack()
+		return "", err
+	}
+
+	if previous != "" {
+		if err := os.RemoveAll(previous); err != nil {
+			logger.Err(err).Str("path", previous).Msg("archives: unable to remove previous storage")
+		}
+	}
+
+	return id, nil
+}
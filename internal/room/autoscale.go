@@ -0,0 +1,201 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// member of room series with its occupancy
+type seriesMember struct {
+	entry       types.RoomEntry
+	connections uint32
+	known       bool // occupancy is known, room could be starting
+}
+
+func (m *seriesMember) full() bool {
+	return m.entry.Running && m.known && m.entry.MaxConnections > 0 &&
+		m.connections >= uint32(m.entry.MaxConnections)
+}
+
+// watchAutoscale periodically checks occupancy of room series.
+func (e *events) watchAutoscale() {
+	if e.config.AutoscaleIntervalSec <= 0 || e.onAutoscalePoll == nil {
+		return
+	}
+
+	e.every(time.Duration(e.config.AutoscaleIntervalSec)*time.Second, func() { e.onAutoscalePoll(e.ctx) })
+}
+
+func (manager *RoomManagerCtx) pollAutoscale(ctx context.Context) {
+	entries, err := manager.List(ctx, nil)
+	if err != nil {
+		manager.logger.Err(err).Msg("autoscale: failed to list rooms")
+		return
+	}
+
+	series := map[string][]*seriesMember{}
+	for _, entry := range entries {
+		if entry.Series == "" || !entry.Profile.IsNeko() {
+			continue
+		}
+
+		member := &seriesMember{entry: entry}
+		if entry.Running {
+			statsCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			stats, err := manager.GetStats(statsCtx, entry.ID)
+			cancel()
+
+			if err == nil {
+				member.connections = stats.Connections
+				member.known = true
+			}
+		}
+
+		series[entry.Series] = append(series[entry.Series], member)
+	}
+
+	seen := map[string]struct{}{}
+	for name, members := range series {
+		// stop early on shutdown
+		if ctx.Err() != nil {
+			return
+		}
+
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].entry.Name < members[j].entry.Name
+		})
+
+		for _, member := range members {
+			seen[member.entry.UUID] = struct{}{}
+		}
+
+		manager.scaleSeries(ctx, name, members)
+	}
+
+	for uuid := range manager.autoscaleEmpty {
+		if _, ok := seen[uuid]; !ok {
+			delete(manager.autoscaleEmpty, uuid)
+		}
+	}
+}
+
+// scaleSeries creates extra room, when all running rooms of series are full,
+// and removes extra rooms, that are empty for longer than configured.
+func (manager *RoomManagerCtx) scaleSeries(ctx context.Context, name string, members []*seriesMember) {
+	logger := manager.logger.With().Str("series", name).Logger()
+
+	var template *seriesMember
+	running, full := 0, 0
+	for _, member := range members {
+		if !member.entry.SeriesExtra && (template == nil || member.entry.Running && !template.entry.Running) {
+			template = member
+		}
+		if member.entry.Running {
+			running++
+		}
+		if member.full() {
+			full++
+		}
+	}
+
+	// series without running rooms is not in use
+	if template != nil && running > 0 && running == full {
+		if len(members) >= manager.config.AutoscaleMaxRooms {
+			logger.Warn().Int("rooms", len(members)).Msg("autoscale: all rooms are full, but maximum number of rooms is reached")
+		} else if ID, err := manager.createExtra(ctx, template.entry); err != nil {
+			logger.Err(err).Msg("autoscale: failed to create extra room")
+		} else {
+			logger.Info().Str("id", ID).Msg("autoscale: all rooms are full, extra room created")
+		}
+		return
+	}
+
+	retireAfter := time.Duration(manager.config.AutoscaleRetireSec) * time.Second
+	for _, member := range members {
+		if !member.entry.SeriesExtra {
+			continue
+		}
+
+		// stopped extra rooms are not needed anymore
+		if member.entry.Running && (!member.known || member.connections > 0) {
+			delete(manager.autoscaleEmpty, member.entry.UUID)
+			continue
+		}
+
+		if member.entry.Running {
+			since, ok := manager.autoscaleEmpty[member.entry.UUID]
+			if !ok {
+				since = time.Now()
+				manager.autoscaleEmpty[member.entry.UUID] = since
+			}
+
+			if time.Since(since) < retireAfter {
+				continue
+			}
+
+			// newcomers must have a free room after it is removed
+			if !seriesHasCapacity(members, member) {
+				continue
+			}
+		}
+
+		if err := manager.Remove(ctx, member.entry.ID); err != nil {
+			logger.Err(err).Str("id", member.entry.ID).Msg("autoscale: failed to remove extra room")
+			continue
+		}
+
+		delete(manager.autoscaleEmpty, member.entry.UUID)
+		member.entry.Running = false
+		logger.Info().Str("id", member.entry.ID).Str("name", member.entry.Name).Msg("autoscale: empty extra room removed")
+	}
+}
+
+// seriesHasCapacity returns true, when other running room than excluded has free seats.
+func seriesHasCapacity(members []*seriesMember, exclude *seriesMember) bool {
+	for _, member := range members {
+		if member != exclude && member.entry.Running && !member.full() {
+			return true
+		}
+	}
+	return false
+}
+
+// createExtra creates and starts new room of series with settings of template room.
+func (manager *RoomManagerCtx) createExtra(ctx context.Context, template types.RoomEntry) (string, error) {
+	settings, err := manager.GetSettings(ctx, template.ID)
+	if err != nil {
+		return "", err
+	}
+
+	var name string
+	for i := 2; i <= manager.config.AutoscaleMaxRooms+1; i++ {
+		candidate := fmt.Sprintf("%s-%d", template.Name, i)
+		if _, err := manager.containerByName(ctx, candidate); err != nil {
+			name = candidate
+			break
+		}
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("unable to find free name for extra room")
+	}
+
+	manager.cloneSettings(&template, settings, name)
+	settings.SeriesExtra = true
+
+	ID, err := manager.Create(ctx, *settings)
+	if err != nil {
+		return "", err
+	}
+
+	if err := manager.Start(ctx, ID); err != nil {
+		_ = manager.Remove(ctx, ID)
+		return "", err
+	}
+
+	return ID, nil
+}
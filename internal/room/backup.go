@@ -0,0 +1,200 @@
+package room
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// exportSettings returns settings of all rooms sorted by name, together with
+// their notes. Settings are loaded lazily, so that they are not held in memory.
+func (manager *RoomManagerCtx) exportSettings(ctx context.Context, fn func(settings *types.RoomSettings) error) error {
+	entries, err := manager.List(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	for _, entry := range entries {
+		// stop early, when client is gone
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		settings, err := manager.GetSettings(ctx, entry.ID)
+		if err != nil {
+			return fmt.Errorf("room %s: %w", entry.Name, err)
+		}
+
+		settings.Notes, err = manager.GetNotes(ctx, entry.ID)
+		if err != nil {
+			return fmt.Errorf("room %s: %w", entry.Name, err)
+		}
+
+		if err := fn(settings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportRooms writes settings of all rooms as JSON lines, one room per line.
+func (manager *RoomManagerCtx) ExportRooms(ctx context.Context, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return manager.exportSettings(ctx, func(settings *types.RoomSettings) error {
+		return enc.Encode(settings)
+	})
+}
+
+// Backup writes tar stream with directory of every room, that has the same
+// layout as room archive. Private storage is included only when requested,
+// because it is read while rooms are running.
+func (manager *RoomManagerCtx) Backup(ctx context.Context, w io.Writer, storage bool) error {
+	if storage && !manager.config.StorageEnabled {
+		return fmt.Errorf("storage cannot be backed up, because it is disabled or unavailable")
+	}
+
+	tw := tar.NewWriter(w)
+
+	err := manager.exportSettings(ctx, func(settings *types.RoomSettings) error {
+		data, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    settings.Name + "/" + archiveSettingsFile,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+
+		if !storage {
+			return nil
+		}
+
+		privateStorage := path.Join(manager.config.StorageInternal, privateStoragePath, settings.Name)
+		if _, err := os.Stat(privateStorage); os.IsNotExist(err) {
+			return nil
+		}
+
+		return utils.WriteTarDir(tw, privateStorage, settings.Name+"/"+archivePrivatePrefix)
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+func (manager *RoomManagerCtx) ListArchives() ([]types.RoomArchive, error) {
+	if !manager.config.StorageEnabled {
+		return nil, fmt.Errorf("archives are not available, because storage is disabled or unavailable")
+	}
+
+	files, err := os.ReadDir(path.Join(manager.config.StorageInternal, archivesStoragePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []types.RoomArchive{}, nil
+		}
+		return nil, err
+	}
+
+	archives := []types.RoomArchive{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), archiveFileNameSuffix) {
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		archives = append(archives, types.RoomArchive{
+			Archive: file.Name(),
+			Size:    info.Size(),
+			Created: info.ModTime(),
+		})
+	}
+
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].Created.Before(archives[j].Created)
+	})
+
+	return archives, nil
+}
+
+// OpenArchive opens tarball in archives storage, so that it can be downloaded,
+// together with uuid of archived room. Uuid is empty, when settings of damaged
+// archive can not be read.
+func (manager *RoomManagerCtx) OpenArchive(name string) (*os.File, string, error) {
+	if !manager.config.StorageEnabled {
+		return nil, "", fmt.Errorf("archives are not available, because storage is disabled or unavailable")
+	}
+
+	archivePath, err := manager.archivePath(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", types.ErrArchiveNotFound
+		}
+		return nil, "", err
+	}
+
+	uuid := archiveRoomUUID(file)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, "", err
+	}
+
+	return file, uuid, nil
+}
+
+// archiveRoomUUID reads uuid from settings, that are stored as first entry.
+func archiveRoomUUID(r io.Reader) string {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return ""
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != archiveSettingsFile {
+		return ""
+	}
+
+	var settings types.RoomSettings
+	if err := json.NewDecoder(tr).Decode(&settings); err != nil {
+		return ""
+	}
+
+	return settings.UUID
+}
@@ -0,0 +1,204 @@
+package room
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	bootstrapPath         = "/opt/neko-rooms"
+	bootstrapTraefikImage = "traefik:2.4"
+	bootstrapImage        = "m1k1o/neko-rooms:latest"
+)
+
+// Bootstrap generates docker compose or cloud-init user-data, that sets up
+// a new host (traefik, neko-rooms and firewall rules) using current config.
+// Host specific values are left as variables in .env file.
+func (manager *RoomManagerCtx) Bootstrap(format types.BootstrapFormat) ([]byte, error) {
+	switch format {
+	case types.BootstrapCompose:
+		return yaml.Marshal(manager.bootstrapCompose())
+	case types.BootstrapCloudInit:
+		compose, err := yaml.Marshal(manager.bootstrapCompose())
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := yaml.Marshal(manager.bootstrapCloudInit(string(compose)))
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte("#cloud-config\n"), data...), nil
+	default:
+		return nil, fmt.Errorf("unknown bootstrap format, must be one of: %s, %s", types.BootstrapCompose, types.BootstrapCloudInit)
+	}
+}
+
+func (manager *RoomManagerCtx) bootstrapCompose() map[string]any {
+	config := manager.config
+	network := config.InstanceNetwork
+	if network == "" {
+		network = "neko-rooms-net"
+	}
+
+	env := []string{
+		fmt.Sprintf("NEKO_ROOMS_EPR=%d-%d", config.EprMin, config.EprMax),
+		fmt.Sprintf("NEKO_ROOMS_MUX=%t", config.Mux),
+		"NEKO_ROOMS_NAT1TO1=${PUBLIC_IP:?public IP address of the host}",
+		fmt.Sprintf("NEKO_ROOMS_NEKO_IMAGES=%s", strings.Join(config.NekoImages, " ")),
+		fmt.Sprintf("NEKO_ROOMS_INSTANCE_NAME=%s", config.InstanceName),
+		fmt.Sprintf("NEKO_ROOMS_INSTANCE_NETWORK=%s", network),
+		fmt.Sprintf("NEKO_ROOMS_PATH_PREFIX=%s", config.PathPrefix),
+		fmt.Sprintf("NEKO_ROOMS_TRAEFIK_ENABLED=%t", config.Traefik.Enabled),
+	}
+
+	neko := map[string]any{
+		"image":   bootstrapImage,
+		"restart": "unless-stopped",
+		"volumes": []string{"/var/run/docker.sock:/var/run/docker.sock"},
+	}
+
+	if config.StorageEnabled {
+		env = append(env,
+			"NEKO_ROOMS_STORAGE_ENABLED=true",
+			fmt.Sprintf("NEKO_ROOMS_STORAGE_INTERNAL=%s", config.StorageInternal),
+			fmt.Sprintf("NEKO_ROOMS_STORAGE_EXTERNAL=%s", config.StorageExternal),
+		)
+		neko["volumes"] = []string{
+			"/var/run/docker.sock:/var/run/docker.sock",
+			fmt.Sprintf("%s:%s", config.StorageExternal, config.StorageInternal),
+		}
+	}
+
+	services := map[string]any{
+		"neko-rooms": neko,
+	}
+
+	if !config.Traefik.Enabled {
+		env = append(env, "NEKO_ROOMS_INSTANCE_URL=${INSTANCE_URL:?external URL of neko-rooms}")
+		neko["environment"] = env
+		neko["ports"] = []string{"8080:8080"}
+	} else {
+		entrypoint := config.Traefik.Entrypoint
+		env = append(env,
+			"NEKO_ROOMS_TRAEFIK_DOMAIN=${DOMAIN:?domain of the host}",
+			fmt.Sprintf("NEKO_ROOMS_TRAEFIK_ENTRYPOINT=%s", entrypoint),
+		)
+
+		labels := []string{
+			"traefik.enable=true",
+			"traefik.http.services.neko-rooms-frontend.loadbalancer.server.port=8080",
+			fmt.Sprintf("traefik.http.routers.neko-rooms.entrypoints=%s", entrypoint),
+			"traefik.http.routers.neko-rooms.rule=Host(`${DOMAIN}`)",
+		}
+
+		command := []string{
+			"--providers.docker=true",
+			"--providers.docker.exposedbydefault=false",
+			fmt.Sprintf("--providers.docker.network=%s", network),
+			"--entrypoints.web.address=:80",
+		}
+
+		volumes := []string{"/var/run/docker.sock:/var/run/docker.sock:ro"}
+		ports := []string{"80:80"}
+
+		if resolver := config.Traefik.Certresolver; resolver != "" {
+			env = append(env, fmt.Sprintf("NEKO_ROOMS_TRAEFIK_CERTRESOLVER=%s", resolver))
+			labels = append(labels,
+				"traefik.http.routers.neko-rooms.tls=true",
+				fmt.Sprintf("traefik.http.routers.neko-rooms.tls.certresolver=%s", resolver),
+			)
+
+			command = append(command,
+				fmt.Sprintf("--entrypoints.%s.address=:443", entrypoint),
+				fmt.Sprintf("--certificatesresolvers.%s.acme.email=${ACME_EMAIL:?email for certificates}", resolver),
+				fmt.Sprintf("--certificatesresolvers.%s.acme.storage=/acme/acme.json", resolver),
+				fmt.Sprintf("--certificatesresolvers.%s.acme.httpchallenge.entrypoint=web", resolver),
+			)
+
+			volumes = append(volumes, "./acme:/acme")
+			ports = append(ports, "443:443")
+		} else if entrypoint != "web" {
+			command = append(command, fmt.Sprintf("--entrypoints.%s.address=:8080", entrypoint))
+			ports = append(ports, "8080:8080")
+		}
+
+		neko["environment"] = env
+		neko["labels"] = labels
+
+		services["traefik"] = map[string]any{
+			"image":   bootstrapTraefikImage,
+			"restart": "unless-stopped",
+			"command": command,
+			"ports":   ports,
+			"volumes": volumes,
+		}
+	}
+
+	return map[string]any{
+		"networks": map[string]any{
+			"default": map[string]any{
+				"name":       network,
+				"attachable": true,
+			},
+		},
+		"services": services,
+	}
+}
+
+// bootstrapFirewall returns ufw commands, that open ports used by neko-rooms and rooms.
+func (manager *RoomManagerCtx) bootstrapFirewall() []string {
+	config := manager.config
+
+	commands := []string{"ufw allow 22/tcp"}
+	if config.Traefik.Enabled {
+		commands = append(commands, "ufw allow 80/tcp")
+		if config.Traefik.Certresolver != "" {
+			commands = append(commands, "ufw allow 443/tcp")
+		} else if config.Traefik.Entrypoint != "web" {
+			commands = append(commands, "ufw allow 8080/tcp")
+		}
+	} else {
+		commands = append(commands, "ufw allow 8080/tcp")
+	}
+
+	// webrtc ports, mux uses both udp and tcp
+	commands = append(commands, fmt.Sprintf("ufw allow %d:%d/udp", config.EprMin, config.EprMax))
+	if config.Mux {
+		commands = append(commands, fmt.Sprintf("ufw allow %d:%d/tcp", config.EprMin, config.EprMax))
+	}
+
+	return append(commands, "ufw --force enable")
+}
+
+func (manager *RoomManagerCtx) bootstrapCloudInit(compose string) map[string]any {
+	runcmd := []string{
+		"curl -fsSL https://get.docker.com | sh",
+	}
+	runcmd = append(runcmd, manager.bootstrapFirewall()...)
+	runcmd = append(runcmd, fmt.Sprintf("cd %s && docker compose up -d", bootstrapPath))
+
+	return map[string]any{
+		"package_update": true,
+		"packages":       []string{"curl", "ufw"},
+		"write_files": []map[string]any{
+			{
+				"path":        bootstrapPath + "/docker-compose.yml",
+				"permissions": "0644",
+				"content":     compose,
+			},
+			{
+				// host specific values, fill in before using user-data
+				"path":        bootstrapPath + "/.env",
+				"permissions": "0600",
+				"content":     "PUBLIC_IP=\nINSTANCE_URL=\nDOMAIN=\nACME_EMAIL=\n",
+			},
+		},
+		"runcmd": runcmd,
+	}
+}
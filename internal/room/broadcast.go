@@ -0,0 +1,146 @@
+package room
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// maskBroadcastUrl hides stream key, that is the last path segment of RTMP url.
+func maskBroadcastUrl(rawUrl string) string {
+	if i := strings.LastIndex(rawUrl, "/"); i >= 0 && i < len(rawUrl)-1 && strings.Contains(rawUrl[:i], "://") {
+		return rawUrl[:i+1] + "***"
+	}
+	return rawUrl
+}
+
+func (e *events) setRoomBroadcast(roomId string, broadcastUrl string) {
+	e.broadcastsMu.Lock()
+	defer e.broadcastsMu.Unlock()
+
+	if broadcastUrl == "" {
+		delete(e.broadcasts, roomId)
+	} else {
+		e.broadcasts[roomId] = broadcastUrl
+	}
+}
+
+func (e *events) IsRoomBroadcasting(roomId string) bool {
+	e.broadcastsMu.Lock()
+	defer e.broadcastsMu.Unlock()
+
+	_, ok := e.broadcasts[roomId]
+	return ok
+}
+
+// nekoBroadcastApi calls broadcast API of neko inside the room, only api v3 is supported.
+func (manager *RoomManagerCtx) nekoBroadcastApi(ctx context.Context, id string, action string, body any) (string, string, error) {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return "", "", err
+	}
+
+	container, err := manager.inspectContainer(ctx, id)
+	if err != nil {
+		return "", "", err
+	}
+
+	labels, err := manager.extractLabels(container.Config.Labels)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !labels.Profile.IsNeko() || labels.ApiVersion != 3 {
+		return "", "", fmt.Errorf("broadcast is only supported for neko API v3")
+	}
+
+	settings := types.RoomSettings{}
+	if err := settings.FromEnv(labels.ApiVersion, container.Config.Env); err != nil {
+		return "", "", err
+	}
+
+	cmd := []string{"wget", "-q", "-O-"}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return "", "", err
+		}
+
+		cmd = append(cmd, "--header=Content-Type: application/json", "--post-data="+string(data))
+	}
+
+	cmd = append(cmd, "http://127.0.0.1:8080/api/room/broadcast"+action+"?token="+url.QueryEscape(settings.AdminPass))
+
+	output, err := manager.containerExec(ctx, id, cmd)
+	return id[:12], output, err
+}
+
+func (manager *RoomManagerCtx) GetBroadcast(ctx context.Context, id string) (*types.RoomBroadcast, error) {
+	roomId, output, err := manager.nekoBroadcastApi(ctx, id, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var status struct {
+		IsActive bool   `json:"is_active"`
+		URL      string `json:"url"`
+	}
+
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		return nil, fmt.Errorf("invalid broadcast status: %w", err)
+	}
+
+	// keep state in sync with neko
+	if status.IsActive {
+		manager.events.setRoomBroadcast(roomId, status.URL)
+	} else {
+		manager.events.setRoomBroadcast(roomId, "")
+	}
+
+	return &types.RoomBroadcast{
+		IsActive: status.IsActive,
+		URL:      maskBroadcastUrl(status.URL),
+	}, nil
+}
+
+// StartBroadcast starts broadcasting room to RTMP target, stream key is appended to the url.
+func (manager *RoomManagerCtx) StartBroadcast(ctx context.Context, id string, target types.RoomBroadcastTarget) error {
+	// parse error would contain url with possible stream key
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("invalid broadcast url")
+	}
+
+	if u.Scheme != "rtmp" && u.Scheme != "rtmps" {
+		return fmt.Errorf("broadcast url must use rtmp or rtmps scheme")
+	}
+
+	broadcastUrl := strings.TrimSuffix(target.URL, "/")
+	if target.Key != "" {
+		broadcastUrl += "/" + target.Key
+	}
+
+	roomId, _, err := manager.nekoBroadcastApi(ctx, id, "/start", map[string]string{
+		"url": broadcastUrl,
+	})
+	if err != nil {
+		return err
+	}
+
+	manager.events.setRoomBroadcast(roomId, broadcastUrl)
+	return nil
+}
+
+func (manager *RoomManagerCtx) StopBroadcast(ctx context.Context, id string) error {
+	roomId, _, err := manager.nekoBroadcastApi(ctx, id, "/stop", struct{}{})
+	if err != nil {
+		return err
+	}
+
+	manager.events.setRoomBroadcast(roomId, "")
+	return nil
+}
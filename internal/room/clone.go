@@ -0,0 +1,110 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// Clone creates new room with settings of existing room. When requested, its
+// private storage is copied as well, the room is stopped while it is copied.
+func (manager *RoomManagerCtx) Clone(ctx context.Context, id string, request types.RoomCloneRequest) (string, error) {
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	settings, err := manager.GetSettings(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	name := request.Name
+	if name == "" {
+		name, err = manager.newRoomName(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := manager.containerByName(ctx, name); err == nil {
+		return "", fmt.Errorf("room with name %s already exists", name)
+	}
+
+	manager.cloneSettings(entry, settings, name)
+	if request.Template {
+		settings.Template = entry.Name
+	}
+
+	if request.Volumes {
+		if err := manager.cloneStorage(ctx, entry, name); err != nil {
+			return "", fmt.Errorf("failed to copy storage: %w", err)
+		}
+	}
+
+	ID, err := manager.Create(ctx, *settings)
+	if err != nil && request.Volumes && manager.config.StorageEnabled {
+		os.RemoveAll(path.Join(manager.config.StorageInternal, privateStoragePath, name))
+	}
+
+	return ID, err
+}
+
+// cloneStorage copies private storage of room to storage of new room.
+func (manager *RoomManagerCtx) cloneStorage(ctx context.Context, entry *types.RoomEntry, name string) error {
+	if !manager.config.StorageEnabled {
+		return fmt.Errorf("storage is disabled or unavailable")
+	}
+
+	src := path.Join(manager.config.StorageInternal, privateStoragePath, entry.Name)
+	dst := path.Join(manager.config.StorageInternal, privateStoragePath, name)
+
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		// room does not have any private storage
+		return nil
+	}
+
+	// storage of another room must not be overwritten
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("storage of room %s already exists", name)
+	}
+
+	// storage must not be changed while it is copied
+	if entry.Running {
+		if err := manager.Stop(ctx, entry.ID); err != nil {
+			return err
+		}
+
+		defer func() {
+			if err := manager.Start(context.Background(), entry.ID); err != nil {
+				manager.logger.Err(err).Str("id", entry.ID).Msg("unable to start room after cloning storage")
+			}
+		}()
+	}
+
+	if err := utils.CopyDir(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+
+	return nil
+}
+
+// cloneSettings prepares settings of room to be used for new room with given name.
+func (manager *RoomManagerCtx) cloneSettings(entry *types.RoomEntry, settings *types.RoomSettings, name string) {
+	// default hostname is container name, it must follow the new name
+	if settings.Hostname == manager.config.InstanceName+"-"+entry.Name {
+		settings.Hostname = ""
+	}
+
+	settings.UUID = ""
+	settings.Name = name
+	settings.Aliases = nil // routes must be unique
+	settings.Demo = false
+	settings.SeriesExtra = false
+	settings.Template = ""
+}
@@ -2,6 +2,7 @@ package room
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	dockerClient "github.com/docker/docker/client"
 
 	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
 )
 
 func (manager *RoomManagerCtx) containerToEntry(container dockerTypes.Container) (*types.RoomEntry, error) {
@@ -24,13 +26,19 @@ func (manager *RoomManagerCtx) containerToEntry(container dockerTypes.Container)
 
 	entry := &types.RoomEntry{
 		ID:             roomId,
+		UUID:           labels.UUID,
 		URL:            labels.URL,
 		Name:           labels.Name,
 		NekoImage:      labels.NekoImage,
+		Profile:        labels.Profile,
 		IsOutdated:     labels.NekoImage != container.Image,
 		MaxConnections: labels.Epr.Max - labels.Epr.Min + 1,
 		Running:        container.State == "running",
+		Paused:         container.State == "paused",
 		IsReady:        manager.events.IsRoomReady(roomId) || strings.Contains(container.Status, "healthy"),
+		CrashLooping:   manager.events.IsRoomCrashLooping(roomId),
+		Failed:         manager.events.IsRoomFailed(roomId),
+		Broadcasting:   manager.events.IsRoomBroadcasting(roomId),
 		Status:         container.Status,
 		Created:        time.Unix(container.Created, 0),
 		Labels:         labels.UserDefined,
@@ -38,10 +46,36 @@ func (manager *RoomManagerCtx) containerToEntry(container dockerTypes.Container)
 		ContainerLabels: container.Labels,
 	}
 
-	if labels.Mux {
+	if labels.Mux || !labels.Profile.IsNeko() {
 		entry.MaxConnections = 0
 	}
 
+	if labels.App != nil {
+		entry.App = labels.App.Name
+	}
+
+	entry.Description = labels.Description
+	entry.Tags = labels.Tags
+	entry.Contact = labels.Contact
+	entry.Aliases = labels.Aliases
+	entry.Expires = labels.Expires
+	entry.ExpireAction = labels.ExpireAction
+	entry.Demo = labels.Demo
+	entry.Listed = labels.Listed
+	entry.Series = labels.Series
+	entry.SeriesExtra = labels.SeriesExtra
+	entry.Template = labels.Template
+	entry.Priority = labels.Priority
+	entry.LastActivity = manager.events.LastActivity(labels.UUID)
+	entry.Notes = manager.entryNotes(labels.UUID)
+
+	if labels.Transfer != nil {
+		entry.Transfer = &types.RoomTransfer{
+			Clipboard: &labels.Transfer.Clipboard,
+			Files:     &labels.Transfer.Files,
+		}
+	}
+
 	return entry, nil
 }
 
@@ -86,6 +120,43 @@ func (manager *RoomManagerCtx) containerById(ctx context.Context, id string) (*d
 	))
 }
 
+func (manager *RoomManagerCtx) containerByUUID(ctx context.Context, uuid string) (*dockerTypes.Container, error) {
+	return manager.containerFilter(ctx, filters.NewArgs(
+		filters.Arg("label", fmt.Sprintf("m1k1o.neko_rooms.uuid=%s", uuid)),
+	))
+}
+
+// resolveId returns container id for room id, that can be either container id or room uuid.
+func (manager *RoomManagerCtx) resolveId(ctx context.Context, id string) (string, error) {
+	if !utils.IsUUID(id) {
+		return id, nil
+	}
+
+	container, err := manager.containerByUUID(ctx, id)
+	if err == nil {
+		return container.ID, nil
+	}
+
+	if !errors.Is(err, types.ErrRoomNotFound) {
+		return "", err
+	}
+
+	// rooms created before uuid label was introduced have it derived from labels
+	containers, err := manager.listContainers(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, container := range containers {
+		labels, err := manager.extractLabels(container.Labels)
+		if err == nil && labels.UUID == id {
+			return container.ID, nil
+		}
+	}
+
+	return "", types.ErrRoomNotFound
+}
+
 func (manager *RoomManagerCtx) containerByName(ctx context.Context, name string) (*dockerTypes.Container, error) {
 	return manager.containerFilter(ctx, filters.NewArgs(
 		filters.Arg("label", fmt.Sprintf("m1k1o.neko_rooms.name=%s", name)),
@@ -0,0 +1,122 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	// non-zero exits within window, that mark room as crash looping
+	crashLoopThreshold = 5
+	crashLoopWindow    = 10 * time.Minute
+	// log lines sent with alert
+	crashLoopLogLines = "20"
+)
+
+// recordCrash records non-zero exit of a room and returns true,
+// if the room has just started crash looping.
+func (e *events) recordCrash(roomId string) bool {
+	e.crashesMu.Lock()
+	defer e.crashesMu.Unlock()
+
+	now := time.Now()
+
+	// keep only crashes within window
+	crashes := []time.Time{}
+	for _, t := range e.crashes[roomId] {
+		if now.Sub(t) < crashLoopWindow {
+			crashes = append(crashes, t)
+		}
+	}
+	crashes = append(crashes, now)
+	e.crashes[roomId] = crashes
+
+	if len(crashes) < crashLoopThreshold {
+		return false
+	}
+
+	if _, ok := e.crashLooping[roomId]; ok {
+		return false
+	}
+
+	e.crashLooping[roomId] = struct{}{}
+	return true
+}
+
+func (e *events) setRoomKilled(roomId string) {
+	e.crashesMu.Lock()
+	defer e.crashesMu.Unlock()
+
+	e.killed[roomId] = struct{}{}
+}
+
+// wasRoomKilled returns true once, if room was killed before it exited.
+func (e *events) wasRoomKilled(roomId string) bool {
+	e.crashesMu.Lock()
+	defer e.crashesMu.Unlock()
+
+	_, ok := e.killed[roomId]
+	delete(e.killed, roomId)
+	return ok
+}
+
+// clearCrashes resets crash loop state of a room.
+func (e *events) clearCrashes(roomId string) {
+	e.crashesMu.Lock()
+	defer e.crashesMu.Unlock()
+
+	delete(e.crashes, roomId)
+	delete(e.crashLooping, roomId)
+	delete(e.killed, roomId)
+}
+
+func (e *events) IsRoomCrashLooping(roomId string) bool {
+	e.crashesMu.Lock()
+	defer e.crashesMu.Unlock()
+
+	_, ok := e.crashLooping[roomId]
+	return ok
+}
+
+// handleCrashLoop stops restart storm by disabling restart policy
+// and sends alert with last log lines.
+func (e *events) handleCrashLoop(roomId string, labels map[string]string) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ctx, cancel := context.WithTimeout(e.ctx, time.Minute)
+		defer cancel()
+
+		_, err := e.client.ContainerUpdate(ctx, roomId, container.UpdateConfig{
+			RestartPolicy: container.RestartPolicy{
+				Name: "no",
+			},
+		})
+		if err != nil {
+			e.logger.Err(err).Str("id", roomId).Msg("failed to disable restart policy")
+		}
+
+		logs := []string{}
+		err = readContainerLogs(ctx, e.client, roomId, "", crashLoopLogLines, func(timestamp time.Time, line string) {
+			logs = append(logs, line)
+		})
+		if err != nil {
+			e.logger.Err(err).Str("id", roomId).Msg("failed to read container logs")
+		}
+
+		e.sendAlert(types.RoomAlert{
+			Type:    types.RoomAlertCrashLoop,
+			ID:      roomId,
+			Name:    labels["m1k1o.neko_rooms.name"],
+			Time:    time.Now(),
+			Message: fmt.Sprintf("room exited %d times within %s, restart policy was disabled", crashLoopThreshold, crashLoopWindow),
+			Logs:    logs,
+		})
+	}()
+}
@@ -0,0 +1,72 @@
+package room
+
+import (
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+func (e *events) recordEvent(timeNano int64) {
+	e.statsMu.Lock()
+	defer e.statsMu.Unlock()
+
+	e.lastEvent = time.Now()
+	if timeNano > 0 {
+		e.eventLag = e.lastEvent.Sub(time.Unix(0, timeNano))
+	}
+}
+
+func (e *events) diagnostics() types.RoomDiagnostics {
+	diagnostics := types.RoomDiagnostics{
+		Caches: map[string]int{},
+	}
+
+	e.listenersMu.Lock()
+	diagnostics.Listeners = len(e.listeners)
+	e.listenersMu.Unlock()
+
+	e.roomsReadyMu.Lock()
+	diagnostics.Caches["rooms_ready"] = len(e.roomsReady)
+	e.roomsReadyMu.Unlock()
+
+	e.crashesMu.Lock()
+	diagnostics.Caches["crashes"] = len(e.crashes)
+	diagnostics.Caches["crash_looping"] = len(e.crashLooping)
+	diagnostics.Caches["killed"] = len(e.killed)
+	e.crashesMu.Unlock()
+
+	e.oomKillsMu.Lock()
+	diagnostics.Caches["oom_kills"] = len(e.oomKills)
+	e.oomKillsMu.Unlock()
+
+	e.broadcastsMu.Lock()
+	diagnostics.Caches["broadcasts"] = len(e.broadcasts)
+	e.broadcastsMu.Unlock()
+
+	e.watchdogsMu.Lock()
+	diagnostics.Caches["watchdogs"] = len(e.watchdogs)
+	diagnostics.Caches["failed"] = len(e.failed)
+	e.watchdogsMu.Unlock()
+
+	e.statsMu.Lock()
+	if !e.lastEvent.IsZero() {
+		lastEvent := e.lastEvent
+		diagnostics.LastEvent = &lastEvent
+	}
+	diagnostics.EventLagMs = e.eventLag.Milliseconds()
+	diagnostics.BroadcastDelayMs = e.broadcastDelay.Milliseconds()
+	e.statsMu.Unlock()
+
+	return diagnostics
+}
+
+// Diagnostics returns sizes of in-memory state and event stream lag.
+func (manager *RoomManagerCtx) Diagnostics() types.RoomDiagnostics {
+	diagnostics := manager.events.diagnostics()
+
+	manager.rolloutsMu.Lock()
+	diagnostics.Caches["rollouts"] = len(manager.rollouts)
+	manager.rolloutsMu.Unlock()
+
+	return diagnostics
+}
@@ -0,0 +1,89 @@
+package room
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+const diskCheckInterval = time.Minute
+
+// watchDisk periodically checks disk usage of configured paths. When threshold
+// is exceeded, new rooms are not created, dangling images are pruned and alert is sent.
+func (e *events) watchDisk() {
+	if e.config.DiskThreshold <= 0 || len(e.config.DiskPaths) == 0 {
+		return
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		// usage is known without waiting for the first interval
+		e.checkDisk()
+		e.every(diskCheckInterval, e.checkDisk)
+	}()
+}
+
+func (e *events) checkDisk() {
+	var pressure bool
+	var pressurePath string
+	var pressureUsage float64
+
+	for _, path := range e.config.DiskPaths {
+		usage, err := utils.DiskUsage(path)
+		if err != nil {
+			e.logger.Err(err).Str("path", path).Msg("failed to get disk usage")
+			continue
+		}
+
+		if usage >= float64(e.config.DiskThreshold) {
+			pressure = true
+			pressurePath = path
+			pressureUsage = usage
+			break
+		}
+	}
+
+	e.diskPressureMu.Lock()
+	changed := e.diskPressure != pressure
+	e.diskPressure = pressure
+	e.diskPressureMu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if !pressure {
+		e.logger.Info().Msg("disk usage is below threshold, creating rooms is allowed again")
+		return
+	}
+
+	// free up space taken by dangling images
+	report, err := e.client.ImagesPrune(e.ctx, filters.NewArgs(
+		filters.Arg("dangling", "true"),
+	))
+	if err != nil {
+		e.logger.Err(err).Msg("failed to prune dangling images")
+	}
+
+	e.sendAlert(types.RoomAlert{
+		Type: types.RoomAlertDiskPressure,
+		Time: time.Now(),
+		Message: fmt.Sprintf(
+			"disk usage of %s is %.1f%% (threshold %d%%), creating rooms is blocked, pruned %d bytes of dangling images",
+			pressurePath, pressureUsage, e.config.DiskThreshold, report.SpaceReclaimed,
+		),
+	})
+}
+
+func (e *events) IsDiskPressure() bool {
+	e.diskPressureMu.Lock()
+	defer e.diskPressureMu.Unlock()
+
+	return e.diskPressure
+}
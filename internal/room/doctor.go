@@ -0,0 +1,505 @@
+package room
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+const doctorRequestTimeout = 10 * time.Second
+
+// router as returned by traefik API
+type traefikApiRouter struct {
+	Name     string   `json:"name"` // with @provider suffix
+	Provider string   `json:"provider"`
+	Rule     string   `json:"rule"`
+	Service  string   `json:"service"`
+	Status   string   `json:"status"`
+	Error    []string `json:"error"`
+}
+
+// traefikRouters returns all http routers known to traefik, following pagination.
+func (manager *RoomManagerCtx) traefikRouters(ctx context.Context) ([]traefikApiRouter, error) {
+	routers := []traefikApiRouter{}
+
+	for page := 1; ; {
+		url := fmt.Sprintf("%s/api/http/routers?per_page=100&page=%d", manager.config.Traefik.Api, page)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageRouters []traefikApiRouter
+		err = json.NewDecoder(res.Body).Decode(&pageRouters)
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("traefik API returned unexpected status %d", res.StatusCode)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		routers = append(routers, pageRouters...)
+
+		next, err := strconv.Atoi(res.Header.Get("X-Next-Page"))
+		if err != nil || next <= page {
+			return routers, nil
+		}
+		page = next
+	}
+}
+
+// Doctor runs checks of rooms and host, and returns found issues ordered by
+// severity, with suggestions how to fix them.
+func (manager *RoomManagerCtx) Doctor(ctx context.Context) (*types.Doctor, error) {
+	entries, err := manager.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := manager.doctorDisk()
+
+	ports, err := manager.doctorPorts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, ports...)
+
+	versions, err := manager.doctorVersions(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, versions...)
+
+	issues = append(issues, manager.doctorCanaries(ctx)...)
+
+	if manager.config.Traefik.Enabled && manager.config.Traefik.Api != "" {
+		issues = append(issues, manager.doctorRouters(ctx, entries)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Severity != b.Severity {
+			return a.Severity.Priority() < b.Severity.Priority()
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Room != b.Room {
+			return a.Room < b.Room
+		}
+		return a.Router < b.Router
+	})
+
+	return &types.Doctor{
+		Time:   time.Now(),
+		Issues: issues,
+	}, nil
+}
+
+// doctorDisk checks disk usage directly, so that it works without events loop.
+func (manager *RoomManagerCtx) doctorDisk() []types.DoctorIssue {
+	issues := []types.DoctorIssue{}
+	if manager.config.DiskThreshold <= 0 {
+		return issues
+	}
+
+	for _, path := range manager.config.DiskPaths {
+		usage, err := utils.DiskUsage(path)
+		if err != nil {
+			manager.logger.Err(err).Str("path", path).Msg("doctor: failed to get disk usage")
+			continue
+		}
+
+		if usage >= float64(manager.config.DiskThreshold) {
+			issues = append(issues, types.DoctorIssue{
+				Type:       types.DoctorDiskPressure,
+				Severity:   types.DoctorCritical,
+				Message:    fmt.Sprintf("disk usage of %s is %.1f%%, above threshold %d%%, new rooms are not created", path, usage, manager.config.DiskThreshold),
+				Suggestion: "remove unused rooms, archives and images",
+			})
+		}
+	}
+
+	return issues
+}
+
+// doctorPorts checks, that webrtc port ranges of rooms do not overlap and that
+// they are within configured range.
+func (manager *RoomManagerCtx) doctorPorts(ctx context.Context) ([]types.DoctorIssue, error) {
+	containers, err := manager.listContainers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	type roomPorts struct {
+		id   string
+		name string
+		epr  EprPorts
+	}
+
+	rooms := []roomPorts{}
+	for _, container := range containers {
+		labels, err := manager.extractLabels(container.Labels)
+		if err != nil {
+			return nil, err
+		}
+
+		// non-neko images do not use webrtc ports
+		if !labels.Profile.IsNeko() {
+			continue
+		}
+
+		rooms = append(rooms, roomPorts{
+			id:   container.ID[:12],
+			name: labels.Name,
+			epr:  labels.Epr,
+		})
+	}
+
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return rooms[i].epr.Min < rooms[j].epr.Min
+	})
+
+	issues := []types.DoctorIssue{}
+	for i, room := range rooms {
+		if room.epr.Min < manager.config.EprMin || room.epr.Max > manager.config.EprMax {
+			issues = append(issues, types.DoctorIssue{
+				Type:       types.DoctorPortRange,
+				Severity:   types.DoctorWarning,
+				Room:       room.name,
+				ID:         room.id,
+				Message:    fmt.Sprintf("ports %d-%d are outside of configured range %d-%d", room.epr.Min, room.epr.Max, manager.config.EprMin, manager.config.EprMax),
+				Suggestion: "recreate the room to allocate ports from configured range",
+				Repairable: true,
+			})
+		}
+
+		for _, other := range rooms[:i] {
+			if room.epr.Min > other.epr.Max {
+				continue
+			}
+
+			issues = append(issues, types.DoctorIssue{
+				Type:       types.DoctorPortCollision,
+				Severity:   types.DoctorCritical,
+				Room:       room.name,
+				ID:         room.id,
+				Message:    fmt.Sprintf("ports %d-%d overlap with ports %d-%d of room %s", room.epr.Min, room.epr.Max, other.epr.Min, other.epr.Max, other.name),
+				Suggestion: "recreate the room to allocate free ports",
+				Repairable: true,
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// doctorVersions checks rooms, whose labels or image do not match current
+// config, labels schema or pulled image.
+func (manager *RoomManagerCtx) doctorVersions(ctx context.Context, entries []types.RoomEntry) ([]types.DoctorIssue, error) {
+	migrations, err := manager.Migrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := map[string]types.RoomEntry{}
+	for _, entry := range entries {
+		byId[entry.ID] = entry
+	}
+
+	issues := []types.DoctorIssue{}
+	for _, migration := range migrations {
+		routing, other := []string{}, []string{}
+		for _, change := range migration.Changes {
+			if isRoutingLabel(change.Key) {
+				routing = append(routing, change.Key)
+			} else {
+				other = append(other, change.Key)
+			}
+		}
+
+		if len(routing) > 0 {
+			// room is unreachable, when it has no routing labels at all
+			severity := types.DoctorCritical
+			for key := range byId[migration.ID].ContainerLabels {
+				if isRoutingLabel(key) {
+					severity = types.DoctorWarning
+					break
+				}
+			}
+
+			issues = append(issues, types.DoctorIssue{
+				Type:       types.DoctorMissingRoutes,
+				Severity:   severity,
+				Room:       migration.Name,
+				ID:         migration.ID,
+				Message:    fmt.Sprintf("routing labels do not match current config: %s", strings.Join(routing, ", ")),
+				Suggestion: "recreate the room or run migration",
+				Repairable: true,
+			})
+		}
+
+		if len(other) > 0 {
+			issues = append(issues, types.DoctorIssue{
+				Type:       types.DoctorVersionSkew,
+				Severity:   types.DoctorWarning,
+				Room:       migration.Name,
+				ID:         migration.ID,
+				Message:    fmt.Sprintf("labels do not match current version: %s", strings.Join(other, ", ")),
+				Suggestion: "recreate the room or run migration",
+				Repairable: true,
+			})
+		}
+	}
+
+	for _, entry := range entries {
+		if !entry.IsOutdated {
+			continue
+		}
+
+		issues = append(issues, types.DoctorIssue{
+			Type:       types.DoctorVersionSkew,
+			Severity:   types.DoctorInfo,
+			Room:       entry.Name,
+			ID:         entry.ID,
+			Message:    fmt.Sprintf("image %s was updated since the room was created", entry.NekoImage),
+			Suggestion: "recreate the room to use updated image",
+			Repairable: true,
+		})
+	}
+
+	return issues, nil
+}
+
+// doctorCanaries checks, that canary rooms of running rollouts still exist and
+// use canary image.
+func (manager *RoomManagerCtx) doctorCanaries(ctx context.Context) []types.DoctorIssue {
+	issues := []types.DoctorIssue{}
+	for _, rollout := range manager.Rollouts(ctx) {
+		if rollout.Status != types.RoomRolloutCanary {
+			continue
+		}
+
+		for _, room := range rollout.Rooms {
+			var message string
+			switch {
+			case room.Error != "":
+				message = fmt.Sprintf("canary room %s of rollout %s is missing: %s", room.UUID, rollout.ID, room.Error)
+			case room.NekoImage != rollout.Image:
+				message = fmt.Sprintf("canary room of rollout %s uses image %s instead of %s", rollout.ID, room.NekoImage, rollout.Image)
+			default:
+				continue
+			}
+
+			issues = append(issues, types.DoctorIssue{
+				Type:       types.DoctorCanaryMissing,
+				Severity:   types.DoctorWarning,
+				Room:       room.Name,
+				ID:         room.ID,
+				Message:    message,
+				Suggestion: "promote or roll back the rollout, results of canary rooms are incomplete",
+			})
+		}
+	}
+
+	return issues
+}
+
+// doctorRouters compares traefik routers of running rooms, as generated in their
+// labels, with routers that traefik actually uses.
+func (manager *RoomManagerCtx) doctorRouters(ctx context.Context, entries []types.RoomEntry) []types.DoctorIssue {
+	ctx, cancel := context.WithTimeout(ctx, doctorRequestTimeout)
+	defer cancel()
+
+	routers, err := manager.traefikRouters(ctx)
+	if err != nil {
+		return []types.DoctorIssue{{
+			Type:       types.DoctorTraefikApi,
+			Severity:   types.DoctorWarning,
+			Message:    fmt.Sprintf("failed to get traefik routers: %s", err),
+			Suggestion: "check that traefik API is enabled and reachable at traefik.api",
+		}}
+	}
+
+	// routers are referenced without provider suffix in labels
+	actual := map[string]traefikApiRouter{}
+	byRule := map[string][]string{}
+	for _, router := range routers {
+		name, _, _ := strings.Cut(router.Name, "@")
+		if router.Provider == "docker" {
+			actual[name] = router
+		}
+		byRule[router.Rule] = append(byRule[router.Rule], router.Name)
+	}
+
+	issues := []types.DoctorIssue{}
+	expected := map[string]struct{}{}
+
+	for _, entry := range entries {
+		// stopped containers are ignored by traefik
+		if !entry.Running {
+			continue
+		}
+
+		for key, rule := range entry.ContainerLabels {
+			if !strings.HasPrefix(key, "traefik.http.routers.") || !strings.HasSuffix(key, ".rule") {
+				continue
+			}
+
+			name := strings.TrimSuffix(strings.TrimPrefix(key, "traefik.http.routers."), ".rule")
+			expected[name] = struct{}{}
+
+			router, ok := actual[name]
+			switch {
+			case !ok:
+				issues = append(issues, types.DoctorIssue{
+					Type:       types.DoctorMissingRouter,
+					Severity:   types.DoctorCritical,
+					Router:     name,
+					Room:       entry.Name,
+					ID:         entry.ID,
+					Message:    "router of running room is not known to traefik, room is unreachable",
+					Suggestion: "recreate the room, check that it is connected to traefik network",
+					Repairable: true,
+				})
+			case router.Rule != rule:
+				issues = append(issues, types.DoctorIssue{
+					Type:       types.DoctorRuleMismatch,
+					Severity:   types.DoctorCritical,
+					Router:     name,
+					Room:       entry.Name,
+					ID:         entry.ID,
+					Message:    fmt.Sprintf("traefik uses rule %s, but room has rule %s", router.Rule, rule),
+					Suggestion: "recreate the room",
+					Repairable: true,
+				})
+			case router.Status != "enabled":
+				issues = append(issues, types.DoctorIssue{
+					Type:       types.DoctorRouterError,
+					Severity:   types.DoctorCritical,
+					Router:     name,
+					Room:       entry.Name,
+					ID:         entry.ID,
+					Message:    fmt.Sprintf("router is %s: %s", router.Status, strings.Join(router.Error, "; ")),
+					Suggestion: "remove containers defining the same router",
+				})
+			}
+
+			if names := byRule[rule]; len(names) > 1 {
+				issues = append(issues, types.DoctorIssue{
+					Type:       types.DoctorDuplicateRule,
+					Severity:   types.DoctorWarning,
+					Router:     name,
+					Room:       entry.Name,
+					ID:         entry.ID,
+					Message:    fmt.Sprintf("rule is used by more routers: %s", strings.Join(names, ", ")),
+					Suggestion: "remove or rename other routers, only one of them is used",
+				})
+			}
+		}
+	}
+
+	// stale routers of this instance, e.g. from containers not managed anymore
+	prefix := manager.config.InstanceName + "-"
+	for name := range actual {
+		if _, ok := expected[name]; ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		issues = append(issues, types.DoctorIssue{
+			Type:       types.DoctorOrphanRouter,
+			Severity:   types.DoctorWarning,
+			Router:     name,
+			Message:    "router does not belong to any running room",
+			Suggestion: "remove container or config, that defines the router",
+		})
+	}
+
+	return issues
+}
+
+// DoctorRepair recreates rooms with repairable issues, so that their labels and
+// ports are generated again. Other issues need to be resolved manually.
+func (manager *RoomManagerCtx) DoctorRepair(ctx context.Context) ([]types.DoctorIssue, error) {
+	doctor, err := manager.Doctor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repaired := map[string]string{} // room id -> error
+	result := []types.DoctorIssue{}
+
+	for _, issue := range doctor.Issues {
+		if !issue.Repairable {
+			continue
+		}
+
+		errMsg, ok := repaired[issue.ID]
+		if !ok {
+			if _, err := manager.recreate(ctx, issue.ID, nil); err != nil {
+				errMsg = err.Error()
+			}
+			repaired[issue.ID] = errMsg
+		}
+
+		issue.Error = errMsg
+		result = append(result, issue)
+	}
+
+	return result, nil
+}
+
+// watchDoctor periodically runs doctor checks.
+func (e *events) watchDoctor() {
+	if e.config.DoctorIntervalSec <= 0 || e.onDoctorPoll == nil {
+		return
+	}
+
+	e.every(time.Duration(e.config.DoctorIntervalSec)*time.Second, func() { e.onDoctorPoll(e.ctx) })
+}
+
+// pollDoctor alerts issues, that were not present in previous check.
+func (manager *RoomManagerCtx) pollDoctor(ctx context.Context) {
+	doctor, err := manager.Doctor(ctx)
+	if err != nil {
+		manager.logger.Err(err).Msg("doctor: failed to run checks")
+		return
+	}
+
+	seen := map[string]struct{}{}
+	for _, issue := range doctor.Issues {
+		key := string(issue.Type) + "/" + issue.Room + "/" + issue.Router
+		seen[key] = struct{}{}
+
+		if _, ok := manager.doctorIssues[key]; ok {
+			continue
+		}
+
+		manager.events.sendAlert(types.RoomAlert{
+			Type:    types.RoomAlertDoctor,
+			ID:      issue.ID,
+			Name:    issue.Room,
+			Time:    doctor.Time,
+			Message: fmt.Sprintf("%s (%s): %s", issue.Type, issue.Severity, issue.Message),
+		})
+	}
+
+	// only accessed by the watcher
+	manager.doctorIssues = seen
+}
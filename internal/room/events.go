@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/m1k1o/neko-rooms/internal/config"
 	"github.com/m1k1o/neko-rooms/internal/types"
@@ -35,14 +36,58 @@ type events struct {
 	roomsReadyMu sync.Mutex
 	roomsReady   map[string]struct{}
 
+	crashesMu    sync.Mutex
+	crashes      map[string][]time.Time
+	crashLooping map[string]struct{}
+	killed       map[string]struct{}
+
+	oomKillsMu sync.Mutex
+	oomKills   map[string]int // by room uuid, so that it survives recreates and renames
+	onOOMKill  func(roomId string, labels map[string]string)
+
+	diskPressureMu sync.Mutex
+	diskPressure   bool
+
+	broadcastsMu sync.Mutex
+	broadcasts   map[string]string // room id -> broadcast url
+
+	watchdogsMu sync.Mutex
+	watchdogs   map[string]*time.Timer
+	failed      map[string]struct{}
+	retried     map[string]struct{} // by room name, so that it survives recreates
+	onFailed    func(roomId string, labels map[string]string)
+
+	onExpired func(roomId string, labels map[string]string)
+
+	activityMu     sync.Mutex
+	activity       map[string]time.Time // by room uuid, so that it survives recreates
+	onActivityPoll func(ctx context.Context)
+
+	onTieringPoll     func(ctx context.Context)
+	onWebRTCStatsPoll func(ctx context.Context)
+	onAlertsPoll      func(ctx context.Context)
+	onDoctorPoll      func(ctx context.Context)
+	onAutoscalePoll   func(ctx context.Context)
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	listeners   []chan types.RoomEvent
 	listenersMu sync.Mutex
 
-	runningRooms prometheus.Gauge
-	totalRooms   prometheus.Counter
+	statsMu        sync.Mutex
+	lastEvent      time.Time
+	eventLag       time.Duration // between docker event and its handling
+	broadcastDelay time.Duration // time spent delivering last event to listeners
+
+	archiveResultsMu sync.Mutex
+	archiveResults   map[string]types.RoomArchiveVerification
+
+	runningRooms     prometheus.Gauge
+	totalRooms       prometheus.Counter
+	archivesTotal    prometheus.Gauge
+	archivesDamaged  prometheus.Gauge
+	archivesVerified prometheus.Gauge
 }
 
 func newEvents(config *config.Room, client *dockerClient.Client) *events {
@@ -54,6 +99,22 @@ func newEvents(config *config.Room, client *dockerClient.Client) *events {
 		roomsReadyCh: make(chan roomReady),
 		roomsReady:   make(map[string]struct{}),
 
+		crashes:      make(map[string][]time.Time),
+		crashLooping: make(map[string]struct{}),
+		killed:       make(map[string]struct{}),
+
+		oomKills: make(map[string]int),
+
+		broadcasts: make(map[string]string),
+
+		watchdogs: make(map[string]*time.Timer),
+		failed:    make(map[string]struct{}),
+		retried:   make(map[string]struct{}),
+
+		activity: make(map[string]time.Time),
+
+		archiveResults: make(map[string]types.RoomArchiveVerification),
+
 		// metrics
 		runningRooms: promauto.NewGauge(prometheus.GaugeOpts{
 			Name:      "running_rooms",
@@ -65,12 +126,58 @@ func newEvents(config *config.Room, client *dockerClient.Client) *events {
 			Namespace: "neko_rooms",
 			Help:      "Total number of rooms created since start.",
 		}),
+		archivesTotal: promauto.NewGauge(prometheus.GaugeOpts{
+			Name:      "archives",
+			Namespace: "neko_rooms",
+			Help:      "Number of archives checked by last verification.",
+		}),
+		archivesDamaged: promauto.NewGauge(prometheus.GaugeOpts{
+			Name:      "archives_damaged",
+			Namespace: "neko_rooms",
+			Help:      "Number of archives, that failed last verification.",
+		}),
+		archivesVerified: promauto.NewGauge(prometheus.GaugeOpts{
+			Name:      "archives_verified_timestamp_seconds",
+			Namespace: "neko_rooms",
+			Help:      "Time of last archive verification.",
+		}),
 	}
 }
 
+// every calls fn periodically in its own goroutine, until events are shut down.
+func (e *events) every(interval time.Duration, fn func()) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			fn()
+		}
+	}()
+}
+
 func (e *events) Start() {
 	e.ctx, e.cancel = context.WithCancel(context.Background())
 
+	e.watchDisk()
+	e.watchExpiry()
+	e.watchArchives()
+	e.watchActivity()
+	e.watchTiering()
+	e.watchWebRTCStats()
+	e.watchAlerts()
+	e.watchDoctor()
+	e.watchAutoscale()
+
 	// load initial metrics
 	containers, err := e.client.ContainerList(e.ctx, dockerTypes.ContainerListOptions{
 		Filters: filters.NewArgs(
@@ -95,7 +202,12 @@ func (e *events) Start() {
 			filters.Arg("event", "create"),
 			filters.Arg("event", "start"),
 			filters.Arg("event", "health_status"),
+			filters.Arg("event", "kill"),
+			filters.Arg("event", "oom"),
+			filters.Arg("event", "die"),
 			filters.Arg("event", "stop"),
+			filters.Arg("event", "pause"),
+			filters.Arg("event", "unpause"),
 			filters.Arg("event", "destroy"),
 		),
 	})
@@ -123,6 +235,8 @@ func (e *events) Start() {
 					continue
 				}
 
+				e.watchdogReady(room.id, room.labels)
+
 				e.broadcast(types.RoomEvent{
 					ID:     room.id,
 					Action: types.RoomEventReady,
@@ -132,6 +246,7 @@ func (e *events) Start() {
 			case msg := <-msgs:
 				roomId := msg.Actor.ID[:12]
 				labels := msg.Actor.Attributes
+				e.recordEvent(msg.TimeNano)
 
 				e.logger.Debug().
 					Str("id", roomId).
@@ -146,6 +261,7 @@ func (e *events) Start() {
 				case "start":
 					action = types.RoomEventStarted
 					e.waitForRoomReady(roomId, labels)
+					e.startWatchdog(roomId, labels)
 					e.runningRooms.Inc()
 				case "health_status: healthy":
 					action = types.RoomEventReady
@@ -153,12 +269,40 @@ func (e *events) Start() {
 					if !e.setRoomReady(roomId) {
 						continue
 					}
+
+					e.watchdogReady(roomId, labels)
+				case "health_status: unhealthy":
+					action = types.RoomEventUnhealthy
 				case "stop":
 					action = types.RoomEventStopped
 					e.setRoomNotReady(roomId)
+					e.cancelWatchdog(roomId)
+					e.setRoomBroadcast(roomId, "")
 					e.runningRooms.Dec()
+				case "pause":
+					action = types.RoomEventPaused
+				case "unpause":
+					action = types.RoomEventUnpaused
+				case "kill":
+					// room is being stopped on purpose
+					e.setRoomKilled(roomId)
+					continue
+				case "oom":
+					e.recordOOMKill(roomId, labels)
+					continue
+				case "die":
+					// only unexpected non-zero exits are tracked
+					if e.wasRoomKilled(roomId) || labels["exitCode"] == "0" || !e.recordCrash(roomId) {
+						continue
+					}
+
+					action = types.RoomEventCrashLooping
+					e.handleCrashLoop(roomId, labels)
 				case "destroy":
 					action = types.RoomEventDestroyed
+					e.clearCrashes(roomId)
+					e.clearWatchdog(roomId)
+					e.setRoomBroadcast(roomId, "")
 				}
 
 				e.broadcast(types.RoomEvent{
@@ -174,6 +318,7 @@ func (e *events) Start() {
 
 func (e *events) Shutdown() error {
 	e.cancel()
+	e.stopWatchdogs()
 	close(e.roomsReadyCh)
 	e.wg.Wait()
 	return nil
@@ -184,6 +329,8 @@ func (e *events) Shutdown() error {
 //
 
 func (e *events) waitForRoomReady(roomId string, labels map[string]string) {
+	frontendPort := types.RoomProfile(labels["m1k1o.neko_rooms.profile"]).FrontendPort()
+
 	e.wg.Add(1)
 	go func() {
 		defer e.wg.Done()
@@ -256,11 +403,17 @@ func (e *events) IsRoomReady(roomId string) bool {
 //
 
 func (e *events) broadcast(event types.RoomEvent) {
+	start := time.Now()
+
 	e.listenersMu.Lock()
 	for _, listener := range e.listeners {
 		listener <- event
 	}
 	e.listenersMu.Unlock()
+
+	e.statsMu.Lock()
+	e.broadcastDelay = time.Since(start)
+	e.statsMu.Unlock()
 }
 
 func (e *events) Events(ctx context.Context) (<-chan types.RoomEvent, <-chan error) {
@@ -0,0 +1,116 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// maximum output of exec, that is returned
+const execMaxOutput = 64 * 1024
+
+// execCommands returns names of whitelisted commands.
+func (manager *RoomManagerCtx) execCommands() []string {
+	commands := []string{}
+	for name := range manager.config.ExecCommands {
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// Exec runs whitelisted command inside the room and captures its output.
+func (manager *RoomManagerCtx) Exec(ctx context.Context, id string, command string) (*types.RoomExecResult, error) {
+	cmd, ok := manager.config.ExecCommands[command]
+	if !ok {
+		return nil, types.ErrExecNotAllowed
+	}
+
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := manager.inspectContainer(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if manager.config.ExecTimeoutSec > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(manager.config.ExecTimeoutSec)*time.Second)
+		defer cancel()
+	}
+
+	started := time.Now()
+
+	exec, err := manager.client.ContainerExecCreate(ctx, id, dockerTypes.ExecConfig{
+		AttachStderr: true,
+		AttachStdout: true,
+		Cmd:          []string{"/bin/sh", "-c", cmd},
+		Tty:          true,
+	})
+	if err != nil {
+		if dockerClient.IsErrNotFound(err) {
+			return nil, types.ErrRoomNotFound
+		}
+		return nil, err
+	}
+
+	conn, err := manager.client.ContainerExecAttach(ctx, exec.ID, dockerTypes.ExecStartCheck{
+		Tty: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// hijacked connection does not follow context, it is closed to unblock reading
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// rest of the output is discarded, so that the command is not blocked
+	data, err := io.ReadAll(io.LimitReader(conn.Reader, execMaxOutput))
+	if err == nil {
+		_, err = io.Copy(io.Discard, conn.Reader)
+	}
+	if ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, types.ErrExecTimeout
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	inspect, err := manager.client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if inspect.Running {
+		return nil, fmt.Errorf("command is still running")
+	}
+
+	return &types.RoomExecResult{
+		Command:  command,
+		ExitCode: inspect.ExitCode,
+		Output:   string(data),
+		Duration: time.Since(started).Seconds(),
+	}, nil
+}
@@ -0,0 +1,75 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const expiryCheckInterval = time.Minute
+
+// watchExpiry periodically notifies handler about rooms, that have expired.
+func (e *events) watchExpiry() {
+	e.every(expiryCheckInterval, e.checkExpiry)
+}
+
+func (e *events) checkExpiry() {
+	containers, err := e.client.ContainerList(e.ctx, dockerTypes.ContainerListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", fmt.Sprintf("m1k1o.neko_rooms.instance=%s", e.config.InstanceName)),
+			filters.Arg("label", "m1k1o.neko_rooms.expires"),
+		),
+	})
+	if err != nil {
+		e.logger.Err(err).Msg("expiry: failed to list containers")
+		return
+	}
+
+	now := time.Now()
+	for _, container := range containers {
+		expires, err := time.Parse(time.RFC3339, container.Labels["m1k1o.neko_rooms.expires"])
+		if err != nil || now.Before(expires) {
+			continue
+		}
+
+		// expired rooms, that are only stopped, are kept
+		if types.RoomExpireAction(container.Labels["m1k1o.neko_rooms.expire_action"]) == types.RoomExpireStop && container.State != "running" {
+			continue
+		}
+
+		if e.onExpired != nil {
+			e.onExpired(container.ID[:12], container.Labels)
+		}
+	}
+}
+
+// handleExpired removes or stops expired room.
+func (manager *RoomManagerCtx) handleExpired(roomId string, labels map[string]string) {
+	logger := manager.logger.With().Str("id", roomId).Str("name", labels["m1k1o.neko_rooms.name"]).Logger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if types.RoomExpireAction(labels["m1k1o.neko_rooms.expire_action"]) == types.RoomExpireStop {
+		if err := manager.Stop(ctx, roomId); err != nil {
+			logger.Err(err).Msg("expiry: failed to stop room")
+			return
+		}
+
+		logger.Info().Msg("expiry: room stopped")
+		return
+	}
+
+	if err := manager.Remove(ctx, roomId); err != nil {
+		logger.Err(err).Msg("expiry: failed to remove room")
+		return
+	}
+
+	logger.Info().Msg("expiry: room removed")
+}
@@ -0,0 +1,116 @@
+package room
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// runHook calls URL with room context as JSON body, or executes local script with
+// room context as JSON on stdin and in environment variables.
+func (manager *RoomManagerCtx) runHook(ctx context.Context, hook string, room types.RoomHook) error {
+	if hook == "" {
+		return nil
+	}
+
+	if manager.config.HooksTimeoutSec > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(manager.config.HooksTimeoutSec)*time.Second)
+		defer cancel()
+	}
+
+	data, err := json.Marshal(room)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("unexpected response status %d", res.StatusCode)
+		}
+
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"NEKO_ROOMS_HOOK="+string(room.Hook),
+		"NEKO_ROOMS_ID="+room.ID,
+		"NEKO_ROOMS_UUID="+room.UUID,
+		"NEKO_ROOMS_NAME="+room.Name,
+		"NEKO_ROOMS_URL="+room.URL,
+		"NEKO_ROOMS_NEKO_IMAGE="+room.NekoImage,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// postStartHook runs post start hook, its failure is only alerted or room is stopped.
+func (manager *RoomManagerCtx) postStartHook(ctx context.Context, id string) error {
+	if manager.config.HooksPostStart == "" {
+		return nil
+	}
+
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = manager.runHook(ctx, manager.config.HooksPostStart, types.RoomHook{
+		Hook:      types.RoomHookPostStart,
+		ID:        entry.ID,
+		UUID:      entry.UUID,
+		Name:      entry.Name,
+		URL:       entry.URL,
+		NekoImage: entry.NekoImage,
+		Contact:   entry.Contact,
+		Labels:    entry.Labels,
+	})
+	if err == nil {
+		return nil
+	}
+
+	if manager.config.HooksPostStartFailure == "stop" {
+		if stopErr := manager.Stop(ctx, id); stopErr != nil {
+			manager.logger.Err(stopErr).Str("id", id).Msg("hooks: failed to stop room")
+		}
+		return fmt.Errorf("post start hook failed: %w", err)
+	}
+
+	manager.events.sendAlert(types.RoomAlert{
+		Type:    types.RoomAlertHook,
+		ID:      entry.ID,
+		Name:    entry.Name,
+		Time:    time.Now(),
+		Message: fmt.Sprintf("post start hook failed: %s", err),
+		Contact: entry.Contact,
+	})
+
+	return nil
+}
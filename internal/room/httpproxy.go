@@ -0,0 +1,86 @@
+package room
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// effectiveHttpProxy returns proxy of the room, or global proxy from config.
+func (manager *RoomManagerCtx) effectiveHttpProxy(settings types.RoomSettings) *types.RoomHttpProxy {
+	if settings.HttpProxy != nil {
+		return settings.HttpProxy
+	}
+
+	if manager.config.HttpProxy == "" && manager.config.HttpsProxy == "" {
+		return nil
+	}
+
+	return &types.RoomHttpProxy{
+		Http:    manager.config.HttpProxy,
+		Https:   manager.config.HttpsProxy,
+		NoProxy: manager.config.NoProxy,
+	}
+}
+
+func validateHttpProxy(proxy *types.RoomHttpProxy) error {
+	for _, val := range []string{proxy.Http, proxy.Https} {
+		if val == "" {
+			continue
+		}
+
+		u, err := url.Parse(val)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid http proxy %s, must be an url", val)
+		}
+	}
+
+	return nil
+}
+
+// httpProxyEnv returns environment variables for proxy, both upper and lower case
+// variants are set, because different programs respect different ones.
+func httpProxyEnv(proxy *types.RoomHttpProxy) []string {
+	env := []string{}
+	for _, v := range []struct {
+		key string
+		val string
+	}{
+		{"HTTP_PROXY", proxy.Http},
+		{"http_proxy", proxy.Http},
+		{"HTTPS_PROXY", proxy.Https},
+		{"https_proxy", proxy.Https},
+		{"NO_PROXY", proxy.NoProxy},
+		{"no_proxy", proxy.NoProxy},
+	} {
+		if v.val != "" {
+			env = append(env, v.key+"="+v.val)
+		}
+	}
+	return env
+}
+
+// extractHttpProxy moves proxy environment variables from settings envs to http proxy
+// settings. When they match global proxy, they are omitted, so that the room follows it.
+func (manager *RoomManagerCtx) extractHttpProxy(settings *types.RoomSettings) {
+	proxy := types.RoomHttpProxy{
+		Http:    settings.Envs["HTTP_PROXY"],
+		Https:   settings.Envs["HTTPS_PROXY"],
+		NoProxy: settings.Envs["NO_PROXY"],
+	}
+
+	for _, key := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+		delete(settings.Envs, key)
+	}
+
+	if proxy == (types.RoomHttpProxy{}) {
+		return
+	}
+
+	if global := manager.effectiveHttpProxy(types.RoomSettings{}); global != nil && *global == proxy {
+		return
+	}
+
+	settings.HttpProxy = &proxy
+}
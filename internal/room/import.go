@@ -0,0 +1,291 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	dockerMount "github.com/docker/docker/api/types/mount"
+	dockerNames "github.com/docker/docker/daemon/names"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+)
+
+// isNekoContainer returns true, when container uses allowed neko image or it
+// is configured using neko envs.
+func (manager *RoomManagerCtx) isNekoContainer(image string, envs []string) bool {
+	if in, _ := utils.ArrayIn(image, manager.config.NekoImages); in {
+		return true
+	}
+
+	for _, env := range envs {
+		if strings.HasPrefix(env, "NEKO_") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importPorts returns number of webrtc ports used by the container, 0 when using mux.
+func importPorts(apiVersion int, envs []string) (uint16, bool) {
+	eprKey, muxKey := "NEKO_EPR", "NEKO_UDPMUX"
+	if apiVersion == 3 {
+		eprKey, muxKey = "NEKO_WEBRTC_EPR", "NEKO_WEBRTC_UDPMUX"
+	}
+
+	for _, env := range envs {
+		key, val, _ := strings.Cut(env, "=")
+		switch key {
+		case muxKey:
+			return 0, true
+		case eprKey:
+			min, max, ok := strings.Cut(val, "-")
+			if !ok {
+				continue
+			}
+
+			minPort, err1 := strconv.ParseUint(min, 10, 16)
+			maxPort, err2 := strconv.ParseUint(max, 10, 16)
+			if err1 != nil || err2 != nil || maxPort < minPort {
+				continue
+			}
+
+			return uint16(maxPort - minPort + 1), true
+		}
+	}
+
+	return 0, false
+}
+
+// composeImport converts container of compose service to proposed room settings.
+func (manager *RoomManagerCtx) composeImport(ctx context.Context, id string) (*types.RoomImport, error) {
+	containerJson, err := manager.client.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := containerJson.Config.Labels
+	result := &types.RoomImport{
+		Project:     labels[composeProjectLabel],
+		Service:     labels[composeServiceLabel],
+		ContainerID: containerJson.ID[:12],
+		Running:     containerJson.State.Running,
+		Warnings:    []string{},
+	}
+
+	if !manager.isNekoContainer(containerJson.Config.Image, containerJson.Config.Env) {
+		return nil, nil
+	}
+
+	settings := manager.defaultSettings()
+	settings.Profile = types.NekoProfile
+	settings.Name = result.Service
+	settings.NekoImage = containerJson.Config.Image
+	settings.Description = fmt.Sprintf("imported from compose project %s", result.Project)
+
+	if !dockerNames.RestrictedNamePattern.MatchString(settings.Name) {
+		result.Warnings = append(result.Warnings, "service name is not a valid room name, another name must be specified")
+	} else if _, err := manager.containerByName(ctx, settings.Name); err == nil {
+		result.Warnings = append(result.Warnings, "room with the same name already exists, another name must be specified")
+	}
+
+	if in, _ := utils.ArrayIn(settings.NekoImage, manager.config.NekoImages); !in {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("image %s is not in neko images, it must be added to config", settings.NekoImage))
+	}
+
+	settings.ApiVersion, err = manager.detectApiVersion(ctx, settings.NekoImage)
+	if err != nil {
+		return nil, err
+	}
+
+	// envs are parsed the same way as for managed rooms
+	if err := settings.FromEnv(settings.ApiVersion, containerJson.Config.Env); err != nil {
+		return nil, err
+	}
+
+	if ports, ok := importPorts(settings.ApiVersion, containerJson.Config.Env); ok {
+		settings.MaxConnections = ports
+	}
+	result.Warnings = append(result.Warnings, "webrtc ports are allocated from configured range, port forwarding must be updated")
+
+	if containerJson.HostConfig != nil {
+		settings.Resources = containerResources(containerJson.HostConfig)
+		settings.DNS = containerJson.HostConfig.DNS
+		settings.DNSSearch = containerJson.HostConfig.DNSSearch
+		settings.ExtraHosts = containerJson.HostConfig.ExtraHosts
+	}
+
+	settings.Mounts = []types.RoomMount{}
+	for _, mount := range containerJson.Mounts {
+		if mount.Type != dockerMount.TypeBind {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s mount %s is not imported", mount.Type, mount.Destination))
+			continue
+		}
+
+		mountType := types.MountPublic
+		if !mount.RW {
+			mountType = types.MountProtected
+		}
+
+		settings.Mounts = append(settings.Mounts, types.RoomMount{
+			Type:          mountType,
+			HostPath:      mount.Source,
+			ContainerPath: mount.Destination,
+		})
+	}
+
+	if len(settings.Mounts) > 0 {
+		result.Warnings = append(result.Warnings, "bind mounts are imported as public mounts, they must be whitelisted in config")
+	}
+
+	result.Settings = &settings
+	return result, nil
+}
+
+// ComposeImports scans containers of docker compose projects, that are not managed
+// by neko-rooms, and returns proposed settings of rooms for neko services.
+func (manager *RoomManagerCtx) ComposeImports(ctx context.Context, project string) ([]types.RoomImport, error) {
+	label := composeProjectLabel
+	if project != "" {
+		label += "=" + project
+	}
+
+	containers, err := manager.client.ContainerList(ctx, dockerTypes.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", label)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.RoomImport{}
+	for _, c := range containers {
+		// already managed by neko-rooms
+		if _, ok := c.Labels["m1k1o.neko_rooms.instance"]; ok {
+			continue
+		}
+
+		entry, err := manager.composeImport(ctx, c.ID)
+		if err != nil {
+			result = append(result, types.RoomImport{
+				Project:     c.Labels[composeProjectLabel],
+				Service:     c.Labels[composeServiceLabel],
+				ContainerID: c.ID[:12],
+				Running:     c.State == "running",
+				Error:       err.Error(),
+			})
+			continue
+		}
+
+		// only containers of neko images are offered
+		if entry != nil {
+			result = append(result, *entry)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Project == result[j].Project {
+			return result[i].Service < result[j].Service
+		}
+		return result[i].Project < result[j].Project
+	})
+
+	return result, nil
+}
+
+// ImportCompose converts selected services of compose project to managed rooms.
+// Original containers are stopped (or removed) and rooms are started, when the
+// original container was running. When the room can not be created, original
+// container is started again.
+func (manager *RoomManagerCtx) ImportCompose(ctx context.Context, request types.RoomImportRequest) ([]types.RoomImport, error) {
+	if request.Project == "" {
+		return nil, fmt.Errorf("project must be specified")
+	}
+
+	imports, err := manager.ComposeImports(ctx, request.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.RoomImport{}
+	for _, item := range imports {
+		if len(request.Services) > 0 {
+			if in, _ := utils.ArrayIn(item.Service, request.Services); !in {
+				continue
+			}
+		}
+
+		if name, ok := request.Names[item.Service]; ok && item.Settings != nil {
+			item.Settings.Name = name
+		}
+
+		if item.Error == "" {
+			item.ID, err = manager.importRoom(ctx, item, request.Remove)
+			if err != nil {
+				item.Error = err.Error()
+			}
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+func (manager *RoomManagerCtx) importRoom(ctx context.Context, item types.RoomImport, remove bool) (string, error) {
+	logger := manager.logger.With().
+		Str("project", item.Project).
+		Str("service", item.Service).
+		Logger()
+
+	// original container might use the same ports or name
+	if item.Running {
+		err := manager.client.ContainerStop(ctx, item.ContainerID, container.StopOptions{
+			Signal:  "SIGTERM",
+			Timeout: &manager.config.StopTimeoutSec,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to stop original container: %w", err)
+		}
+	}
+
+	ID, err := manager.Create(ctx, *item.Settings)
+	if err == nil && item.Running {
+		if err = manager.Start(ctx, ID); err != nil {
+			_ = manager.Remove(ctx, ID)
+		}
+	}
+
+	if err != nil {
+		if item.Running {
+			if err := manager.client.ContainerStart(ctx, item.ContainerID, dockerTypes.ContainerStartOptions{}); err != nil {
+				logger.Err(err).Msg("import: failed to start original container again")
+			}
+		}
+		return "", fmt.Errorf("failed to create room: %w", err)
+	}
+
+	if remove {
+		err := manager.client.ContainerRemove(ctx, item.ContainerID, dockerTypes.ContainerRemoveOptions{
+			Force: true,
+		})
+		if err != nil {
+			logger.Err(err).Msg("import: failed to remove original container")
+		}
+	}
+
+	logger.Info().Str("id", ID).Msg("import: service converted to room")
+	return ID, nil
+}
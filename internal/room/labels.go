@@ -1,10 +1,12 @@
 package room
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/m1k1o/neko-rooms/internal/types"
 )
@@ -12,6 +14,7 @@ import (
 var labelRegex = regexp.MustCompile(`^[a-z0-9.-]+$`)
 
 type RoomLabels struct {
+	UUID string
 	Name string
 	URL  string
 	Mux  bool
@@ -19,9 +22,35 @@ type RoomLabels struct {
 
 	NekoImage  string
 	ApiVersion int
+	Profile    types.RoomProfile
 
 	BrowserPolicy *BrowserPolicyLabels
+	App           *AppLabels
 	UserDefined   map[string]string
+
+	Description string
+	Tags        []string
+	Contact     string
+
+	Aliases []string
+
+	Transfer *TransferLabels
+
+	Expires      *time.Time
+	ExpireAction types.RoomExpireAction
+	Demo         bool
+	Listed       bool
+
+	Series      string
+	SeriesExtra bool
+
+	Priority types.RoomPriority
+
+	IdleTimeout *int
+
+	RestartPolicy string
+
+	Template string
 }
 
 type BrowserPolicyLabels struct {
@@ -29,7 +58,23 @@ type BrowserPolicyLabels struct {
 	Path string
 }
 
+type TransferLabels struct {
+	Clipboard bool
+	Files     bool
+}
+
+type AppLabels struct {
+	Name string
+	Path string
+	Args []string
+}
+
 func (manager *RoomManagerCtx) extractLabels(labels map[string]string) (*RoomLabels, error) {
+	labels, err := manager.migrateLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+
 	name, ok := labels["m1k1o.neko_rooms.name"]
 	if !ok {
 		return nil, fmt.Errorf("damaged container labels: name not found")
@@ -37,16 +82,28 @@ func (manager *RoomManagerCtx) extractLabels(labels map[string]string) (*RoomLab
 
 	url, ok := labels["m1k1o.neko_rooms.url"]
 	if !ok {
-		// TODO: It should be always available.
-		url = manager.config.GetRoomUrl(name)
-		//return nil, fmt.Errorf("damaged container labels: url not found")
+		return nil, fmt.Errorf("damaged container labels: url not found")
+	}
+
+	uuid, ok := labels["m1k1o.neko_rooms.uuid"]
+	if !ok {
+		return nil, fmt.Errorf("damaged container labels: uuid not found")
+	}
+
+	profile := types.NekoProfile
+	if val, ok := labels["m1k1o.neko_rooms.profile"]; ok {
+		profile = types.RoomProfile(val)
 	}
 
 	var mux bool
 	var epr EprPorts
 
 	muxStr, ok := labels["m1k1o.neko_rooms.mux"]
-	if ok {
+	if !profile.IsNeko() {
+		// non-neko images do not use webrtc ports
+		mux = false
+		epr = EprPorts{}
+	} else if ok {
 		muxPort, err := strconv.ParseUint(muxStr, 10, 16)
 		if err != nil {
 			return nil, err
@@ -92,7 +149,10 @@ func (manager *RoomManagerCtx) extractLabels(labels map[string]string) (*RoomLab
 
 	apiVersion := 2 // default, prior to api versioning
 	apiVersionStr, ok := labels["m1k1o.neko_rooms.api_version"]
-	if ok {
+	if !profile.IsNeko() {
+		// non-neko images do not use api version
+		apiVersion = 0
+	} else if ok {
 		var err error
 		apiVersion, err = strconv.Atoi(apiVersionStr)
 		if err != nil {
@@ -118,6 +178,73 @@ func (manager *RoomManagerCtx) extractLabels(labels map[string]string) (*RoomLab
 		}
 	}
 
+	var app *AppLabels
+	if appName, ok := labels["m1k1o.neko_rooms.app.name"]; ok {
+		appPath, ok := labels["m1k1o.neko_rooms.app.path"]
+		if !ok {
+			return nil, fmt.Errorf("damaged container labels: app.path not found")
+		}
+
+		var appArgs []string
+		if val, ok := labels["m1k1o.neko_rooms.app.args"]; ok {
+			if err := json.Unmarshal([]byte(val), &appArgs); err != nil {
+				return nil, fmt.Errorf("damaged container labels: app.args %w", err)
+			}
+		}
+
+		app = &AppLabels{
+			Name: appName,
+			Path: appPath,
+			Args: appArgs,
+		}
+	}
+
+	var tags []string
+	if val, ok := labels["m1k1o.neko_rooms.tags"]; ok && val != "" {
+		tags = strings.Split(val, ",")
+	}
+
+	var aliases []string
+	if val, ok := labels["m1k1o.neko_rooms.aliases"]; ok && val != "" {
+		aliases = strings.Split(val, ",")
+	}
+
+	var transfer *TransferLabels
+	if val, ok := labels["m1k1o.neko_rooms.transfer.clipboard"]; ok {
+		clipboard, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, err
+		}
+
+		files, err := strconv.ParseBool(labels["m1k1o.neko_rooms.transfer.files"])
+		if err != nil {
+			return nil, fmt.Errorf("damaged container labels: transfer.files not valid")
+		}
+
+		transfer = &TransferLabels{
+			Clipboard: clipboard,
+			Files:     files,
+		}
+	}
+
+	var expires *time.Time
+	if val, ok := labels["m1k1o.neko_rooms.expires"]; ok {
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, fmt.Errorf("damaged container labels: expires not valid")
+		}
+		expires = &t
+	}
+
+	var idleTimeout *int
+	if val, ok := labels["m1k1o.neko_rooms.idle_timeout"]; ok {
+		timeout, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("damaged container labels: idle_timeout not valid")
+		}
+		idleTimeout = &timeout
+	}
+
 	// extract user defined labels
 	userDefined := map[string]string{}
 	for key, val := range labels {
@@ -127,6 +254,7 @@ func (manager *RoomManagerCtx) extractLabels(labels map[string]string) (*RoomLab
 	}
 
 	return &RoomLabels{
+		UUID: uuid,
 		Name: name,
 		URL:  url,
 		Mux:  mux,
@@ -134,30 +262,63 @@ func (manager *RoomManagerCtx) extractLabels(labels map[string]string) (*RoomLab
 
 		NekoImage:  nekoImage,
 		ApiVersion: apiVersion,
+		Profile:    profile,
 
 		BrowserPolicy: browserPolicy,
+		App:           app,
 		UserDefined:   userDefined,
+
+		Description: labels["m1k1o.neko_rooms.description"],
+		Tags:        tags,
+		Contact:     labels["m1k1o.neko_rooms.contact"],
+
+		Aliases: aliases,
+
+		Transfer: transfer,
+
+		Expires:      expires,
+		ExpireAction: types.RoomExpireAction(labels["m1k1o.neko_rooms.expire_action"]),
+		Demo:         labels["m1k1o.neko_rooms.demo"] == "true",
+		Listed:       labels["m1k1o.neko_rooms.listed"] == "true",
+
+		Series:      labels["m1k1o.neko_rooms.series"],
+		SeriesExtra: labels["m1k1o.neko_rooms.series_extra"] == "true",
+
+		Priority: types.RoomPriority(labels["m1k1o.neko_rooms.priority"]),
+
+		IdleTimeout: idleTimeout,
+
+		RestartPolicy: labels["m1k1o.neko_rooms.restart_policy"],
+
+		Template: labels["m1k1o.neko_rooms.template"],
 	}, nil
 }
 
 func (manager *RoomManagerCtx) serializeLabels(labels RoomLabels) map[string]string {
 	labelsMap := map[string]string{
+		"m1k1o.neko_rooms.uuid":       labels.UUID,
 		"m1k1o.neko_rooms.name":       labels.Name,
 		"m1k1o.neko_rooms.url":        manager.config.GetRoomUrl(labels.Name),
 		"m1k1o.neko_rooms.instance":   manager.config.InstanceName,
 		"m1k1o.neko_rooms.neko_image": labels.NekoImage,
+		"m1k1o.neko_rooms.version":    strconv.Itoa(labelsVersion),
 	}
 
-	// api version 2 is currently default
-	if labels.ApiVersion != 2 {
-		labelsMap["m1k1o.neko_rooms.api_version"] = fmt.Sprintf("%d", labels.ApiVersion)
-	}
+	if labels.Profile.IsNeko() {
+		// api version 2 is currently default
+		if labels.ApiVersion != 2 {
+			labelsMap["m1k1o.neko_rooms.api_version"] = fmt.Sprintf("%d", labels.ApiVersion)
+		}
 
-	if labels.Mux && labels.Epr.Min == labels.Epr.Max {
-		labelsMap["m1k1o.neko_rooms.mux"] = fmt.Sprintf("%d", labels.Epr.Min)
+		if labels.Mux && labels.Epr.Min == labels.Epr.Max {
+			labelsMap["m1k1o.neko_rooms.mux"] = fmt.Sprintf("%d", labels.Epr.Min)
+		} else {
+			labelsMap["m1k1o.neko_rooms.epr.min"] = fmt.Sprintf("%d", labels.Epr.Min)
+			labelsMap["m1k1o.neko_rooms.epr.max"] = fmt.Sprintf("%d", labels.Epr.Max)
+		}
 	} else {
-		labelsMap["m1k1o.neko_rooms.epr.min"] = fmt.Sprintf("%d", labels.Epr.Min)
-		labelsMap["m1k1o.neko_rooms.epr.max"] = fmt.Sprintf("%d", labels.Epr.Max)
+		// non-neko images do not use api version nor webrtc ports
+		labelsMap["m1k1o.neko_rooms.profile"] = string(labels.Profile)
 	}
 
 	if labels.BrowserPolicy != nil {
@@ -166,6 +327,78 @@ func (manager *RoomManagerCtx) serializeLabels(labels RoomLabels) map[string]str
 		labelsMap["m1k1o.neko_rooms.browser_policy.path"] = labels.BrowserPolicy.Path
 	}
 
+	if labels.App != nil {
+		labelsMap["m1k1o.neko_rooms.app.name"] = labels.App.Name
+		labelsMap["m1k1o.neko_rooms.app.path"] = labels.App.Path
+
+		if len(labels.App.Args) > 0 {
+			// error can be ignored, because slice of strings is always serializable
+			args, _ := json.Marshal(labels.App.Args)
+			labelsMap["m1k1o.neko_rooms.app.args"] = string(args)
+		}
+	}
+
+	if labels.Description != "" {
+		labelsMap["m1k1o.neko_rooms.description"] = labels.Description
+	}
+
+	if len(labels.Tags) > 0 {
+		labelsMap["m1k1o.neko_rooms.tags"] = strings.Join(labels.Tags, ",")
+	}
+
+	if labels.Contact != "" {
+		labelsMap["m1k1o.neko_rooms.contact"] = labels.Contact
+	}
+
+	if len(labels.Aliases) > 0 {
+		labelsMap["m1k1o.neko_rooms.aliases"] = strings.Join(labels.Aliases, ",")
+	}
+
+	if labels.Transfer != nil {
+		labelsMap["m1k1o.neko_rooms.transfer.clipboard"] = strconv.FormatBool(labels.Transfer.Clipboard)
+		labelsMap["m1k1o.neko_rooms.transfer.files"] = strconv.FormatBool(labels.Transfer.Files)
+	}
+
+	if labels.Expires != nil {
+		labelsMap["m1k1o.neko_rooms.expires"] = labels.Expires.UTC().Format(time.RFC3339)
+	}
+
+	if labels.ExpireAction != "" && labels.ExpireAction != types.RoomExpireRemove {
+		labelsMap["m1k1o.neko_rooms.expire_action"] = string(labels.ExpireAction)
+	}
+
+	if labels.Demo {
+		labelsMap["m1k1o.neko_rooms.demo"] = "true"
+	}
+
+	if labels.Listed {
+		labelsMap["m1k1o.neko_rooms.listed"] = "true"
+	}
+
+	if labels.Series != "" {
+		labelsMap["m1k1o.neko_rooms.series"] = labels.Series
+	}
+
+	if labels.SeriesExtra {
+		labelsMap["m1k1o.neko_rooms.series_extra"] = "true"
+	}
+
+	if labels.Priority != "" && labels.Priority != types.RoomPriorityNormal {
+		labelsMap["m1k1o.neko_rooms.priority"] = string(labels.Priority)
+	}
+
+	if labels.IdleTimeout != nil {
+		labelsMap["m1k1o.neko_rooms.idle_timeout"] = strconv.Itoa(*labels.IdleTimeout)
+	}
+
+	if labels.RestartPolicy != "" && labels.RestartPolicy != defaultRestartPolicy {
+		labelsMap["m1k1o.neko_rooms.restart_policy"] = labels.RestartPolicy
+	}
+
+	if labels.Template != "" {
+		labelsMap["m1k1o.neko_rooms.template"] = labels.Template
+	}
+
 	for key, val := range labels.UserDefined {
 		// to lowercase
 		key = strings.ToLower(key)
@@ -0,0 +1,51 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// LazyStart starts room, that was visited. Unlike Start, that is requested by
+// admin, it keeps rooms stopped by crash loop protection, watchdog or expiry.
+func (manager *RoomManagerCtx) LazyStart(ctx context.Context, id string) error {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	containerJson, err := manager.inspectContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	roomId := containerJson.ID[:12]
+	labels := containerJson.Config.Labels
+
+	if manager.events.IsRoomCrashLooping(roomId) {
+		return fmt.Errorf("%w: crash looping", types.ErrRoomKeptStopped)
+	}
+
+	// crash loop state is not kept across restarts, disabled restart policy is
+	if containerJson.HostConfig.RestartPolicy.Name == "no" {
+		restartPolicy, err := parseRestartPolicy(labels["m1k1o.neko_rooms.restart_policy"])
+		if err == nil && restartPolicy.Name != "no" {
+			return fmt.Errorf("%w: restart policy was disabled", types.ErrRoomKeptStopped)
+		}
+	}
+
+	if manager.events.IsRoomFailed(roomId) {
+		return fmt.Errorf("%w: did not become ready", types.ErrRoomKeptStopped)
+	}
+
+	if val, ok := labels["m1k1o.neko_rooms.expires"]; ok {
+		expires, err := time.Parse(time.RFC3339, val)
+		if err == nil && !time.Now().Before(expires) {
+			return fmt.Errorf("%w: expired", types.ErrRoomKeptStopped)
+		}
+	}
+
+	return manager.Start(ctx, id)
+}
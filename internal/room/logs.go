@@ -0,0 +1,155 @@
+package room
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	// how many containers are searched at once
+	logsSearchConcurrency = 4
+	// how many last lines are searched per container
+	logsSearchTail = "5000"
+	// max lines returned in total
+	logsSearchLimit = 1000
+)
+
+// SearchLogs searches recent logs of all rooms for lines containing query (case insensitive).
+// Since can be either timestamp or relative duration, as accepted by docker.
+func (manager *RoomManagerCtx) SearchLogs(ctx context.Context, query string, since string) ([]types.RoomLogLine, error) {
+	containers, err := manager.listContainers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	result := []types.RoomLogLine{}
+
+	sem := make(chan struct{}, logsSearchConcurrency)
+	for _, container := range containers {
+		labels, err := manager.extractLabels(container.Labels)
+		if err != nil {
+			return nil, err
+		}
+
+		wg.Add(1)
+		go func(id, name string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			lines, err := manager.searchContainerLogs(ctx, id, query, since)
+			for i := range lines {
+				lines[i].ID = id[:12]
+				lines[i].Name = name
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				manager.logger.Err(err).Str("id", id).Msg("failed to search container logs")
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			result = append(result, lines...)
+		}(container.ID, labels.Name)
+	}
+
+	wg.Wait()
+
+	// return error only if no logs were searched successfully
+	if firstErr != nil && len(result) == 0 {
+		return nil, firstErr
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+
+	// keep the most recent lines
+	if len(result) > logsSearchLimit {
+		result = result[len(result)-logsSearchLimit:]
+	}
+
+	return result, nil
+}
+
+func (manager *RoomManagerCtx) searchContainerLogs(ctx context.Context, id string, query string, since string) ([]types.RoomLogLine, error) {
+	lines := []types.RoomLogLine{}
+	err := readContainerLogs(ctx, manager.client, id, since, logsSearchTail, func(timestamp time.Time, line string) {
+		if !strings.Contains(strings.ToLower(line), query) {
+			return
+		}
+
+		lines = append(lines, types.RoomLogLine{
+			Time: timestamp,
+			Line: line,
+		})
+	})
+
+	return lines, err
+}
+
+// readContainerLogs reads container logs line by line, with stdout and stderr combined.
+func readContainerLogs(ctx context.Context, client *dockerClient.Client, id string, since string, tail string, fn func(timestamp time.Time, line string)) error {
+	logs, err := client.ContainerLogs(ctx, id, dockerTypes.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Since:      since,
+		Tail:       tail,
+	})
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	// demultiplex stdout and stderr
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, logs)
+		pw.CloseWithError(err)
+	}()
+
+	// unblock writer, if scanner stopped early
+	defer pr.Close()
+
+	scanner := bufio.NewScanner(pr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// every line is prefixed with timestamp
+		var timestamp time.Time
+		if ts, rest, ok := strings.Cut(line, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+				timestamp = t
+				line = rest
+			}
+		}
+
+		fn(timestamp, line)
+	}
+
+	return scanner.Err()
+}
@@ -5,13 +5,16 @@ import (
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/cli/opts"
@@ -34,22 +37,46 @@ import (
 )
 
 const (
-	frontendPort        = 8080
 	templateStoragePath = "./templates"
 	privateStoragePath  = "./rooms"
 	privateStorageUid   = 1000
 	privateStorageGid   = 1000
 )
 
-func New(client *dockerClient.Client, config *config.Room) *RoomManagerCtx {
+func New(client *dockerClient.Client, config *config.Room, dockerOps utils.Semaphore) *RoomManagerCtx {
 	logger := log.With().Str("module", "room").Logger()
 
-	return &RoomManagerCtx{
+	manager := &RoomManagerCtx{
 		logger: logger,
 		config: config,
 		client: client,
 		events: newEvents(config, client),
+
+		rollouts: map[string]*rollout{},
+		tiering:  map[string]*tieringState{},
+		alerts: alertRules{
+			states:   map[string]*types.RoomAlertState{},
+			notified: map[string]time.Time{},
+			silences: map[string]time.Time{},
+		},
+		autoscaleEmpty: map[string]time.Time{},
+
+		dockerOps: dockerOps,
+		starts:    newStartScheduler(config),
+		webrtc:    newWebRTCStats(config),
 	}
+
+	manager.events.onOOMKill = manager.handleOOMKill
+	manager.events.onFailed = manager.handleFailed
+	manager.events.onExpired = manager.handleExpired
+	manager.events.onActivityPoll = manager.pollActivity
+	manager.events.onTieringPoll = manager.pollTiering
+	manager.events.onWebRTCStatsPoll = manager.pollWebRTCStats
+	manager.events.onAlertsPoll = manager.evaluateAlerts
+	manager.events.onDoctorPoll = manager.pollDoctor
+	manager.events.onAutoscalePoll = manager.pollAutoscale
+
+	return manager
 }
 
 type RoomManagerCtx struct {
@@ -57,14 +84,53 @@ type RoomManagerCtx struct {
 	config *config.Room
 	client *dockerClient.Client
 	events *events
+
+	rolloutsMu sync.Mutex
+	rollouts   map[string]*rollout
+
+	notesMu sync.Mutex
+
+	tieringMu sync.Mutex
+	tiering   map[string]*tieringState // by room uuid
+
+	alertsMu sync.Mutex
+	alerts   alertRules
+
+	doctorIssues map[string]struct{} // from last check, to alert only new issues
+
+	autoscaleEmpty map[string]time.Time // since when extra rooms are empty, by room uuid
+
+	dockerOps utils.Semaphore // limits concurrent heavy docker operations
+	starts    *startScheduler
+	webrtc    *webrtcStats
 }
 
 func (manager *RoomManagerCtx) Config() types.RoomsConfig {
 	return types.RoomsConfig{
-		Connections:    manager.config.EprMax - manager.config.EprMin + 1,
-		NekoImages:     manager.config.NekoImages,
-		StorageEnabled: manager.config.StorageEnabled,
-		UsesMux:        manager.config.Mux,
+		Connections:     manager.config.EprMax - manager.config.EprMin + 1,
+		NekoImages:      manager.config.NekoImages,
+		StorageEnabled:  manager.config.StorageEnabled,
+		UsesMux:         manager.config.Mux,
+		ExecCommands:    manager.execCommands(),
+		TerminalEnabled: manager.config.TerminalEnabled,
+
+		Defaults: manager.defaultSettings(),
+	}
+}
+
+// defaultSettings returns settings, that new rooms start with.
+func (manager *RoomManagerCtx) defaultSettings() types.RoomSettings {
+	return types.RoomSettings{
+		MaxConnections: manager.config.DefaultMaxConnections,
+		Screen:         manager.config.DefaultScreen,
+		VideoCodec:     manager.config.DefaultVideoCodec,
+		AudioCodec:     manager.config.DefaultAudioCodec,
+		Domainname:     manager.config.DefaultDomainname,
+		Resources: types.RoomResources{
+			ShmSize:  manager.config.DefaultShmSize,
+			Memory:   manager.config.DefaultMemory,
+			NanoCPUs: manager.config.DefaultNanoCPUs,
+		},
 	}
 }
 
@@ -87,11 +153,10 @@ func (manager *RoomManagerCtx) List(ctx context.Context, labels map[string]strin
 	return result, nil
 }
 
-func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byte, error) {
+func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context, options types.ComposeOptions) ([]byte, error) {
 	services := map[string]any{}
 
 	dockerCompose := map[string]any{
-		"version": "3.8",
 		"networks": map[string]any{
 			"default": map[string]any{
 				"name":     manager.config.InstanceNetwork,
@@ -101,12 +166,20 @@ func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byt
 		"services": services,
 	}
 
+	if options.Version != "" {
+		dockerCompose["version"] = options.Version
+	}
+
 	containers, err := manager.listContainers(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, container := range containers {
+		if !options.IncludeStopped && container.State != "running" {
+			continue
+		}
+
 		containerJson, err := manager.inspectContainer(ctx, container.ID)
 		if err != nil {
 			return nil, err
@@ -124,9 +197,23 @@ func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byt
 		services[containerName] = service
 
 		service["image"] = labels.NekoImage
+		if options.PinDigests {
+			image, _, err := manager.client.ImageInspectWithRaw(ctx, containerJson.Image)
+			if err != nil {
+				return nil, err
+			}
+
+			// digest of the image, that the room is actually running
+			if len(image.RepoDigests) > 0 {
+				service["image"] = image.RepoDigests[0]
+			}
+		}
 		service["container_name"] = containerName
-		service["hostname"] = containerJson.Config.Hostname
-		service["restart"] = containerJson.HostConfig.RestartPolicy.Name
+		// restart policy can be disabled because of crash loop, label is the configured one
+		service["restart"] = defaultRestartPolicy
+		if restartPolicy := labels.RestartPolicy; restartPolicy != "" {
+			service["restart"] = restartPolicy
+		}
 
 		// privileged
 		if containerJson.HostConfig.Privileged {
@@ -180,15 +267,26 @@ func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byt
 			}
 		}
 
-		// hostname
+		// hostname and domainname, FQDN was split to both when room was created
 		if containerJson.Config.Hostname != containerName {
 			service["hostname"] = containerJson.Config.Hostname
 		}
+		if containerJson.Config.Domainname != "" {
+			service["domainname"] = containerJson.Config.Domainname
+		}
 
 		// dns
 		if len(containerJson.HostConfig.DNS) > 0 {
 			service["dns"] = containerJson.HostConfig.DNS
 		}
+		if len(containerJson.HostConfig.DNSSearch) > 0 {
+			service["dns_search"] = containerJson.HostConfig.DNSSearch
+		}
+
+		// extra hosts
+		if len(containerJson.HostConfig.ExtraHosts) > 0 {
+			service["extra_hosts"] = containerJson.HostConfig.ExtraHosts
+		}
 
 		// ports
 		ports := []string{}
@@ -198,12 +296,27 @@ func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byt
 			}
 		}
 		if len(ports) > 0 {
+			sort.Strings(ports)
 			service["ports"] = ports
 		}
 
 		// environment variables
-		if len(containerJson.Config.Env) > 0 {
-			service["environment"] = containerJson.Config.Env
+		env := []string{}
+		hasSecrets := false
+		for _, val := range containerJson.Config.Env {
+			key, _, _ := strings.Cut(val, "=")
+			if options.SecretsEnvFile && types.SecretEnvRegex.MatchString(key) {
+				hasSecrets = true
+				continue
+			}
+			env = append(env, val)
+		}
+		if len(env) > 0 {
+			sort.Strings(env)
+			service["environment"] = env
+		}
+		if hasSecrets {
+			service["env_file"] = []string{containerName + ".env"}
 		}
 
 		// volumes
@@ -216,6 +329,7 @@ func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byt
 			}
 		}
 		if len(volumes) > 0 {
+			sort.Strings(volumes)
 			service["volumes"] = volumes
 		}
 
@@ -225,6 +339,7 @@ func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byt
 			devices = append(devices, fmt.Sprintf("%s:%s:%s", device.PathOnHost, device.PathInContainer, device.CgroupPermissions))
 		}
 		if len(devices) > 0 {
+			sort.Strings(devices)
 			service["devices"] = devices
 		}
 
@@ -234,6 +349,7 @@ func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byt
 			labelsArr = append(labelsArr, fmt.Sprintf("%s=%s", key, val))
 		}
 		if len(labelsArr) > 0 {
+			sort.Strings(labelsArr)
 			service["labels"] = labelsArr
 		}
 	}
@@ -241,48 +357,158 @@ func (manager *RoomManagerCtx) ExportAsDockerCompose(ctx context.Context) ([]byt
 	return yaml.Marshal(dockerCompose)
 }
 
+// detectApiVersion returns neko API version of the image, based on its labels.
+func (manager *RoomManagerCtx) detectApiVersion(ctx context.Context, image string) (int, error) {
+	inspect, _, err := manager.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return 0, err
+	}
+
+	// based on image label
+	if val, ok := inspect.Config.Labels["m1k1o.neko_rooms.api_version"]; ok {
+		return strconv.Atoi(val)
+	}
+
+	// based on opencontainers image url label
+	if val, ok := inspect.Config.Labels["org.opencontainers.image.url"]; ok {
+		switch val {
+		case "https://github.com/m1k1o/neko":
+			return 2, nil
+		case "https://github.com/demodesk/neko":
+			return 3, nil
+		}
+		return 0, nil
+	}
+
+	// unable to detect api version
+	// TODO: this should be removed in future, but since we have a lot of v2 images, we need to support it
+	log.Warn().Str("image", image).Msg("unable to detect api version, fallback to v2")
+	return 2, nil
+}
+
 func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSettings) (string, error) {
 	if settings.Name != "" && !dockerNames.RestrictedNamePattern.MatchString(settings.Name) {
 		return "", fmt.Errorf("invalid container name, must match %s", dockerNames.RestrictedNameChars)
 	}
 
-	if in, _ := utils.ArrayIn(settings.NekoImage, manager.config.NekoImages); !in {
+	// snapshots are allowed, when image they were taken from is allowed
+	nekoImage := settings.NekoImage
+	if source, ok := manager.snapshotSource(ctx, nekoImage); ok {
+		nekoImage = source
+	}
+
+	if in, _ := utils.ArrayIn(nekoImage, manager.config.NekoImages); !in {
 		return "", fmt.Errorf("invalid neko image")
 	}
 
-	isPrivilegedImage, _ := utils.ArrayIn(settings.NekoImage, manager.config.NekoPrivilegedImages)
+	isPrivilegedImage, _ := utils.ArrayIn(nekoImage, manager.config.NekoPrivilegedImages)
+
+	if settings.Profile == "" {
+		settings.Profile = types.NekoProfile
+	}
+
+	if in, _ := utils.ArrayIn(settings.Profile, types.RoomProfiles); !in {
+		return "", fmt.Errorf("invalid room profile")
+	}
+
+	if settings.Series != "" && !dockerNames.RestrictedNamePattern.MatchString(settings.Series) {
+		return "", fmt.Errorf("invalid series name")
+	}
+
+	if settings.SeriesExtra && settings.Series == "" {
+		return "", fmt.Errorf("extra room must be part of a series")
+	}
+
+	if settings.IdleTimeout != nil && *settings.IdleTimeout < 0 {
+		return "", fmt.Errorf("idle timeout must not be negative")
+	}
+
+	restartPolicy, err := parseRestartPolicy(settings.RestartPolicy)
+	if err != nil {
+		return "", err
+	}
+
+	if settings.TTL < 0 {
+		return "", fmt.Errorf("ttl must not be negative")
+	}
+
+	// ttl is converted to expiry, so that it survives recreates
+	if settings.TTL > 0 {
+		if settings.Expires != nil {
+			return "", fmt.Errorf("ttl and expires can not be set at the same time")
+		}
+
+		expires := time.Now().Add(time.Duration(settings.TTL) * time.Second)
+		settings.Expires = &expires
+		settings.TTL = 0
+	}
+
+	switch settings.ExpireAction {
+	case "", types.RoomExpireRemove, types.RoomExpireStop:
+	default:
+		return "", fmt.Errorf("invalid expire action, must be one of remove, stop")
+	}
 
 	// if api version is not set, try to detect it
-	if settings.ApiVersion == 0 {
-		inspect, _, err := manager.client.ImageInspectWithRaw(ctx, settings.NekoImage)
+	if settings.ApiVersion == 0 && settings.Profile.IsNeko() {
+		var err error
+		settings.ApiVersion, err = manager.detectApiVersion(ctx, settings.NekoImage)
 		if err != nil {
 			return "", err
 		}
+	}
 
-		// based on image label
-		if val, ok := inspect.Config.Labels["m1k1o.neko_rooms.api_version"]; ok {
-			var err error
-			settings.ApiVersion, err = strconv.Atoi(val)
-			if err != nil {
-				return "", err
-			}
-		} else
-
-		// based on opencontainers image url label
-		if val, ok := inspect.Config.Labels["org.opencontainers.image.url"]; ok {
-			switch val {
-			case "https://github.com/m1k1o/neko":
-				settings.ApiVersion = 2
-			case "https://github.com/demodesk/neko":
-				settings.ApiVersion = 3
-			}
-		} else
+	// uuid is kept when room is recreated
+	roomUUID := settings.UUID
+	if roomUUID == "" {
+		var err error
+		roomUUID, err = utils.NewUUID()
+		if err != nil {
+			return "", err
+		}
+	} else if !utils.IsUUID(roomUUID) {
+		return "", fmt.Errorf("invalid room uuid")
+	} else if _, err := manager.resolveId(ctx, roomUUID); err == nil {
+		return "", fmt.Errorf("room with this uuid already exists")
+	} else if !errors.Is(err, types.ErrRoomNotFound) {
+		return "", err
+	}
 
-		// unable to detect api version
-		{
-			// TODO: this should be removed in future, but since we have a lot of v2 images, we need to support it
-			log.Warn().Str("image", settings.NekoImage).Msg("unable to detect api version, fallback to v2")
-			settings.ApiVersion = 2
+	if err := manager.validateNetwork(settings); err != nil {
+		return "", err
+	}
+
+	if settings.HttpProxy != nil {
+		if err := validateHttpProxy(settings.HttpProxy); err != nil {
+			return "", err
+		}
+	}
+
+	// clipboard and file transfer policy is enforced using envs
+	var transferLabels *TransferLabels
+	if settings.Profile.IsNeko() {
+		transfer := manager.effectiveTransfer(settings)
+
+		envs, err := transferEnvs(settings.ApiVersion, transfer, settings.Envs)
+		if err != nil {
+			return "", err
+		}
+
+		settings.Envs = envs
+		transferLabels = &transfer
+	} else if settings.Transfer != nil {
+		return "", fmt.Errorf("transfer policy can only be specified for neko images")
+	}
+
+	if settings.Priority != "" {
+		if in, _ := utils.ArrayIn(settings.Priority, types.RoomPriorities); !in {
+			return "", fmt.Errorf("invalid room priority")
+		}
+	}
+
+	for _, tag := range settings.Tags {
+		if tag == "" || strings.Contains(tag, ",") {
+			return "", fmt.Errorf("invalid tag, must not be empty nor contain a comma")
 		}
 	}
 
@@ -290,48 +516,66 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 	roomName := settings.Name
 	if roomName == "" {
 		var err error
-		roomName, err = utils.NewUID(8)
+		roomName, err = manager.newRoomName(ctx)
 		if err != nil {
 			return "", err
 		}
 	}
 
+	if err := manager.checkAliases(ctx, roomName, settings.Aliases); err != nil {
+		return "", err
+	}
+
 	containerName := manager.config.InstanceName + "-" + roomName
 
+	// wait for other heavy docker operations
+	if err := manager.dockerOps.Acquire(ctx); err != nil {
+		return "", err
+	}
+	defer manager.dockerOps.Release()
+
 	//
 	// Allocate ports
 	//
 
-	portsNeeded := settings.MaxConnections
-	if manager.config.Mux {
-		portsNeeded = 1
-	}
+	var epr EprPorts
+	portBindings := nat.PortMap{}
 
-	epr, err := manager.allocatePorts(ctx, portsNeeded)
-	if err != nil {
-		return "", err
-	}
+	// non-neko images do not use webrtc ports
+	if settings.Profile.IsNeko() {
+		portsNeeded := settings.MaxConnections
+		if manager.config.Mux {
+			portsNeeded = 1
+		}
 
-	portBindings := nat.PortMap{}
-	for port := epr.Min; port <= epr.Max; port++ {
-		portBindings[nat.Port(fmt.Sprintf("%d/udp", port))] = []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: fmt.Sprintf("%d", port),
-			},
+		var err error
+		epr, err = manager.allocatePorts(ctx, portsNeeded)
+		if err != nil {
+			return "", err
 		}
 
-		// expose TCP port as well when using mux
-		if manager.config.Mux {
-			portBindings[nat.Port(fmt.Sprintf("%d/tcp", port))] = []nat.PortBinding{
+		for port := epr.Min; port <= epr.Max; port++ {
+			portBindings[nat.Port(fmt.Sprintf("%d/udp", port))] = []nat.PortBinding{
 				{
 					HostIP:   "0.0.0.0",
 					HostPort: fmt.Sprintf("%d", port),
 				},
 			}
+
+			// expose TCP port as well when using mux
+			if manager.config.Mux {
+				portBindings[nat.Port(fmt.Sprintf("%d/tcp", port))] = []nat.PortBinding{
+					{
+						HostIP:   "0.0.0.0",
+						HostPort: fmt.Sprintf("%d", port),
+					},
+				}
+			}
 		}
 	}
 
+	frontendPort := settings.Profile.FrontendPort()
+
 	exposedPorts := nat.PortSet{
 		nat.Port(fmt.Sprintf("%d/tcp", frontendPort)): struct{}{},
 	}
@@ -352,81 +596,67 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 		}
 	}
 
+	var appLabels *AppLabels
+	if settings.App != nil {
+		if !settings.Profile.IsNeko() {
+			return "", fmt.Errorf("app can be specified only for neko images")
+		}
+
+		if err := validateApp(settings.App); err != nil {
+			return "", err
+		}
+
+		appLabels = &AppLabels{
+			Name: settings.App.Name,
+			Path: settings.App.Path,
+			Args: settings.App.Args,
+		}
+	}
+
 	labels := manager.serializeLabels(RoomLabels{
+		UUID: roomUUID,
 		Name: roomName,
 		Mux:  manager.config.Mux,
 		Epr:  epr,
 
 		NekoImage:  settings.NekoImage,
 		ApiVersion: settings.ApiVersion,
+		Profile:    settings.Profile,
 
 		BrowserPolicy: browserPolicyLabels,
+		App:           appLabels,
 		UserDefined:   settings.Labels,
-	})
 
-	//
-	// Set traefik labels
-	//
+		Description: settings.Description,
+		Tags:        settings.Tags,
+		Contact:     settings.Contact,
 
-	pathPrefix := path.Join("/", manager.config.PathPrefix, roomName)
-
-	if t := manager.config.Traefik; t.Enabled {
-		// create traefik rule
-		traefikRule := "PathPrefix(`" + pathPrefix + "`)"
-		if t.Domain != "" && t.Domain != "*" {
-			// match *.domain.tld as subdomain
-			if strings.HasPrefix(t.Domain, "*.") {
-				traefikRule = fmt.Sprintf(
-					"Host(`%s.%s`)",
-					roomName,
-					strings.TrimPrefix(t.Domain, "*."),
-				)
-			} else {
-				traefikRule += " && Host(`" + t.Domain + "`)"
-			}
-		} else {
-			traefikRule += " && HostRegexp(`{host:.+}`)"
-		}
+		Aliases: settings.Aliases,
 
-		labels["traefik.enable"] = "true"
-		labels["traefik.http.services."+containerName+"-frontend.loadbalancer.server.port"] = fmt.Sprintf("%d", frontendPort)
-		labels["traefik.http.routers."+containerName+".entrypoints"] = t.Entrypoint
-		labels["traefik.http.routers."+containerName+".rule"] = traefikRule
-		labels["traefik.http.middlewares."+containerName+"-rdr.redirectregex.regex"] = pathPrefix + "$$"
-		labels["traefik.http.middlewares."+containerName+"-rdr.redirectregex.replacement"] = pathPrefix + "/"
-		labels["traefik.http.middlewares."+containerName+"-prf.stripprefix.prefixes"] = pathPrefix + "/"
-		labels["traefik.http.routers."+containerName+".middlewares"] = containerName + "-rdr," + containerName + "-prf"
-		labels["traefik.http.routers."+containerName+".service"] = containerName + "-frontend"
+		Transfer: transferLabels,
 
-		// optional HTTPS
-		if t.Certresolver != "" {
-			labels["traefik.http.routers."+containerName+".tls"] = "true"
-			labels["traefik.http.routers."+containerName+".tls.certresolver"] = t.Certresolver
-		}
-	} else {
-		labels["m1k1o.neko_rooms.proxy.enabled"] = "true"
-		labels["m1k1o.neko_rooms.proxy.path"] = pathPrefix
-		labels["m1k1o.neko_rooms.proxy.port"] = fmt.Sprintf("%d", frontendPort)
-	}
+		Expires:      settings.Expires,
+		ExpireAction: settings.ExpireAction,
+		Demo:         settings.Demo,
+		Listed:       settings.Listed,
 
-	// add custom labels
-	for _, label := range manager.config.Labels {
-		// replace dynamic values in labels
-		label = strings.Replace(label, "{containerName}", containerName, -1)
-		label = strings.Replace(label, "{roomName}", roomName, -1)
+		Series:      settings.Series,
+		SeriesExtra: settings.SeriesExtra,
 
-		if t := manager.config.Traefik; t.Enabled {
-			label = strings.Replace(label, "{traefikEntrypoint}", t.Entrypoint, -1)
-			label = strings.Replace(label, "{traefikCertresolver}", t.Certresolver, -1)
-		}
+		Priority: settings.Priority,
 
-		v := strings.SplitN(label, "=", 2)
-		if len(v) != 2 {
-			manager.logger.Warn().Str("label", label).Msg("invalid custom label")
-			continue
-		}
+		IdleTimeout: settings.IdleTimeout,
+
+		RestartPolicy: settings.RestartPolicy,
+
+		Template: settings.Template,
+	})
+
+	//
+	// Set routing labels
+	//
 
-		key, val := v[0], v[1]
+	for key, val := range manager.routingLabels(containerName, roomName, settings.Aliases, settings.Profile) {
 		labels[key] = val
 	}
 
@@ -445,6 +675,12 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 		return "", err
 	}
 
+	// outbound http proxy
+	httpProxy := manager.effectiveHttpProxy(settings)
+	if httpProxy != nil {
+		env = append(env, httpProxyEnv(httpProxy)...)
+	}
+
 	//
 	// Set browser policies
 	//
@@ -454,7 +690,17 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 			return "", fmt.Errorf("policies cannot be specified, because storage is disabled or unavailable")
 		}
 
-		policyJson, err := policies.Generate(settings.BrowserPolicy.Content, settings.BrowserPolicy.Type)
+		// browsers are forced to use the same proxy
+		content := settings.BrowserPolicy.Content
+		if httpProxy != nil {
+			content.Proxy = &types.BrowserPolicyProxy{
+				Http:    httpProxy.Http,
+				Https:   httpProxy.Https,
+				NoProxy: httpProxy.NoProxy,
+			}
+		}
+
+		policyJson, err := policies.Generate(content, settings.BrowserPolicy.Type)
 		if err != nil {
 			return "", err
 		}
@@ -484,10 +730,102 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 		})
 	}
 
+	//
+	// Set single app
+	//
+
+	if settings.App != nil {
+		if !manager.config.StorageEnabled {
+			return "", fmt.Errorf("app cannot be specified, because storage is disabled or unavailable")
+		}
+
+		// create app config path (+ also get host path)
+		appPath := fmt.Sprintf("/%s-app.conf", roomName)
+		templateInternalPath := path.Join(manager.config.StorageInternal, templateStoragePath)
+		appInternalPath := path.Join(templateInternalPath, appPath)
+
+		// create dir if does not exist
+		if _, err := os.Stat(templateInternalPath); os.IsNotExist(err) {
+			if err := os.MkdirAll(templateInternalPath, os.ModePerm); err != nil {
+				return "", err
+			}
+		}
+
+		// write app config to file
+		if err := os.WriteFile(appInternalPath, []byte(generateAppSupervisord(settings.App)), 0644); err != nil {
+			return "", err
+		}
+
+		// mount app config
+		settings.Mounts = append(settings.Mounts, types.RoomMount{
+			Type:          types.MountTemplate,
+			HostPath:      appPath,
+			ContainerPath: appSupervisordPath,
+		})
+	}
+
 	//
 	// Set container mounts
 	//
 
+	if settings.BrowserProfile != "" {
+		if !manager.config.StorageEnabled {
+			return "", fmt.Errorf("browser profile cannot be specified, because storage is disabled or unavailable")
+		}
+
+		seed, err := manager.profileSeed(settings.BrowserProfile)
+		if err != nil {
+			return "", err
+		}
+
+		// profile is cloned to private mount, that is added when missing
+		hasMount := false
+		for _, mount := range settings.Mounts {
+			if mount.Type == types.MountPrivate && filepath.Clean(mount.ContainerPath) == seed.ContainerPath {
+				hasMount = true
+				break
+			}
+		}
+
+		if !hasMount {
+			settings.Mounts = append(settings.Mounts, types.RoomMount{
+				Type:          types.MountPrivate,
+				HostPath:      path.Join("/", filepath.Base(seed.ContainerPath)),
+				ContainerPath: seed.ContainerPath,
+			})
+		}
+
+		settings.Seeds = append(settings.Seeds, *seed)
+	}
+
+	seeds := map[string]string{}
+	for _, seed := range settings.Seeds {
+		if !manager.config.StorageEnabled {
+			return "", fmt.Errorf("seeds cannot be specified, because storage is disabled or unavailable")
+		}
+
+		archivePath := filepath.Clean(seed.Archive)
+		if !filepath.IsAbs(archivePath) {
+			return "", fmt.Errorf("seed archive path must be absolute")
+		}
+
+		containerPath := filepath.Clean(seed.ContainerPath)
+
+		isPrivate := false
+		for _, mount := range settings.Mounts {
+			if mount.Type == types.MountPrivate && filepath.Clean(mount.ContainerPath) == containerPath {
+				isPrivate = true
+				break
+			}
+		}
+
+		if !isPrivate {
+			return "", fmt.Errorf("seed container path must match private mount")
+		}
+
+		seeds[containerPath] = path.Join(manager.config.StorageInternal, templateStoragePath, archivePath)
+	}
+
 	paths := map[string]bool{}
 	mounts := []dockerMount.Mount{}
 	for _, mount := range settings.Mounts {
@@ -518,6 +856,15 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 					return "", err
 				}
 
+				// seed newly created storage
+				if archivePath, ok := seeds[containerPath]; ok {
+					if err := utils.ExtractTar(archivePath, internalPath); err != nil {
+						return "", fmt.Errorf("unable to extract seed archive: %w", err)
+					}
+
+					delete(seeds, containerPath)
+				}
+
 				if err := utils.ChownR(internalPath, privateStorageUid, privateStorageGid); err != nil {
 					return "", err
 				}
@@ -615,17 +962,18 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 	// Set container configs
 	//
 
-	hostname := containerName
-	if settings.Hostname != "" {
-		hostname = settings.Hostname
+	// Domainname is preventing from running container on LXC (Proxmox), so it is not set by default
+	// https://www.gitmemory.com/issue/docker/for-linux/743/524569376
+	hostname, domainname := splitHostname(settings.Hostname, settings.Domainname)
+	if hostname == "" {
+		hostname = containerName
 	}
 
 	config := &container.Config{
 		// Hostname
 		Hostname: hostname,
-		// Domainname is preventing from running container on LXC (Proxmox)
-		// https://www.gitmemory.com/issue/docker/for-linux/743/524569376
-		// Domainname: containerName,
+		// Domainname
+		Domainname: domainname,
 		// List of exposed ports
 		ExposedPorts: exposedPorts,
 		// List of environment variable to set in the container
@@ -645,9 +993,7 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 			Config: map[string]string{},
 		},
 		// Restart policy to be used for the container
-		RestartPolicy: container.RestartPolicy{
-			Name: "unless-stopped",
-		},
+		RestartPolicy: restartPolicy,
 		// List of kernel capabilities to add to the container
 		CapAdd: strslice.StrSlice{
 			"SYS_ADMIN",
@@ -665,7 +1011,10 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 			Devices:        devices,
 		},
 		// DNS
-		DNS: settings.DNS,
+		DNS:       settings.DNS,
+		DNSSearch: settings.DNSSearch,
+		// List of extra hosts
+		ExtraHosts: settings.ExtraHosts,
 		// Privileged
 		Privileged: isPrivilegedImage,
 	}
@@ -676,6 +1025,20 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 		},
 	}
 
+	// site-specific steps, that must succeed before room is created
+	hook := types.RoomHook{
+		Hook:      types.RoomHookPreCreate,
+		UUID:      roomUUID,
+		Name:      roomName,
+		NekoImage: settings.NekoImage,
+		Contact:   settings.Contact,
+		Labels:    settings.Labels,
+	}
+
+	if err := manager.runHook(ctx, manager.config.HooksPreCreate, hook); err != nil {
+		return "", fmt.Errorf("pre create hook failed: %w", err)
+	}
+
 	// Creating the actual container
 	container, err := manager.client.ContainerCreate(
 		ctx,
@@ -687,9 +1050,21 @@ func (manager *RoomManagerCtx) Create(ctx context.Context, settings types.RoomSe
 	)
 
 	if err != nil {
+		// let the hook undo its work, request context may be already gone
+		hook.Hook = types.RoomHookPreCreateFailed
+		if hookErr := manager.runHook(context.Background(), manager.config.HooksPreCreate, hook); hookErr != nil {
+			manager.logger.Err(hookErr).Str("name", roomName).Msg("hooks: pre create failed hook failed")
+		}
 		return "", err
 	}
 
+	// exported notes are restored together with the room
+	if len(settings.Notes) > 0 && manager.config.StorageEnabled {
+		if err := manager.restoreNotes(roomUUID, settings.Notes); err != nil {
+			manager.logger.Warn().Err(err).Str("uuid", roomUUID).Msg("notes: unable to restore notes")
+		}
+	}
+
 	return container.ID[:12], nil
 }
 
@@ -700,6 +1075,11 @@ func (manager *RoomManagerCtx) GetEntry(ctx context.Context, id string) (*types.
 		return nil, types.ErrRoomNotFound
 	}
 
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	container, err := manager.containerById(ctx, id)
 	if err != nil {
 		return nil, err
@@ -718,7 +1098,12 @@ func (manager *RoomManagerCtx) GetEntryByName(ctx context.Context, name string)
 }
 
 func (manager *RoomManagerCtx) Remove(ctx context.Context, id string) error {
-	_, err := manager.inspectContainer(ctx, id)
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = manager.inspectContainer(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -742,7 +1127,74 @@ func (manager *RoomManagerCtx) Remove(ctx context.Context, id string) error {
 	return err
 }
 
+// containerResources returns resources of the container, as used in room settings.
+func containerResources(hostConfig *container.HostConfig) types.RoomResources {
+	gpus := []string{}
+	for _, req := range hostConfig.DeviceRequests {
+		var isGpu bool
+		var caps []string
+		for _, cc := range req.Capabilities {
+			for _, c := range cc {
+				if c == "gpu" {
+					isGpu = true
+					continue
+				}
+				caps = append(caps, c)
+			}
+		}
+		if !isGpu {
+			continue
+		}
+
+		if req.Count > 1 {
+			gpus = append(gpus, fmt.Sprintf("count=%d", req.Count))
+		} else if req.Count == -1 {
+			gpus = append(gpus, "all")
+		}
+		if req.Driver != "" {
+			gpus = append(gpus, fmt.Sprintf("driver=%s", req.Driver))
+		}
+		if len(req.DeviceIDs) > 0 {
+			gpus = append(gpus, fmt.Sprintf("device=%s", strings.Join(req.DeviceIDs, ",")))
+		}
+		if len(caps) > 0 {
+			gpus = append(gpus, fmt.Sprintf("capabilities=%s", strings.Join(caps, ",")))
+		}
+		var opts []string
+		for key, val := range req.Options {
+			opts = append(opts, fmt.Sprintf("%s=%s", key, val))
+		}
+		if len(opts) > 0 {
+			gpus = append(gpus, fmt.Sprintf("options=%s", strings.Join(opts, ",")))
+		}
+	}
+
+	devices := []string{}
+	for _, dev := range hostConfig.Devices {
+		// TODO: dev.CgroupPermissions
+		if dev.PathOnHost == dev.PathInContainer {
+			devices = append(devices, dev.PathOnHost)
+		} else {
+			devices = append(devices, fmt.Sprintf("%s:%s", dev.PathOnHost, dev.PathInContainer))
+		}
+	}
+
+	return types.RoomResources{
+		CPUShares: hostConfig.CPUShares,
+		NanoCPUs:  hostConfig.NanoCPUs,
+		ShmSize:   hostConfig.ShmSize,
+		Memory:    hostConfig.Memory,
+		Gpus:      gpus,
+		Devices:   devices,
+	}
+}
+
 func (manager *RoomManagerCtx) GetSettings(ctx context.Context, id string) (*types.RoomSettings, error) {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	container, err := manager.inspectContainer(ctx, id)
 	if err != nil {
 		return nil, err
@@ -807,69 +1259,18 @@ func (manager *RoomManagerCtx) GetSettings(ctx context.Context, id string) (*typ
 	}
 
 	var roomResources types.RoomResources
+	var dns, dnsSearch, extraHosts []string
 	if container.HostConfig != nil {
-		gpus := []string{}
-		for _, req := range container.HostConfig.DeviceRequests {
-			var isGpu bool
-			var caps []string
-			for _, cc := range req.Capabilities {
-				for _, c := range cc {
-					if c == "gpu" {
-						isGpu = true
-						continue
-					}
-					caps = append(caps, c)
-				}
-			}
-			if !isGpu {
-				continue
-			}
-
-			if req.Count > 1 {
-				gpus = append(gpus, fmt.Sprintf("count=%d", req.Count))
-			} else if req.Count == -1 {
-				gpus = append(gpus, "all")
-			}
-			if req.Driver != "" {
-				gpus = append(gpus, fmt.Sprintf("driver=%s", req.Driver))
-			}
-			if len(req.DeviceIDs) > 0 {
-				gpus = append(gpus, fmt.Sprintf("device=%s", strings.Join(req.DeviceIDs, ",")))
-			}
-			if len(caps) > 0 {
-				gpus = append(gpus, fmt.Sprintf("capabilities=%s", strings.Join(caps, ",")))
-			}
-			var opts []string
-			for key, val := range req.Options {
-				opts = append(opts, fmt.Sprintf("%s=%s", key, val))
-			}
-			if len(opts) > 0 {
-				gpus = append(gpus, fmt.Sprintf("options=%s", strings.Join(opts, ",")))
-			}
-		}
-
-		devices := []string{}
-		for _, dev := range container.HostConfig.Devices {
-			// TODO: dev.CgroupPermissions
-			if dev.PathOnHost == dev.PathInContainer {
-				devices = append(devices, dev.PathOnHost)
-			} else {
-				devices = append(devices, fmt.Sprintf("%s:%s", dev.PathOnHost, dev.PathInContainer))
-			}
-		}
-
-		roomResources = types.RoomResources{
-			CPUShares: container.HostConfig.CPUShares,
-			NanoCPUs:  container.HostConfig.NanoCPUs,
-			ShmSize:   container.HostConfig.ShmSize,
-			Memory:    container.HostConfig.Memory,
-			Gpus:      gpus,
-			Devices:   devices,
-		}
+		roomResources = containerResources(container.HostConfig)
+		dns = container.HostConfig.DNS
+		dnsSearch = container.HostConfig.DNSSearch
+		extraHosts = container.HostConfig.ExtraHosts
 	}
 
 	settings := types.RoomSettings{
+		UUID:           labels.UUID,
 		ApiVersion:     labels.ApiVersion,
+		Profile:        labels.Profile,
 		Name:           labels.Name,
 		NekoImage:      labels.NekoImage,
 		MaxConnections: labels.Epr.Max - labels.Epr.Min + 1,
@@ -877,19 +1278,62 @@ func (manager *RoomManagerCtx) GetSettings(ctx context.Context, id string) (*typ
 		Mounts:         mounts,
 		Resources:      roomResources,
 		Hostname:       container.Config.Hostname,
-		DNS:            container.HostConfig.DNS,
+		Domainname:     container.Config.Domainname,
+		DNS:            dns,
+		DNSSearch:      dnsSearch,
+		ExtraHosts:     extraHosts,
 		BrowserPolicy:  browserPolicy,
+		Description:    labels.Description,
+		Tags:           labels.Tags,
+		Contact:        labels.Contact,
+		Aliases:        labels.Aliases,
+		Expires:        labels.Expires,
+		ExpireAction:   labels.ExpireAction,
+		Demo:           labels.Demo,
+		Listed:         labels.Listed,
+		Series:         labels.Series,
+		SeriesExtra:    labels.SeriesExtra,
+		Priority:       labels.Priority,
+		IdleTimeout:    labels.IdleTimeout,
+		RestartPolicy:  labels.RestartPolicy,
+		Template:       labels.Template,
 	}
 
-	if labels.Mux {
+	if labels.Mux || !labels.Profile.IsNeko() {
 		settings.MaxConnections = 0
 	}
 
-	err = settings.FromEnv(labels.ApiVersion, container.Config.Env)
-	return &settings, err
+	if labels.App != nil {
+		settings.App = &types.RoomApp{
+			Name: labels.App.Name,
+			Path: labels.App.Path,
+			Args: labels.App.Args,
+		}
+
+		// app config is managed by neko-rooms
+		for i, mount := range settings.Mounts {
+			if mount.ContainerPath == appSupervisordPath {
+				settings.Mounts = append(settings.Mounts[:i], settings.Mounts[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if err := settings.FromEnv(labels.ApiVersion, container.Config.Env); err != nil {
+		return nil, err
+	}
+
+	manager.extractHttpProxy(&settings)
+	manager.extractTransfer(&settings, labels)
+	return &settings, nil
 }
 
 func (manager *RoomManagerCtx) GetStats(ctx context.Context, id string) (*types.RoomStats, error) {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	container, err := manager.inspectContainer(ctx, id)
 	if err != nil {
 		return nil, err
@@ -900,6 +1344,10 @@ func (manager *RoomManagerCtx) GetStats(ctx context.Context, id string) (*types.
 		return nil, err
 	}
 
+	if !labels.Profile.IsNeko() {
+		return nil, fmt.Errorf("stats are not supported for profile: %s", labels.Profile)
+	}
+
 	settings := types.RoomSettings{}
 	err = settings.FromEnv(labels.ApiVersion, container.Config.Env)
 	if err != nil {
@@ -935,6 +1383,7 @@ func (manager *RoomManagerCtx) GetStats(ctx context.Context, id string) (*types.
 			} `json:"profile"`
 			State struct {
 				IsConnected       bool       `json:"is_connected"`
+				ConnectedSince    *time.Time `json:"connected_since"`
 				NotConnectedSince *time.Time `json:"not_connected_since"`
 			} `json:"state"`
 		}
@@ -955,6 +1404,8 @@ func (manager *RoomManagerCtx) GetStats(ctx context.Context, id string) (*types.
 					Name:  session.Profile.Name,
 					Admin: session.Profile.IsAdmin,
 					Muted: false, // not supported
+
+					ConnectedSince: session.State.ConnectedSince,
 				})
 			} else if session.State.NotConnectedSince != nil {
 				// populate last admin left time
@@ -981,21 +1432,63 @@ func (manager *RoomManagerCtx) GetStats(ctx context.Context, id string) (*types.
 		return nil, fmt.Errorf("unsupported API version: %d", labels.ApiVersion)
 	}
 
+	stats.OOMKills = manager.events.OOMKills(labels.UUID)
+
 	return &stats, nil
 }
 
 func (manager *RoomManagerCtx) Start(ctx context.Context, id string) error {
-	_, err := manager.inspectContainer(ctx, id)
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	containerJson, err := manager.inspectContainer(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	// room is started on purpose, reset crash loop detection
+	manager.events.clearCrashes(containerJson.ID[:12])
+
+	// restore restart policy, that was disabled because of crash loop
+	if containerJson.HostConfig.RestartPolicy.Name == "no" {
+		restartPolicy, err := parseRestartPolicy(containerJson.Config.Labels["m1k1o.neko_rooms.restart_policy"])
+		if err != nil {
+			return err
+		}
+
+		if restartPolicy.Name != "no" {
+			_, err := manager.client.ContainerUpdate(ctx, id, container.UpdateConfig{
+				RestartPolicy: restartPolicy,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// wait for our turn, when starts are staggered
+	priority := types.RoomPriority(containerJson.Config.Labels["m1k1o.neko_rooms.priority"])
+	if err := manager.starts.wait(ctx, priority); err != nil {
+		return err
+	}
+
 	// Start the actual container
-	return manager.client.ContainerStart(ctx, id, dockerTypes.ContainerStartOptions{})
+	if err := manager.client.ContainerStart(ctx, id, dockerTypes.ContainerStartOptions{}); err != nil {
+		return err
+	}
+
+	return manager.postStartHook(ctx, id)
 }
 
 func (manager *RoomManagerCtx) Stop(ctx context.Context, id string) error {
-	_, err := manager.inspectContainer(ctx, id)
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = manager.inspectContainer(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -1008,7 +1501,12 @@ func (manager *RoomManagerCtx) Stop(ctx context.Context, id string) error {
 }
 
 func (manager *RoomManagerCtx) Restart(ctx context.Context, id string) error {
-	_, err := manager.inspectContainer(ctx, id)
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = manager.inspectContainer(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -1020,8 +1518,43 @@ func (manager *RoomManagerCtx) Restart(ctx context.Context, id string) error {
 	})
 }
 
+// Pause freezes all processes of the room, browser state is kept in memory.
+func (manager *RoomManagerCtx) Pause(ctx context.Context, id string) error {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = manager.inspectContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Pause the actual container
+	return manager.client.ContainerPause(ctx, id)
+}
+
+func (manager *RoomManagerCtx) Unpause(ctx context.Context, id string) error {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = manager.inspectContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Unpause the actual container
+	return manager.client.ContainerUnpause(ctx, id)
+}
+
 // events
 
+func (manager *RoomManagerCtx) DiskPressure() bool {
+	return manager.events.IsDiskPressure()
+}
+
 func (manager *RoomManagerCtx) EventsLoopStart() {
 	manager.events.Start()
 }
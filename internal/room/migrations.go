@@ -0,0 +1,193 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// labelsVersion is current version of room labels schema. It must be increased
+// when labels are changed in a backwards incompatible way, and a migration
+// from the previous version must be added to labelsMigrations.
+const labelsVersion = 2
+
+// labelsMigrations upgrade labels from version i to version i+1.
+var labelsMigrations = []func(manager *RoomManagerCtx, labels map[string]string){
+	// 0 -> 1: url label was not always set
+	func(manager *RoomManagerCtx, labels map[string]string) {
+		if _, ok := labels["m1k1o.neko_rooms.url"]; !ok {
+			labels["m1k1o.neko_rooms.url"] = manager.config.GetRoomUrl(labels["m1k1o.neko_rooms.name"])
+		}
+	},
+	// 1 -> 2: uuid label was added, for existing rooms it is derived from its name,
+	// so that it stays the same until the room is recreated and the label is stored
+	func(manager *RoomManagerCtx, labels map[string]string) {
+		if _, ok := labels["m1k1o.neko_rooms.uuid"]; !ok {
+			labels["m1k1o.neko_rooms.uuid"] = utils.NameUUID(manager.config.InstanceName + "/" + labels["m1k1o.neko_rooms.name"])
+		}
+	},
+}
+
+// migrateLabels returns labels upgraded to the current schema version,
+// original labels are left untouched.
+func (manager *RoomManagerCtx) migrateLabels(labels map[string]string) (map[string]string, error) {
+	version := 0 // default, prior to labels versioning
+	if val, ok := labels["m1k1o.neko_rooms.version"]; ok {
+		var err error
+		version, err = strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("damaged container labels: version %w", err)
+		}
+	}
+
+	if version > labelsVersion {
+		return nil, fmt.Errorf("labels version %d is newer than supported version %d", version, labelsVersion)
+	}
+
+	if version == labelsVersion {
+		return labels, nil
+	}
+
+	migrated := make(map[string]string, len(labels))
+	for key, val := range labels {
+		migrated[key] = val
+	}
+
+	for _, migration := range labelsMigrations[version:] {
+		migration(manager, migrated)
+	}
+
+	migrated["m1k1o.neko_rooms.version"] = strconv.Itoa(labelsVersion)
+	return migrated, nil
+}
+
+// Migrations compares labels of existing rooms with labels that would be
+// generated using current config and labels schema, and returns rooms that
+// need to be recreated in order to match it.
+func (manager *RoomManagerCtx) Migrations(ctx context.Context) ([]types.RoomMigration, error) {
+	containers, err := manager.listContainers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.RoomMigration{}
+	for _, container := range containers {
+		labels, err := manager.extractLabels(container.Labels)
+		if err != nil {
+			return nil, err
+		}
+
+		containerName := manager.config.InstanceName + "-" + labels.Name
+		if len(container.Names) > 0 {
+			containerName = strings.TrimPrefix(container.Names[0], "/")
+		}
+
+		expected := manager.routingLabels(containerName, labels.Name, labels.Aliases, labels.Profile)
+		expected["m1k1o.neko_rooms.url"] = manager.config.GetRoomUrl(labels.Name)
+		expected["m1k1o.neko_rooms.version"] = strconv.Itoa(labelsVersion)
+		expected["m1k1o.neko_rooms.uuid"] = labels.UUID
+
+		changes := []types.RoomLabelChange{}
+		for key, val := range expected {
+			if current, ok := container.Labels[key]; !ok || current != val {
+				changes = append(changes, types.RoomLabelChange{
+					Key:      key,
+					Current:  current,
+					Expected: val,
+				})
+			}
+		}
+
+		for key, val := range container.Labels {
+			if _, ok := expected[key]; !ok && isRoutingLabel(key) {
+				changes = append(changes, types.RoomLabelChange{
+					Key:     key,
+					Current: val,
+				})
+			}
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].Key < changes[j].Key
+		})
+
+		result = append(result, types.RoomMigration{
+			ID:      container.ID[:12],
+			Name:    labels.Name,
+			Changes: changes,
+		})
+	}
+
+	return result, nil
+}
+
+// Migrate recreates all rooms returned by Migrations, running rooms are started
+// again. Errors of individual rooms are reported in the result.
+func (manager *RoomManagerCtx) Migrate(ctx context.Context) ([]types.RoomMigration, error) {
+	migrations, err := manager.Migrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range migrations {
+		logger := manager.logger.With().Str("id", migration.ID).Str("name", migration.Name).Logger()
+
+		ID, err := manager.recreate(ctx, migration.ID, nil)
+		if err != nil {
+			logger.Error().Err(err).Msg("migrate: failed to recreate room")
+			migrations[i].Error = err.Error()
+			continue
+		}
+
+		// new room has new id
+		migrations[i].ID = ID
+
+		logger.Info().Str("new_id", ID).Msg("migrate: room recreated")
+	}
+
+	return migrations, nil
+}
+
+// recreate removes room and creates it again with the same settings, optionally
+// modified. If the room was running, it is started again.
+func (manager *RoomManagerCtx) recreate(ctx context.Context, id string, modify func(settings *types.RoomSettings)) (string, error) {
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get room entry: %w", err)
+	}
+
+	settings, err := manager.GetSettings(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get room settings: %w", err)
+	}
+
+	if modify != nil {
+		modify(settings)
+	}
+
+	if err := manager.Remove(ctx, id); err != nil {
+		return "", fmt.Errorf("failed to remove room: %w", err)
+	}
+
+	ID, err := manager.Create(ctx, *settings)
+	if err != nil {
+		return "", fmt.Errorf("failed to create room: %w", err)
+	}
+
+	if entry.Running {
+		if err := manager.Start(ctx, ID); err != nil {
+			return ID, fmt.Errorf("failed to start room: %w", err)
+		}
+	}
+
+	return ID, nil
+}
@@ -0,0 +1,127 @@
+package room
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+func TestMigrateLabels(t *testing.T) {
+	manager := &RoomManagerCtx{
+		config: &config.Room{
+			InstanceName: "neko-rooms",
+		},
+	}
+
+	current := strconv.Itoa(labelsVersion)
+	nameUUID := utils.NameUUID("neko-rooms/foo")
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected map[string]string
+		err      bool
+	}{
+		{
+			name: "prior to versioning",
+			labels: map[string]string{
+				"m1k1o.neko_rooms.name": "foo",
+			},
+			expected: map[string]string{
+				"m1k1o.neko_rooms.name":    "foo",
+				"m1k1o.neko_rooms.url":     "http://127.0.0.1/foo/",
+				"m1k1o.neko_rooms.uuid":    nameUUID,
+				"m1k1o.neko_rooms.version": current,
+			},
+		},
+		{
+			name: "existing url is kept",
+			labels: map[string]string{
+				"m1k1o.neko_rooms.name": "foo",
+				"m1k1o.neko_rooms.url":  "https://example.com/foo/",
+			},
+			expected: map[string]string{
+				"m1k1o.neko_rooms.name":    "foo",
+				"m1k1o.neko_rooms.url":     "https://example.com/foo/",
+				"m1k1o.neko_rooms.uuid":    nameUUID,
+				"m1k1o.neko_rooms.version": current,
+			},
+		},
+		{
+			name: "from version 1",
+			labels: map[string]string{
+				"m1k1o.neko_rooms.name":    "foo",
+				"m1k1o.neko_rooms.version": "1",
+			},
+			expected: map[string]string{
+				"m1k1o.neko_rooms.name":    "foo",
+				"m1k1o.neko_rooms.uuid":    nameUUID,
+				"m1k1o.neko_rooms.version": current,
+			},
+		},
+		{
+			name: "existing uuid is kept",
+			labels: map[string]string{
+				"m1k1o.neko_rooms.name":    "foo",
+				"m1k1o.neko_rooms.uuid":    "6f1c0d5e-3b7a-4f2e-9c1d-2a4b6c8d0e1f",
+				"m1k1o.neko_rooms.version": "1",
+			},
+			expected: map[string]string{
+				"m1k1o.neko_rooms.name":    "foo",
+				"m1k1o.neko_rooms.uuid":    "6f1c0d5e-3b7a-4f2e-9c1d-2a4b6c8d0e1f",
+				"m1k1o.neko_rooms.version": current,
+			},
+		},
+		{
+			name: "current version",
+			labels: map[string]string{
+				"m1k1o.neko_rooms.name":    "foo",
+				"m1k1o.neko_rooms.version": current,
+			},
+			expected: map[string]string{
+				"m1k1o.neko_rooms.name":    "foo",
+				"m1k1o.neko_rooms.version": current,
+			},
+		},
+		{
+			name: "newer version",
+			labels: map[string]string{
+				"m1k1o.neko_rooms.version": strconv.Itoa(labelsVersion + 1),
+			},
+			err: true,
+		},
+		{
+			name: "damaged version",
+			labels: map[string]string{
+				"m1k1o.neko_rooms.version": "x",
+			},
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// original labels must be left untouched
+			original := map[string]string{}
+			for key, val := range tt.labels {
+				original[key] = val
+			}
+
+			migrated, err := manager.migrateLabels(tt.labels)
+			if (err != nil) != tt.err {
+				t.Fatalf("migrateLabels() error = %v, expected error %v", err, tt.err)
+			}
+
+			if !tt.err && !reflect.DeepEqual(migrated, tt.expected) {
+				t.Errorf("migrateLabels() = %v, expected %v", migrated, tt.expected)
+			}
+
+			if !reflect.DeepEqual(tt.labels, original) {
+				t.Errorf("migrateLabels() modified original labels to %v", tt.labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	dockerNames "github.com/docker/docker/daemon/names"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// prefix of room names generated by sequential strategy
+const sequentialNamePrefix = "room-"
+
+// newRoomName generates name for a room, that has been created without one.
+func (manager *RoomManagerCtx) newRoomName(ctx context.Context) (string, error) {
+	switch manager.config.NameStrategy {
+	case config.NameStrategyRandom:
+		return utils.NewUID(8)
+	case config.NameStrategySlug:
+		return utils.NewSlug()
+	case config.NameStrategySequential:
+		containers, err := manager.listContainers(ctx, nil)
+		if err != nil {
+			return "", err
+		}
+
+		last := 0
+		for _, container := range containers {
+			name, ok := container.Labels["m1k1o.neko_rooms.name"]
+			if !ok || !strings.HasPrefix(name, sequentialNamePrefix) {
+				continue
+			}
+
+			num, err := strconv.Atoi(strings.TrimPrefix(name, sequentialNamePrefix))
+			if err == nil && num > last {
+				last = num
+			}
+		}
+
+		return fmt.Sprintf("%s%d", sequentialNamePrefix, last+1), nil
+	case config.NameStrategyRequest:
+		return "", fmt.Errorf("room name is required")
+	}
+
+	return "", fmt.Errorf("unknown name strategy: %s", manager.config.NameStrategy)
+}
+
+// checkAliases validates aliases of a room and ensures, that neither room name
+// nor its aliases are used by any other room.
+func (manager *RoomManagerCtx) checkAliases(ctx context.Context, roomName string, aliases []string) error {
+	names := map[string]struct{}{roomName: {}}
+	for _, alias := range aliases {
+		if !dockerNames.RestrictedNamePattern.MatchString(alias) {
+			return fmt.Errorf("invalid alias, must match %s", dockerNames.RestrictedNameChars)
+		}
+
+		if _, ok := names[alias]; ok {
+			return fmt.Errorf("alias %s is duplicate or same as room name", alias)
+		}
+
+		names[alias] = struct{}{}
+	}
+
+	containers, err := manager.listContainers(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, container := range containers {
+		labels, err := manager.extractLabels(container.Labels)
+		if err != nil {
+			return err
+		}
+
+		// room itself, when being recreated, name conflicts are checked by docker
+		if labels.Name == roomName {
+			continue
+		}
+
+		if _, ok := names[labels.Name]; ok {
+			return fmt.Errorf("alias %s is already used as room name", labels.Name)
+		}
+
+		for _, alias := range labels.Aliases {
+			if _, ok := names[alias]; ok {
+				return fmt.Errorf("name %s is already used as alias of room %s", alias, labels.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetAliases recreates room with new aliases and returns its new id.
+func (manager *RoomManagerCtx) SetAliases(ctx context.Context, id string, aliases []string) (string, error) {
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	// check before the room is removed, so that it is not lost
+	if err := manager.checkAliases(ctx, entry.Name, aliases); err != nil {
+		return "", err
+	}
+
+	return manager.recreate(ctx, id, func(settings *types.RoomSettings) {
+		settings.Aliases = aliases
+	})
+}
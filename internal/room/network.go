@@ -0,0 +1,99 @@
+package room
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// single DNS label as of RFC 1123
+var hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// validDomainname checks, that all labels of domain name are valid.
+func validDomainname(domainname string) bool {
+	if len(domainname) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(domainname, ".") {
+		if !hostnameLabel.MatchString(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitHostname splits FQDN to hostname and domainname, when domainname is not set,
+// so that the room sees a real FQDN.
+func splitHostname(hostname, domainname string) (string, string) {
+	if domainname == "" {
+		if host, domain, ok := strings.Cut(hostname, "."); ok {
+			return host, domain
+		}
+	}
+	return hostname, domainname
+}
+
+// validateNetwork checks hostname, domainname, and DNS servers and extra hosts against whitelists from config.
+func (manager *RoomManagerCtx) validateNetwork(settings types.RoomSettings) error {
+	hostname, domainname := splitHostname(settings.Hostname, settings.Domainname)
+	if hostname != "" && !hostnameLabel.MatchString(hostname) {
+		return fmt.Errorf("invalid hostname %s, must be a valid DNS label", hostname)
+	}
+
+	if domainname != "" && !validDomainname(domainname) {
+		return fmt.Errorf("invalid domainname %s, must be a valid DNS name", domainname)
+	}
+
+	for _, dns := range settings.DNS {
+		if net.ParseIP(dns) == nil {
+			return fmt.Errorf("invalid DNS server %s, must be an IP address", dns)
+		}
+
+		if len(manager.config.DNSWhitelist) > 0 {
+			if in, _ := utils.ArrayIn(dns, manager.config.DNSWhitelist); !in {
+				return fmt.Errorf("DNS server %s is not whitelisted", dns)
+			}
+		}
+	}
+
+	for _, search := range settings.DNSSearch {
+		if search == "" || strings.ContainsAny(search, " \t\n") {
+			return fmt.Errorf("invalid DNS search domain")
+		}
+	}
+
+	for _, extraHost := range settings.ExtraHosts {
+		// ipv6 addresses contain colons, host is before the first one
+		host, ip, ok := strings.Cut(extraHost, ":")
+		if !ok || host == "" || (net.ParseIP(ip) == nil && ip != "host-gateway") {
+			return fmt.Errorf("invalid extra host %s, must be in form of host:ip", extraHost)
+		}
+
+		if len(manager.config.ExtraHostsWhitelist) > 0 && !hostWhitelisted(host, manager.config.ExtraHostsWhitelist) {
+			return fmt.Errorf("extra host %s is not whitelisted", host)
+		}
+	}
+
+	return nil
+}
+
+// hostWhitelisted matches host exactly, or as subdomain for *.domain.tld patterns.
+func hostWhitelisted(host string, whitelist []string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range whitelist {
+		pattern = strings.ToLower(pattern)
+		if host == pattern {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
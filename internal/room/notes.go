@@ -0,0 +1,183 @@
+package room
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// notes are stored by room uuid, so that they survive recreates
+const notesStoragePath = "./notes"
+
+func (manager *RoomManagerCtx) notesPath(roomUUID string) (string, error) {
+	if !utils.IsUUID(roomUUID) {
+		return "", fmt.Errorf("invalid room uuid")
+	}
+
+	return path.Join(manager.config.StorageInternal, notesStoragePath, roomUUID+".json"), nil
+}
+
+func (manager *RoomManagerCtx) readNotes(roomUUID string) ([]types.RoomNote, error) {
+	notesPath, err := manager.notesPath(roomUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(notesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []types.RoomNote{}, nil
+		}
+		return nil, err
+	}
+
+	notes := []types.RoomNote{}
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+func (manager *RoomManagerCtx) writeNotes(roomUUID string, notes []types.RoomNote) error {
+	notesPath, err := manager.notesPath(roomUUID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(notesPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to temporary file, so that notes are not lost when write fails
+	if err := os.WriteFile(notesPath+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(notesPath+".tmp", notesPath)
+}
+
+// entryNotes returns notes of room for its entry, errors are only logged.
+func (manager *RoomManagerCtx) entryNotes(roomUUID string) []types.RoomNote {
+	if !manager.config.StorageEnabled || roomUUID == "" {
+		return nil
+	}
+
+	manager.notesMu.Lock()
+	defer manager.notesMu.Unlock()
+
+	notes, err := manager.readNotes(roomUUID)
+	if err != nil {
+		manager.logger.Warn().Err(err).Str("uuid", roomUUID).Msg("notes: unable to read notes")
+		return nil
+	}
+
+	if len(notes) == 0 {
+		return nil
+	}
+
+	return notes
+}
+
+func (manager *RoomManagerCtx) GetNotes(ctx context.Context, id string) ([]types.RoomNote, error) {
+	if !manager.config.StorageEnabled {
+		return nil, fmt.Errorf("notes are not available, because storage is disabled or unavailable")
+	}
+
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.notesMu.Lock()
+	defer manager.notesMu.Unlock()
+
+	return manager.readNotes(entry.UUID)
+}
+
+func (manager *RoomManagerCtx) AddNote(ctx context.Context, id string, author string, text string) (*types.RoomNote, error) {
+	if !manager.config.StorageEnabled {
+		return nil, fmt.Errorf("notes are not available, because storage is disabled or unavailable")
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("note text must not be empty")
+	}
+
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	noteId, err := utils.NewUID(8)
+	if err != nil {
+		return nil, err
+	}
+
+	note := types.RoomNote{
+		ID:     noteId,
+		Time:   time.Now(),
+		Author: author,
+		Text:   text,
+	}
+
+	manager.notesMu.Lock()
+	defer manager.notesMu.Unlock()
+
+	notes, err := manager.readNotes(entry.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := manager.writeNotes(entry.UUID, append(notes, note)); err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+func (manager *RoomManagerCtx) RemoveNote(ctx context.Context, id string, noteId string) error {
+	if !manager.config.StorageEnabled {
+		return fmt.Errorf("notes are not available, because storage is disabled or unavailable")
+	}
+
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	manager.notesMu.Lock()
+	defer manager.notesMu.Unlock()
+
+	notes, err := manager.readNotes(entry.UUID)
+	if err != nil {
+		return err
+	}
+
+	for i, note := range notes {
+		if note.ID == noteId {
+			return manager.writeNotes(entry.UUID, append(notes[:i], notes[i+1:]...))
+		}
+	}
+
+	return types.ErrNoteNotFound
+}
+
+// restoreNotes stores notes, that were exported together with room settings.
+func (manager *RoomManagerCtx) restoreNotes(roomUUID string, notes []types.RoomNote) error {
+	manager.notesMu.Lock()
+	defer manager.notesMu.Unlock()
+
+	return manager.writeNotes(roomUUID, notes)
+}
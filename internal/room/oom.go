@@ -0,0 +1,109 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+const oomRecreateTimeout = 5 * time.Minute
+
+// recordOOMKill records out of memory kill of a room and notifies handler.
+func (e *events) recordOOMKill(roomId string, labels map[string]string) {
+	name := labels["m1k1o.neko_rooms.name"]
+
+	// uuid survives recreates and renames, rooms without uuid label derive it from name
+	uuid := labels["m1k1o.neko_rooms.uuid"]
+	if uuid == "" {
+		uuid = utils.NameUUID(e.config.InstanceName + "/" + name)
+	}
+
+	e.oomKillsMu.Lock()
+	e.oomKills[uuid]++
+	count := e.oomKills[uuid]
+	e.oomKillsMu.Unlock()
+
+	// do not block events loop
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		e.sendAlert(types.RoomAlert{
+			Type:    types.RoomAlertOOM,
+			ID:      roomId,
+			Name:    name,
+			Time:    time.Now(),
+			Message: fmt.Sprintf("room was killed because of out of memory (%d times)", count),
+		})
+	}()
+
+	if e.onOOMKill != nil {
+		e.onOOMKill(roomId, labels)
+	}
+}
+
+func (e *events) OOMKills(uuid string) int {
+	e.oomKillsMu.Lock()
+	defer e.oomKillsMu.Unlock()
+
+	return e.oomKills[uuid]
+}
+
+// handleOOMKill recreates room with increased memory limit, if enabled.
+func (manager *RoomManagerCtx) handleOOMKill(roomId string, labels map[string]string) {
+	increment := manager.config.OOMMemoryIncrement
+	if increment <= 0 {
+		return
+	}
+
+	logger := manager.logger.With().Str("id", roomId).Str("name", labels["m1k1o.neko_rooms.name"]).Logger()
+
+	// do not block events loop
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), oomRecreateTimeout)
+		defer cancel()
+
+		settings, err := manager.GetSettings(ctx, roomId)
+		if err != nil {
+			logger.Err(err).Msg("oom: failed to get room settings")
+			return
+		}
+
+		// unlimited memory can not be increased
+		memory := settings.Resources.Memory
+		if memory <= 0 {
+			return
+		}
+
+		memory += increment
+		if max := manager.config.OOMMemoryMax; max > 0 && memory > max {
+			memory = max
+		}
+
+		if memory <= settings.Resources.Memory {
+			logger.Warn().Int64("memory", memory).Msg("oom: room memory limit reached maximum")
+			return
+		}
+
+		ID, err := manager.recreate(ctx, roomId, func(settings *types.RoomSettings) {
+			settings.Resources.Memory = memory
+		})
+		if err != nil {
+			logger.Err(err).Msg("oom: failed to recreate room")
+			return
+		}
+
+		// room could have been restarting, while it was recreated
+		if entry, err := manager.GetEntry(ctx, ID); err == nil && !entry.Running {
+			if err := manager.Start(ctx, ID); err != nil {
+				logger.Err(err).Msg("oom: failed to start room")
+				return
+			}
+		}
+
+		logger.Info().Str("new_id", ID).Int64("memory", memory).Msg("oom: room recreated with increased memory")
+	}()
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"sort"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
 )
 
 type EprPorts struct {
@@ -38,7 +40,7 @@ func (manager *RoomManagerCtx) allocatePorts(ctx context.Context, sum uint16) (E
 	}
 
 	if epr.Min > max || epr.Max > max {
-		return epr, fmt.Errorf("unable to allocate ports: not enough ports")
+		return epr, fmt.Errorf("unable to allocate ports: %w", types.ErrNotEnoughCapacity)
 	}
 
 	return epr, nil
@@ -57,6 +59,11 @@ func (manager *RoomManagerCtx) getUsedPorts(ctx context.Context) ([]EprPorts, er
 			return nil, err
 		}
 
+		// non-neko images do not use webrtc ports
+		if !labels.Profile.IsNeko() {
+			continue
+		}
+
 		result = append(result, labels.Epr)
 	}
 
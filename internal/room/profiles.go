@@ -0,0 +1,266 @@
+package room
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// profiles are stored in templates storage, so that they can be used as seeds
+const profilesStoragePath = "/profiles"
+
+var profileNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func (manager *RoomManagerCtx) profilePaths(name string) (archive string, meta string, err error) {
+	if !profileNameRegex.MatchString(name) {
+		return "", "", fmt.Errorf("invalid profile name, allowed characters: [a-z0-9_-]")
+	}
+
+	base := path.Join(manager.config.StorageInternal, templateStoragePath, profilesStoragePath, name)
+	return base + ".tar.gz", base + ".json", nil
+}
+
+func (manager *RoomManagerCtx) ListProfiles(owner string) ([]types.BrowserProfile, error) {
+	if !manager.config.StorageEnabled {
+		return nil, fmt.Errorf("profiles are not available, because storage is disabled or unavailable")
+	}
+
+	dir := path.Join(manager.config.StorageInternal, templateStoragePath, profilesStoragePath)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []types.BrowserProfile{}, nil
+		}
+		return nil, err
+	}
+
+	profiles := []types.BrowserProfile{}
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), ".json")
+		if !ok {
+			continue
+		}
+
+		profile, err := manager.GetProfile(name)
+		if err != nil {
+			manager.logger.Warn().Err(err).Str("profile", name).Msg("unable to read profile")
+			continue
+		}
+
+		if owner == "" || profile.Owner == owner {
+			profiles = append(profiles, *profile)
+		}
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Created.Before(profiles[j].Created)
+	})
+
+	return profiles, nil
+}
+
+func (manager *RoomManagerCtx) GetProfile(name string) (*types.BrowserProfile, error) {
+	_, metaPath, err := manager.profilePaths(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, types.ErrProfileNotFound
+		}
+		return nil, err
+	}
+
+	var profile types.BrowserProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// SaveProfile stores contents of room's private mount as named profile. Room is
+// stopped while its storage is read and started again afterwards.
+func (manager *RoomManagerCtx) SaveProfile(ctx context.Context, id string, request types.BrowserProfileRequest, owner string) (*types.BrowserProfile, error) {
+	if !manager.config.StorageEnabled {
+		return nil, fmt.Errorf("profiles are not available, because storage is disabled or unavailable")
+	}
+
+	archivePath, metaPath, err := manager.profilePaths(request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := manager.GetProfile(request.Name); err == nil {
+		if existing.Owner != owner {
+			return nil, types.ErrProfileForbidden
+		}
+	} else if !errors.Is(err, types.ErrProfileNotFound) {
+		return nil, err
+	} else if manager.config.ProfilesMaxPerOwner > 0 {
+		profiles, err := manager.ListProfiles(owner)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(profiles) >= manager.config.ProfilesMaxPerOwner {
+			return nil, fmt.Errorf("%w: maximum %d profiles per owner", types.ErrProfileQuota, manager.config.ProfilesMaxPerOwner)
+		}
+	}
+
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := manager.GetSettings(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	containerPath := filepath.Clean(request.ContainerPath)
+
+	var hostPath string
+	for _, mount := range settings.Mounts {
+		if mount.Type == types.MountPrivate && filepath.Clean(mount.ContainerPath) == containerPath {
+			hostPath = mount.HostPath
+			break
+		}
+	}
+
+	if hostPath == "" {
+		return nil, fmt.Errorf("container path must match private mount")
+	}
+
+	// storage must not be changed while it is read
+	if entry.Running {
+		if err := manager.Stop(ctx, id); err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err := manager.Start(context.Background(), id); err != nil {
+				manager.logger.Err(err).Str("id", id).Msg("unable to start room after saving profile")
+			}
+		}()
+	}
+
+	if err := os.MkdirAll(path.Dir(archivePath), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	// write to temporary file, so that existing profile is replaced only when complete
+	privateStorage := path.Join(manager.config.StorageInternal, privateStoragePath, entry.Name, hostPath)
+	if err := writeProfileArchive(archivePath+".tmp", privateStorage); err != nil {
+		os.Remove(archivePath + ".tmp")
+		return nil, err
+	}
+
+	if err := os.Rename(archivePath+".tmp", archivePath); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &types.BrowserProfile{
+		Name:          request.Name,
+		Owner:         owner,
+		Room:          entry.Name,
+		ContainerPath: containerPath,
+		Size:          info.Size(),
+		Created:       time.Now(),
+	}
+
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(metaPath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+func writeProfileArchive(archivePath, src string) error {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	if err := utils.WriteTarDir(tw, src, ""); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func (manager *RoomManagerCtx) RemoveProfile(name string, owner string) error {
+	archivePath, metaPath, err := manager.profilePaths(name)
+	if err != nil {
+		return err
+	}
+
+	profile, err := manager.GetProfile(name)
+	if err != nil {
+		return err
+	}
+
+	if profile.Owner != owner {
+		return types.ErrProfileForbidden
+	}
+
+	if err := os.Remove(metaPath); err != nil {
+		return err
+	}
+
+	if err := os.Remove(archivePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// profileSeed returns seed, that clones profile to newly created room.
+func (manager *RoomManagerCtx) profileSeed(name string) (*types.RoomSeed, error) {
+	profile, err := manager.GetProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.RoomSeed{
+		Archive:       path.Join(profilesStoragePath, profile.Name+".tar.gz"),
+		ContainerPath: profile.ContainerPath,
+	}, nil
+}
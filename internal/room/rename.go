@@ -0,0 +1,110 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	dockerNames "github.com/docker/docker/daemon/names"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// Rename recreates room under new name, so that its routing follows the name.
+// Private storage is moved to the new name and uuid of the room is kept, so that
+// its data, notes and recordings are not lost. If the room can not be created
+// under new name, it is created again under the old one.
+func (manager *RoomManagerCtx) Rename(ctx context.Context, id string, name string) (string, error) {
+	if !dockerNames.RestrictedNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid room name, must match %s", dockerNames.RestrictedNameChars)
+	}
+
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if entry.Name == name {
+		return entry.ID, nil
+	}
+
+	if _, err := manager.containerByName(ctx, name); err == nil {
+		return "", types.ErrRoomNameTaken
+	}
+
+	settings, err := manager.GetSettings(ctx, entry.ID)
+	if err != nil {
+		return "", err
+	}
+
+	oldStorage := path.Join(manager.config.StorageInternal, privateStoragePath, entry.Name)
+	newStorage := path.Join(manager.config.StorageInternal, privateStoragePath, name)
+
+	moveStorage := false
+	if manager.config.StorageEnabled {
+		if _, err := os.Stat(oldStorage); err == nil {
+			moveStorage = true
+		}
+
+		// storage of another room must not be overwritten
+		if _, err := os.Stat(newStorage); err == nil {
+			return "", fmt.Errorf("storage of room %s already exists", name)
+		}
+	}
+
+	if err := manager.Remove(ctx, entry.ID); err != nil {
+		return "", fmt.Errorf("failed to remove room: %w", err)
+	}
+
+	if moveStorage {
+		if err := os.Rename(oldStorage, newStorage); err != nil {
+			manager.logger.Err(err).Str("name", entry.Name).Msg("rename: failed to move storage")
+			return manager.renameRollback(ctx, entry, *settings, fmt.Errorf("failed to move storage: %w", err))
+		}
+	}
+
+	renamed := *settings
+	renamed.Name = name
+
+	// default hostname is container name, it must follow the new name
+	if renamed.Hostname == manager.config.InstanceName+"-"+entry.Name {
+		renamed.Hostname = ""
+	}
+
+	ID, err := manager.Create(ctx, renamed)
+	if err != nil {
+		if moveStorage {
+			if err := os.Rename(newStorage, oldStorage); err != nil {
+				manager.logger.Err(err).Str("name", name).Msg("rename: failed to move storage back")
+			}
+		}
+		return manager.renameRollback(ctx, entry, *settings, fmt.Errorf("failed to create room: %w", err))
+	}
+
+	if entry.Running {
+		if err := manager.Start(ctx, ID); err != nil {
+			return ID, fmt.Errorf("failed to start room: %w", err)
+		}
+	}
+
+	manager.logger.Info().Str("id", ID).Str("old_name", entry.Name).Str("name", name).Msg("room renamed")
+	return ID, nil
+}
+
+// renameRollback creates room with original settings again, after rename failed.
+func (manager *RoomManagerCtx) renameRollback(ctx context.Context, entry *types.RoomEntry, settings types.RoomSettings, cause error) (string, error) {
+	ID, err := manager.Create(ctx, settings)
+	if err != nil {
+		manager.logger.Err(err).Str("name", entry.Name).Msg("rename: failed to create room again")
+		return "", cause
+	}
+
+	if entry.Running {
+		if err := manager.Start(ctx, ID); err != nil {
+			manager.logger.Err(err).Str("id", ID).Msg("rename: failed to start room again")
+		}
+	}
+
+	return "", cause
+}
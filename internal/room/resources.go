@@ -0,0 +1,40 @@
+package room
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// UpdateResources changes cpu and memory limits of the room in place, without
+// recreating its container. Limits can not be removed this way.
+func (manager *RoomManagerCtx) UpdateResources(ctx context.Context, id string, resources types.RoomResources) error {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	containerJson, err := manager.client.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	update := container.Resources{
+		CPUShares: resources.CPUShares,
+		NanoCPUs:  resources.NanoCPUs,
+		Memory:    resources.Memory,
+	}
+
+	// swap must not be lower than memory, keep the same amount of swap
+	currentMemory := containerJson.HostConfig.Memory
+	if swap := containerJson.HostConfig.MemorySwap; swap > 0 && resources.Memory != currentMemory {
+		update.MemorySwap = resources.Memory + swap - currentMemory
+	}
+
+	_, err = manager.client.ContainerUpdate(ctx, id, container.UpdateConfig{
+		Resources: update,
+	})
+	return err
+}
@@ -0,0 +1,42 @@
+package room
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// restart policy of rooms, that do not specify their own
+const defaultRestartPolicy = "unless-stopped"
+
+// parseRestartPolicy parses restart policy in docker compose format, e.g. on-failure:5.
+func parseRestartPolicy(policy string) (container.RestartPolicy, error) {
+	if policy == "" {
+		policy = defaultRestartPolicy
+	}
+
+	name, retries, hasRetries := strings.Cut(policy, ":")
+	switch name {
+	case "no", "always", "unless-stopped":
+		if hasRetries {
+			return container.RestartPolicy{}, fmt.Errorf("maximum retry count can be set only for on-failure restart policy")
+		}
+
+		return container.RestartPolicy{Name: name}, nil
+	case "on-failure":
+		restartPolicy := container.RestartPolicy{Name: name}
+		if hasRetries {
+			count, err := strconv.Atoi(retries)
+			if err != nil || count < 0 {
+				return container.RestartPolicy{}, fmt.Errorf("invalid maximum retry count of on-failure restart policy")
+			}
+			restartPolicy.MaximumRetryCount = count
+		}
+
+		return restartPolicy, nil
+	default:
+		return container.RestartPolicy{}, fmt.Errorf("invalid restart policy, must be one of: always, unless-stopped, on-failure[:N], no")
+	}
+}
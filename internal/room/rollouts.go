@@ -0,0 +1,242 @@
+package room
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// rollouts are kept only in memory, rooms themselves keep their images in labels
+type rollout struct {
+	types.RoomRollout
+
+	// uuids of rooms, that were moved to canary image
+	canaries []string
+}
+
+// CreateRollout recreates percentage of rooms, that use the source image and match
+// given labels, with canary image.
+func (manager *RoomManagerCtx) CreateRollout(ctx context.Context, request types.RoomRolloutRequest) (*types.RoomRollout, error) {
+	for _, image := range []string{request.FromImage, request.Image} {
+		if in, _ := utils.ArrayIn(image, manager.config.NekoImages); !in {
+			return nil, fmt.Errorf("invalid neko image: %s", image)
+		}
+	}
+
+	if request.FromImage == request.Image {
+		return nil, fmt.Errorf("canary image must be different from source image")
+	}
+
+	if request.Percent <= 0 || request.Percent > 100 {
+		return nil, fmt.Errorf("percent must be between 1 and 100")
+	}
+
+	entries, err := manager.List(ctx, request.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []types.RoomEntry{}
+	for _, entry := range entries {
+		if entry.NekoImage == request.FromImage {
+			candidates = append(candidates, entry)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no rooms use source image")
+	}
+
+	// deterministic selection, rounded up so that at least one room is selected
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Name < candidates[j].Name
+	})
+	count := (len(candidates)*request.Percent + 99) / 100
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	r := &rollout{
+		RoomRollout: types.RoomRollout{
+			ID:        hex.EncodeToString(id),
+			FromImage: request.FromImage,
+			Image:     request.Image,
+			Percent:   request.Percent,
+			Labels:    request.Labels,
+			Status:    types.RoomRolloutCanary,
+			Created:   time.Now(),
+		},
+	}
+
+	for _, entry := range candidates[:count] {
+		r.canaries = append(r.canaries, entry.UUID)
+		if _, err := manager.setRoomImage(ctx, entry.UUID, request.Image); err != nil {
+			manager.logger.Err(err).Str("name", entry.Name).Msg("rollout: failed to recreate room with canary image")
+		}
+	}
+
+	manager.rolloutsMu.Lock()
+	manager.rollouts[r.ID] = r
+	manager.rolloutsMu.Unlock()
+
+	manager.logger.Info().
+		Str("rollout_id", r.ID).
+		Str("image", r.Image).
+		Int("rooms", len(r.canaries)).
+		Msg("rollout: canary created")
+
+	return manager.rolloutStatus(ctx, r), nil
+}
+
+func (manager *RoomManagerCtx) Rollouts(ctx context.Context) []types.RoomRollout {
+	manager.rolloutsMu.Lock()
+	rollouts := make([]*rollout, 0, len(manager.rollouts))
+	for _, r := range manager.rollouts {
+		rollouts = append(rollouts, r)
+	}
+	manager.rolloutsMu.Unlock()
+
+	sort.Slice(rollouts, func(i, j int) bool {
+		return rollouts[i].Created.Before(rollouts[j].Created)
+	})
+
+	result := make([]types.RoomRollout, len(rollouts))
+	for i, r := range rollouts {
+		result[i] = *manager.rolloutStatus(ctx, r)
+	}
+	return result
+}
+
+func (manager *RoomManagerCtx) GetRollout(ctx context.Context, id string) (*types.RoomRollout, error) {
+	r, err := manager.getRollout(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.rolloutStatus(ctx, r), nil
+}
+
+// PromoteRollout recreates all remaining rooms, that use the source image, with canary image.
+func (manager *RoomManagerCtx) PromoteRollout(ctx context.Context, id string) (*types.RoomRollout, error) {
+	r, err := manager.finishRollout(id, types.RoomRolloutPromoted)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := manager.List(ctx, r.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.NekoImage != r.FromImage {
+			continue
+		}
+
+		if _, err := manager.setRoomImage(ctx, entry.UUID, r.Image); err != nil {
+			manager.logger.Err(err).Str("name", entry.Name).Msg("rollout: failed to recreate room with promoted image")
+		}
+	}
+
+	return manager.rolloutStatus(ctx, r), nil
+}
+
+// RollbackRollout recreates canary rooms with the source image.
+func (manager *RoomManagerCtx) RollbackRollout(ctx context.Context, id string) (*types.RoomRollout, error) {
+	r, err := manager.finishRollout(id, types.RoomRolloutRolledBack)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, uuid := range r.canaries {
+		if _, err := manager.setRoomImage(ctx, uuid, r.FromImage); err != nil {
+			manager.logger.Err(err).Str("uuid", uuid).Msg("rollout: failed to recreate room with source image")
+		}
+	}
+
+	return manager.rolloutStatus(ctx, r), nil
+}
+
+func (manager *RoomManagerCtx) getRollout(id string) (*rollout, error) {
+	manager.rolloutsMu.Lock()
+	defer manager.rolloutsMu.Unlock()
+
+	r, ok := manager.rollouts[id]
+	if !ok {
+		return nil, types.ErrRolloutNotFound
+	}
+	return r, nil
+}
+
+// finishRollout changes status of canary rollout, so that it can not be finished twice.
+func (manager *RoomManagerCtx) finishRollout(id string, status types.RoomRolloutStatus) (*rollout, error) {
+	manager.rolloutsMu.Lock()
+	defer manager.rolloutsMu.Unlock()
+
+	r, ok := manager.rollouts[id]
+	if !ok {
+		return nil, types.ErrRolloutNotFound
+	}
+
+	if r.Status != types.RoomRolloutCanary {
+		return nil, fmt.Errorf("rollout is already %s", r.Status)
+	}
+
+	r.Status = status
+	return r, nil
+}
+
+// setRoomImage recreates room with given image, unless it already uses it.
+func (manager *RoomManagerCtx) setRoomImage(ctx context.Context, id string, image string) (string, error) {
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if entry.NekoImage == image {
+		return entry.ID, nil
+	}
+
+	return manager.recreate(ctx, id, func(settings *types.RoomSettings) {
+		settings.NekoImage = image
+	})
+}
+
+// rolloutStatus returns rollout with current health of canary rooms.
+func (manager *RoomManagerCtx) rolloutStatus(ctx context.Context, r *rollout) *types.RoomRollout {
+	manager.rolloutsMu.Lock()
+	result := r.RoomRollout
+	manager.rolloutsMu.Unlock()
+
+	result.Rooms = []types.RoomRolloutRoom{}
+	for _, uuid := range r.canaries {
+		room := types.RoomRolloutRoom{
+			UUID: uuid,
+		}
+
+		entry, err := manager.GetEntry(ctx, uuid)
+		if err != nil {
+			room.Error = err.Error()
+		} else {
+			room.ID = entry.ID
+			room.Name = entry.Name
+			room.NekoImage = entry.NekoImage
+			room.Running = entry.Running
+			room.IsReady = entry.IsReady
+			room.CrashLooping = entry.CrashLooping
+			room.OOMKills = manager.events.OOMKills(entry.UUID)
+		}
+
+		result.Rooms = append(result.Rooms, room)
+	}
+
+	return &result
+}
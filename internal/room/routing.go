@@ -0,0 +1,119 @@
+package room
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// routingLabels returns labels, that are used by traefik or internal proxy
+// to route traffic to the room, including custom labels from config.
+func (manager *RoomManagerCtx) routingLabels(containerName, roomName string, aliases []string, profile types.RoomProfile) map[string]string {
+	labels := map[string]string{}
+
+	frontendPort := profile.FrontendPort()
+	frontendScheme := profile.FrontendScheme()
+
+	pathPrefix := path.Join("/", manager.config.PathPrefix, roomName)
+
+	if t := manager.config.Traefik; t.Enabled {
+		labels["traefik.enable"] = "true"
+		labels["traefik.http.services."+containerName+"-frontend.loadbalancer.server.port"] = fmt.Sprintf("%d", frontendPort)
+		labels["traefik.http.services."+containerName+"-frontend.loadbalancer.server.scheme"] = frontendScheme
+
+		manager.traefikRouter(labels, containerName, containerName, roomName)
+
+		// every alias has its own router, pointing to the same service
+		for _, alias := range aliases {
+			manager.traefikRouter(labels, containerName+"-alias-"+alias, containerName, alias)
+		}
+	}
+
+	// internal proxy routes rooms without traefik, with traefik it only serves
+	// lobby of stopped rooms, that are started when visited
+	if t := manager.config.Traefik; !t.Enabled || manager.config.LazyStart {
+		labels["m1k1o.neko_rooms.proxy.enabled"] = strconv.FormatBool(!t.Enabled)
+		labels["m1k1o.neko_rooms.proxy.path"] = pathPrefix
+		labels["m1k1o.neko_rooms.proxy.port"] = fmt.Sprintf("%d", frontendPort)
+		labels["m1k1o.neko_rooms.proxy.scheme"] = frontendScheme
+
+		if len(aliases) > 0 {
+			aliasPaths := make([]string, len(aliases))
+			for i, alias := range aliases {
+				aliasPaths[i] = path.Join("/", manager.config.PathPrefix, alias)
+			}
+			labels["m1k1o.neko_rooms.proxy.aliases"] = strings.Join(aliasPaths, ",")
+		}
+	}
+
+	// add custom labels
+	for _, label := range manager.config.Labels {
+		// replace dynamic values in labels
+		label = strings.Replace(label, "{containerName}", containerName, -1)
+		label = strings.Replace(label, "{roomName}", roomName, -1)
+
+		if t := manager.config.Traefik; t.Enabled {
+			label = strings.Replace(label, "{traefikEntrypoint}", t.Entrypoint, -1)
+			label = strings.Replace(label, "{traefikCertresolver}", t.Certresolver, -1)
+		}
+
+		v := strings.SplitN(label, "=", 2)
+		if len(v) != 2 {
+			manager.logger.Warn().Str("label", label).Msg("invalid custom label")
+			continue
+		}
+
+		key, val := v[0], v[1]
+		labels[key] = val
+	}
+
+	return labels
+}
+
+// traefikRouter adds traefik router with its middlewares, that routes roomName
+// path (or subdomain) to the service of given container.
+func (manager *RoomManagerCtx) traefikRouter(labels map[string]string, routerName, containerName, roomName string) {
+	t := manager.config.Traefik
+
+	pathPrefix := path.Join("/", manager.config.PathPrefix, roomName)
+
+	// create traefik rule
+	traefikRule := "PathPrefix(`" + pathPrefix + "`)"
+	if t.Domain != "" && t.Domain != "*" {
+		// match *.domain.tld as subdomain
+		if strings.HasPrefix(t.Domain, "*.") {
+			traefikRule = fmt.Sprintf(
+				"Host(`%s.%s`)",
+				roomName,
+				strings.TrimPrefix(t.Domain, "*."),
+			)
+		} else {
+			traefikRule += " && Host(`" + t.Domain + "`)"
+		}
+	} else {
+		traefikRule += " && HostRegexp(`{host:.+}`)"
+	}
+
+	labels["traefik.http.routers."+routerName+".entrypoints"] = t.Entrypoint
+	labels["traefik.http.routers."+routerName+".rule"] = traefikRule
+	labels["traefik.http.middlewares."+routerName+"-rdr.redirectregex.regex"] = pathPrefix + "$$"
+	labels["traefik.http.middlewares."+routerName+"-rdr.redirectregex.replacement"] = pathPrefix + "/"
+	labels["traefik.http.middlewares."+routerName+"-prf.stripprefix.prefixes"] = pathPrefix + "/"
+	labels["traefik.http.routers."+routerName+".middlewares"] = routerName + "-rdr," + routerName + "-prf"
+	labels["traefik.http.routers."+routerName+".service"] = containerName + "-frontend"
+
+	// optional HTTPS
+	if t.Certresolver != "" {
+		labels["traefik.http.routers."+routerName+".tls"] = "true"
+		labels["traefik.http.routers."+routerName+".tls.certresolver"] = t.Certresolver
+	}
+}
+
+// isRoutingLabel returns true for labels managed by routingLabels
+func isRoutingLabel(key string) bool {
+	return strings.HasPrefix(key, "traefik.") ||
+		strings.HasPrefix(key, "m1k1o.neko_rooms.proxy.")
+}
@@ -0,0 +1,261 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+type alertCondition struct {
+	subject string // room name or path, empty for host
+	id      string
+	contact string
+	message string
+}
+
+type alertRules struct {
+	states   map[string]*types.RoomAlertState // by rule index and subject
+	notified map[string]time.Time             // last notification, survives resolved conditions
+	silences map[string]time.Time             // by rule kind and optional subject
+}
+
+// watchAlerts periodically evaluates configured alert rules.
+func (e *events) watchAlerts() {
+	if len(e.config.AlertRules) == 0 || e.config.AlertsIntervalSec <= 0 || e.onAlertsPoll == nil {
+		return
+	}
+
+	e.every(time.Duration(e.config.AlertsIntervalSec)*time.Second, func() { e.onAlertsPoll(e.ctx) })
+}
+
+func silenceKey(rule, subject string) string {
+	if subject == "" {
+		return rule
+	}
+	return rule + "/" + subject
+}
+
+// alertConditions returns subjects, for which condition of rule holds.
+func (manager *RoomManagerCtx) alertConditions(rule config.AlertRule, entries []types.RoomEntry) ([]alertCondition, error) {
+	conditions := []alertCondition{}
+
+	switch rule.Kind {
+	case config.AlertRuleRoomUnhealthy:
+		for _, entry := range entries {
+			if entry.Running && (!entry.IsReady || entry.Failed) {
+				conditions = append(conditions, alertCondition{
+					subject: entry.Name,
+					id:      entry.ID,
+					contact: entry.Contact,
+					message: "room is running, but it is not ready",
+				})
+			}
+		}
+	case config.AlertRuleCrashLoop:
+		for _, entry := range entries {
+			if entry.CrashLooping {
+				conditions = append(conditions, alertCondition{
+					subject: entry.Name,
+					id:      entry.ID,
+					contact: entry.Contact,
+					message: "room is crash looping",
+				})
+			}
+		}
+	case config.AlertRuleHostMemory:
+		usage, err := utils.MemoryUsage()
+		if err != nil {
+			return nil, err
+		}
+
+		if usage >= rule.Threshold {
+			conditions = append(conditions, alertCondition{
+				message: fmt.Sprintf("host memory usage is %.1f%%, above threshold %.0f%%", usage, rule.Threshold),
+			})
+		}
+	case config.AlertRuleDiskUsage:
+		for _, path := range manager.config.DiskPaths {
+			usage, err := utils.DiskUsage(path)
+			if err != nil {
+				return nil, err
+			}
+
+			if usage >= rule.Threshold {
+				conditions = append(conditions, alertCondition{
+					subject: path,
+					message: fmt.Sprintf("disk usage is %.1f%%, above threshold %.0f%%", usage, rule.Threshold),
+				})
+			}
+		}
+	}
+
+	return conditions, nil
+}
+
+// evaluateAlerts sends alert once per condition, that holds long enough, and once
+// when it is resolved. Silenced alerts and alerts within silence window are not sent.
+func (manager *RoomManagerCtx) evaluateAlerts(ctx context.Context) {
+	entries, err := manager.List(ctx, nil)
+	if err != nil {
+		manager.logger.Err(err).Msg("alerts: failed to list rooms")
+		return
+	}
+
+	now := time.Now()
+	alerts := []types.RoomAlert{}
+
+	manager.alertsMu.Lock()
+
+	for key, until := range manager.alerts.silences {
+		if now.After(until) {
+			delete(manager.alerts.silences, key)
+		}
+	}
+
+	active := map[string]struct{}{}
+	for i, rule := range manager.config.AlertRules {
+		conditions, err := manager.alertConditions(rule, entries)
+		if err != nil {
+			manager.logger.Err(err).Str("rule", rule.Kind).Msg("alerts: failed to evaluate rule")
+			continue
+		}
+
+		for _, condition := range conditions {
+			key := fmt.Sprintf("%d/%s", i, condition.subject)
+			active[key] = struct{}{}
+
+			state, ok := manager.alerts.states[key]
+			if !ok {
+				state = &types.RoomAlertState{
+					Rule:    rule.Kind,
+					Subject: condition.subject,
+					Since:   now,
+				}
+				manager.alerts.states[key] = state
+			}
+
+			state.ID = condition.id
+			state.Message = condition.message
+			state.Firing = now.Sub(state.Since) >= time.Duration(rule.ForSec)*time.Second
+			state.SilencedUntil = manager.silencedUntil(rule.Kind, condition.subject)
+
+			// deduplicate, notify only once
+			if !state.Firing || state.Notified != nil || state.SilencedUntil != nil {
+				continue
+			}
+
+			// within silence window after last notification
+			if last, ok := manager.alerts.notified[key]; ok && now.Sub(last) < time.Duration(rule.SilenceSec)*time.Second {
+				continue
+			}
+
+			notified := now
+			state.Notified = &notified
+			manager.alerts.notified[key] = now
+
+			alerts = append(alerts, types.RoomAlert{
+				Type:    types.RoomAlertRule,
+				Rule:    rule.Kind,
+				ID:      condition.id,
+				Name:    condition.subject,
+				Time:    now,
+				Message: condition.message,
+				Contact: condition.contact,
+			})
+		}
+	}
+
+	// resolved conditions
+	for key, state := range manager.alerts.states {
+		if _, ok := active[key]; ok {
+			continue
+		}
+
+		delete(manager.alerts.states, key)
+
+		if state.Notified == nil || manager.silencedUntil(state.Rule, state.Subject) != nil {
+			continue
+		}
+
+		alerts = append(alerts, types.RoomAlert{
+			Type:     types.RoomAlertRule,
+			Rule:     state.Rule,
+			ID:       state.ID,
+			Name:     state.Subject,
+			Time:     now,
+			Message:  "resolved: " + state.Message,
+			Resolved: true,
+		})
+	}
+
+	manager.alertsMu.Unlock()
+
+	for _, alert := range alerts {
+		manager.events.sendAlert(alert)
+	}
+}
+
+// silencedUntil must be called with alertsMu held.
+func (manager *RoomManagerCtx) silencedUntil(rule, subject string) *time.Time {
+	var result *time.Time
+	for _, key := range []string{silenceKey(rule, ""), silenceKey(rule, subject)} {
+		if until, ok := manager.alerts.silences[key]; ok && (result == nil || until.After(*result)) {
+			result = &until
+		}
+	}
+	return result
+}
+
+// Alerts returns conditions of alert rules, that currently hold.
+func (manager *RoomManagerCtx) Alerts() []types.RoomAlertState {
+	manager.alertsMu.Lock()
+	defer manager.alertsMu.Unlock()
+
+	result := []types.RoomAlertState{}
+	for _, state := range manager.alerts.states {
+		result = append(result, *state)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Since.Before(result[j].Since)
+	})
+
+	return result
+}
+
+func (manager *RoomManagerCtx) SilenceAlert(silence types.RoomAlertSilence) error {
+	if silence.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+
+	found := false
+	for _, rule := range manager.config.AlertRules {
+		if rule.Kind == silence.Rule {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("alert rule %q is not configured", silence.Rule)
+	}
+
+	manager.alertsMu.Lock()
+	defer manager.alertsMu.Unlock()
+
+	until := time.Now().Add(time.Duration(silence.Duration) * time.Second)
+	manager.alerts.silences[silenceKey(silence.Rule, silence.Subject)] = until
+
+	// reflect in current states
+	for _, state := range manager.alerts.states {
+		if state.Rule == silence.Rule {
+			state.SilencedUntil = manager.silencedUntil(state.Rule, state.Subject)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,349 @@
+package room
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	snapshotLabel       = "m1k1o.neko_rooms.snapshot" // instance name
+	snapshotRoomLabel   = "m1k1o.neko_rooms.snapshot.room"
+	snapshotSourceLabel = "m1k1o.neko_rooms.snapshot.source"
+)
+
+var snapshotTagRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+
+// dockerfileQuote quotes value, so that it is not expanded in dockerfile instruction.
+func dockerfileQuote(val string) (string, error) {
+	if strings.ContainsAny(val, "\r\n") {
+		return "", fmt.Errorf("multiline values are not supported")
+	}
+
+	val = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(val)
+	return `"` + val + `"`, nil
+}
+
+// snapshotChanges returns dockerfile instructions, that restore config of source
+// image. Config of the room container is not used, because it contains labels and
+// envs of the room (routing, passwords), that must not end up in new rooms.
+func snapshotChanges(config *container.Config, labels map[string]string) ([]string, error) {
+	changes := []string{}
+
+	for _, env := range config.Env {
+		key, val, _ := strings.Cut(env, "=")
+		quoted, err := dockerfileQuote(val)
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", key, err)
+		}
+		changes = append(changes, "ENV "+key+"="+quoted)
+	}
+
+	for key, val := range labels {
+		quotedKey, err := dockerfileQuote(key)
+		if err != nil {
+			return nil, fmt.Errorf("label %s: %w", key, err)
+		}
+		quotedVal, err := dockerfileQuote(val)
+		if err != nil {
+			return nil, fmt.Errorf("label %s: %w", key, err)
+		}
+		changes = append(changes, "LABEL "+quotedKey+"="+quotedVal)
+	}
+
+	if len(config.Entrypoint) > 0 {
+		data, _ := json.Marshal(config.Entrypoint)
+		changes = append(changes, "ENTRYPOINT "+string(data))
+	}
+
+	if len(config.Cmd) > 0 {
+		data, _ := json.Marshal(config.Cmd)
+		changes = append(changes, "CMD "+string(data))
+	}
+
+	if config.WorkingDir != "" {
+		changes = append(changes, "WORKDIR "+config.WorkingDir)
+	}
+
+	if config.User != "" {
+		changes = append(changes, "USER "+config.User)
+	}
+
+	if config.StopSignal != "" {
+		changes = append(changes, "STOPSIGNAL "+config.StopSignal)
+	}
+
+	for port := range config.ExposedPorts {
+		changes = append(changes, "EXPOSE "+string(port))
+	}
+
+	if len(config.Volumes) > 0 {
+		volumes := []string{}
+		for volume := range config.Volumes {
+			volumes = append(volumes, volume)
+		}
+		sort.Strings(volumes)
+
+		data, _ := json.Marshal(volumes)
+		changes = append(changes, "VOLUME "+string(data))
+	}
+
+	// readiness of rooms depends on healthcheck
+	if hc := config.Healthcheck; hc != nil && len(hc.Test) > 0 {
+		opts := ""
+		if hc.Interval > 0 {
+			opts += " --interval=" + hc.Interval.String()
+		}
+		if hc.Timeout > 0 {
+			opts += " --timeout=" + hc.Timeout.String()
+		}
+		if hc.StartPeriod > 0 {
+			opts += " --start-period=" + hc.StartPeriod.String()
+		}
+		if hc.Retries > 0 {
+			opts += fmt.Sprintf(" --retries=%d", hc.Retries)
+		}
+
+		switch hc.Test[0] {
+		case "NONE":
+			changes = append(changes, "HEALTHCHECK NONE")
+		case "CMD":
+			data, _ := json.Marshal(hc.Test[1:])
+			changes = append(changes, "HEALTHCHECK"+opts+" CMD "+string(data))
+		case "CMD-SHELL":
+			changes = append(changes, "HEALTHCHECK"+opts+" CMD "+strings.Join(hc.Test[1:], " "))
+		}
+	}
+
+	return changes, nil
+}
+
+func (manager *RoomManagerCtx) snapshotImage(tag string) (string, error) {
+	if !snapshotTagRegex.MatchString(tag) {
+		return "", fmt.Errorf("invalid snapshot tag, allowed characters: [a-zA-Z0-9_.-]")
+	}
+
+	return manager.config.SnapshotsRepository + ":" + tag, nil
+}
+
+// snapshotSource returns neko image of room, that snapshot was taken from.
+func (manager *RoomManagerCtx) snapshotSource(ctx context.Context, image string) (string, bool) {
+	if !strings.HasPrefix(image, manager.config.SnapshotsRepository+":") {
+		return "", false
+	}
+
+	inspect, _, err := manager.client.ImageInspectWithRaw(ctx, image)
+	if err != nil || inspect.Config == nil || inspect.Config.Labels[snapshotLabel] != manager.config.InstanceName {
+		return "", false
+	}
+
+	source, ok := inspect.Config.Labels[snapshotSourceLabel]
+	return source, ok
+}
+
+func (manager *RoomManagerCtx) getSnapshot(ctx context.Context, image string) (*types.RoomSnapshot, error) {
+	inspect, _, err := manager.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		if dockerClient.IsErrNotFound(err) {
+			return nil, types.ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+
+	// only snapshots of this instance are managed
+	if inspect.Config == nil || inspect.Config.Labels[snapshotLabel] != manager.config.InstanceName {
+		return nil, types.ErrSnapshotNotFound
+	}
+
+	created, _ := time.Parse(time.RFC3339Nano, inspect.Created)
+
+	return &types.RoomSnapshot{
+		Image:   image,
+		ID:      strings.TrimPrefix(inspect.ID, "sha256:")[:12],
+		Room:    inspect.Config.Labels[snapshotRoomLabel],
+		Source:  inspect.Config.Labels[snapshotSourceLabel],
+		Size:    inspect.Size,
+		Created: created,
+	}, nil
+}
+
+func (manager *RoomManagerCtx) ListSnapshots(ctx context.Context) ([]types.RoomSnapshot, error) {
+	images, err := manager.client.ImageList(ctx, dockerTypes.ImageListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", snapshotLabel+"="+manager.config.InstanceName)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []types.RoomSnapshot{}
+	for _, image := range images {
+		for _, ref := range image.RepoTags {
+			if !strings.HasPrefix(ref, manager.config.SnapshotsRepository+":") {
+				continue
+			}
+
+			snapshots = append(snapshots, types.RoomSnapshot{
+				Image:   ref,
+				ID:      strings.TrimPrefix(image.ID, "sha256:")[:12],
+				Room:    image.Labels[snapshotRoomLabel],
+				Source:  image.Labels[snapshotSourceLabel],
+				Size:    image.Size,
+				Created: time.Unix(image.Created, 0),
+			})
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+
+	return snapshots, nil
+}
+
+// Snapshot saves filesystem of room container as new image, that can be used
+// as neko image of new rooms. Running room is paused while it is saved.
+func (manager *RoomManagerCtx) Snapshot(ctx context.Context, id string, request types.RoomSnapshotRequest) (*types.RoomSnapshot, error) {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	containerJson, err := manager.inspectContainer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := manager.extractLabels(containerJson.Config.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := request.Tag
+	if tag == "" {
+		tag = fmt.Sprintf("%s-%s", labels.Name, time.Now().UTC().Format("20060102-150405"))
+	}
+
+	image, err := manager.snapshotImage(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := manager.client.ImageInspectWithRaw(ctx, image); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", tag)
+	}
+
+	// snapshot of snapshot keeps original source image
+	source := labels.NekoImage
+	if original, ok := manager.snapshotSource(ctx, source); ok {
+		source = original
+	}
+
+	sourceImage, _, err := manager.client.ImageInspectWithRaw(ctx, containerJson.Image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image of room: %w", err)
+	}
+
+	imageLabels := map[string]string{}
+	for key, val := range sourceImage.Config.Labels {
+		imageLabels[key] = val
+	}
+	imageLabels[snapshotLabel] = manager.config.InstanceName
+	imageLabels[snapshotRoomLabel] = labels.Name
+	imageLabels[snapshotSourceLabel] = source
+
+	changes, err := snapshotChanges(sourceImage.Config, imageLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	// wait for other heavy docker operations
+	if err := manager.dockerOps.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer manager.dockerOps.Release()
+
+	// filesystem must not be changed while it is saved
+	if containerJson.State.Running && !containerJson.State.Paused {
+		if err := manager.client.ContainerPause(ctx, id); err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err := manager.client.ContainerUnpause(context.Background(), id); err != nil {
+				manager.logger.Err(err).Str("id", id).Msg("unable to unpause room after snapshot")
+			}
+		}()
+	}
+
+	export, err := manager.client.ContainerExport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer export.Close()
+
+	reader, err := manager.client.ImageImport(ctx, dockerTypes.ImageImportSource{
+		Source:     export,
+		SourceName: "-",
+	}, image, dockerTypes.ImageImportOptions{
+		Message: fmt.Sprintf("snapshot of room %s", labels.Name),
+		Changes: changes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	// import is finished when reader is closed, errors are reported in the stream
+	decoder := json.NewDecoder(reader)
+	for {
+		var message struct {
+			Error string `json:"error"`
+		}
+
+		if err := decoder.Decode(&message); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if message.Error != "" {
+			return nil, fmt.Errorf("failed to import snapshot: %s", message.Error)
+		}
+	}
+
+	manager.logger.Info().Str("id", id).Str("image", image).Msg("room snapshot created")
+	return manager.getSnapshot(ctx, image)
+}
+
+func (manager *RoomManagerCtx) RemoveSnapshot(ctx context.Context, tag string) error {
+	image, err := manager.snapshotImage(tag)
+	if err != nil {
+		return err
+	}
+
+	if _, err := manager.getSnapshot(ctx, image); err != nil {
+		return err
+	}
+
+	_, err = manager.client.ImageRemove(ctx, image, dockerTypes.ImageRemoveOptions{
+		PruneChildren: true,
+	})
+	if errdefs.IsConflict(err) {
+		return types.ErrSnapshotInUse
+	}
+
+	return err
+}
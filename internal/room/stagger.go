@@ -0,0 +1,125 @@
+package room
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// startScheduler spreads room starts over time, so that many rooms started
+// at once do not cause CPU spikes in already running rooms. Waiting rooms with
+// higher priority are started before rooms with lower priority.
+type startScheduler struct {
+	stagger time.Duration
+	jitter  time.Duration
+
+	mu      sync.Mutex
+	next    time.Time
+	waiting map[types.RoomPriority]int
+	changed chan struct{} // closed when slot is taken
+}
+
+func newStartScheduler(config *config.Room) *startScheduler {
+	return &startScheduler{
+		stagger: time.Duration(config.StartStaggerMs) * time.Millisecond,
+		jitter:  time.Duration(config.StartJitterMs) * time.Millisecond,
+
+		waiting: map[types.RoomPriority]int{},
+		changed: make(chan struct{}),
+	}
+}
+
+func priorityRank(priority types.RoomPriority) int {
+	switch priority {
+	case types.RoomPriorityHigh:
+		return 0
+	case types.RoomPriorityLow:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// preceded returns true, if there is room with higher priority waiting.
+func (s *startScheduler) preceded(priority types.RoomPriority) bool {
+	rank := priorityRank(priority)
+	for p, count := range s.waiting {
+		if count > 0 && priorityRank(p) < rank {
+			return true
+		}
+	}
+	return false
+}
+
+// wait blocks until the room can be started.
+func (s *startScheduler) wait(ctx context.Context, priority types.RoomPriority) error {
+	if s.stagger <= 0 && s.jitter <= 0 {
+		return nil
+	}
+
+	if priority == "" {
+		priority = types.RoomPriorityNormal
+	}
+
+	s.mu.Lock()
+	s.waiting[priority]++
+	defer func() {
+		s.waiting[priority]--
+		s.mu.Unlock()
+	}()
+
+	for {
+		now := time.Now()
+		preceded := s.preceded(priority)
+		if !preceded && !now.Before(s.next) {
+			delay := s.stagger
+			if s.jitter > 0 {
+				delay += time.Duration(rand.Int63n(int64(s.jitter)))
+			}
+			s.next = now.Add(delay)
+			s.notify()
+			return nil
+		}
+
+		// when preceded, wait until room with higher priority takes the slot
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !preceded {
+			timer = time.NewTimer(s.next.Sub(now))
+			timeout = timer.C
+		}
+
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+
+			s.mu.Lock()
+			// waiting rooms with lower priority might be preceded by this one
+			s.notify()
+			return ctx.Err()
+		case <-changed:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		s.mu.Lock()
+	}
+}
+
+// notify wakes up all waiting rooms, so that they can re-evaluate their turn.
+func (s *startScheduler) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
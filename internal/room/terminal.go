@@ -0,0 +1,132 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// terminal recordings are stored by room uuid, so that they survive recreates
+const terminalStoragePath = "./terminal"
+
+// terminalRecording creates file, where output of terminal session is recorded.
+func (manager *RoomManagerCtx) terminalRecording(roomUUID string, actor string) (*os.File, error) {
+	dir := path.Join(manager.config.StorageInternal, terminalStoragePath, roomUUID)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s.log", time.Now().UTC().Format("20060102-150405"))
+	file, err := os.OpenFile(path.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(file, "# terminal session of %s started at %s\n", actor, time.Now().Format(time.RFC3339))
+	return file, nil
+}
+
+// Terminal attaches session to interactive shell inside the room, until the shell
+// exits or input of the session ends.
+func (manager *RoomManagerCtx) Terminal(ctx context.Context, id string, session types.RoomTerminalSession) error {
+	if !manager.config.TerminalEnabled {
+		return types.ErrTerminalDisabled
+	}
+
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !entry.Running {
+		return fmt.Errorf("room is not running")
+	}
+
+	exec, err := manager.client.ContainerExecCreate(ctx, entry.ID, dockerTypes.ExecConfig{
+		AttachStdin:  true,
+		AttachStderr: true,
+		AttachStdout: true,
+		Tty:          true,
+		Env:          []string{"TERM=xterm-256color"},
+		Cmd:          []string{manager.config.TerminalShell},
+	})
+	if err != nil {
+		if dockerClient.IsErrNotFound(err) {
+			return types.ErrRoomNotFound
+		}
+		return err
+	}
+
+	conn, err := manager.client.ContainerExecAttach(ctx, exec.ID, dockerTypes.ExecStartCheck{
+		Tty: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	logger := manager.logger.With().Str("id", entry.ID).Str("actor", session.Actor).Logger()
+
+	stdout := session.Stdout
+	if manager.config.TerminalRecord && manager.config.StorageEnabled {
+		recording, err := manager.terminalRecording(entry.UUID, session.Actor)
+		if err != nil {
+			return err
+		}
+		defer recording.Close()
+
+		logger = logger.With().Str("recording", recording.Name()).Logger()
+		stdout = io.MultiWriter(stdout, recording)
+	}
+
+	logger.Info().Msg("terminal: session started")
+	defer logger.Info().Msg("terminal: session ended")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// when input ends, client is gone and shell is detached
+	go func() {
+		io.Copy(conn.Conn, session.Stdin)
+		conn.Close()
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case size, ok := <-session.Resize:
+				if !ok {
+					return
+				}
+
+				err := manager.client.ContainerExecResize(ctx, exec.ID, dockerTypes.ResizeOptions{
+					Height: size.Rows,
+					Width:  size.Cols,
+				})
+				if err != nil {
+					logger.Debug().Err(err).Msg("terminal: failed to resize")
+				}
+			}
+		}
+	}()
+
+	_, err = io.Copy(stdout, conn.Reader)
+	if err != nil && (errors.Is(err, net.ErrClosed) || ctx.Err() != nil) {
+		// connection was closed, because session ended
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,289 @@
+package room
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	tieringMemoryStep = 64 * 1024 * 1024 // new memory limits are rounded up to this
+	tieringCPUStep    = 10000000         // new cpu limits are rounded up to 0.01 cpu
+)
+
+// usage samples of a room, by its uuid
+type tieringState struct {
+	lastCPU  uint64
+	lastRead time.Time
+
+	memory []int64
+	cpus   []int64
+
+	suggestion *types.RoomTiering // last suggested or applied limits
+}
+
+// watchTiering periodically samples resource usage of running rooms.
+func (e *events) watchTiering() {
+	if e.config.TieringMode == "off" || e.config.TieringIntervalSec <= 0 || e.onTieringPoll == nil {
+		return
+	}
+
+	e.every(time.Duration(e.config.TieringIntervalSec)*time.Second, func() { e.onTieringPoll(e.ctx) })
+}
+
+// tieringLimit returns new limit for current limit and peak usage, or current
+// limit, when difference is within hysteresis. Unlimited resources stay unlimited.
+func tieringLimit(current, peak int64, headroom, hysteresis float64, min, step int64) int64 {
+	if current <= 0 {
+		return current
+	}
+
+	limit := int64(math.Ceil(float64(peak)*headroom/float64(step))) * step
+	if limit < min {
+		limit = min
+	}
+
+	if math.Abs(float64(limit-current)) <= float64(current)*hysteresis {
+		return current
+	}
+
+	return limit
+}
+
+func maxSample(samples []int64) int64 {
+	var max int64
+	for _, sample := range samples {
+		if sample > max {
+			max = sample
+		}
+	}
+	return max
+}
+
+// sampleUsage returns memory usage without page cache and cpu time of a container.
+func (manager *RoomManagerCtx) sampleUsage(ctx context.Context, id string) (memory int64, cpu uint64, read time.Time, err error) {
+	res, err := manager.client.ContainerStatsOneShot(ctx, id)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	var stats dockerTypes.StatsJSON
+	if err = json.NewDecoder(res.Body).Decode(&stats); err != nil {
+		return
+	}
+
+	memory = int64(stats.MemoryStats.Usage)
+	// same as docker cli, cgroup v1 and v2
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if cache, ok := stats.MemoryStats.Stats[key]; ok && int64(cache) < memory {
+			memory -= int64(cache)
+			break
+		}
+	}
+
+	return memory, stats.CPUStats.CPUUsage.TotalUsage, stats.Read, nil
+}
+
+func (manager *RoomManagerCtx) pollTiering(ctx context.Context) {
+	entries, err := manager.List(ctx, nil)
+	if err != nil {
+		manager.logger.Err(err).Msg("tiering: failed to list rooms")
+		return
+	}
+
+	seen := map[string]struct{}{}
+	for _, entry := range entries {
+		if !entry.Running {
+			continue
+		}
+
+		// stop early on shutdown
+		if ctx.Err() != nil {
+			return
+		}
+
+		seen[entry.UUID] = struct{}{}
+		manager.sampleTiering(ctx, entry)
+	}
+
+	// forget rooms, that are not running anymore
+	manager.tieringMu.Lock()
+	for uuid := range manager.tiering {
+		if _, ok := seen[uuid]; !ok {
+			delete(manager.tiering, uuid)
+		}
+	}
+	manager.tieringMu.Unlock()
+}
+
+func (manager *RoomManagerCtx) sampleTiering(ctx context.Context, entry types.RoomEntry) {
+	logger := manager.logger.With().Str("id", entry.ID).Str("name", entry.Name).Logger()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	containerJson, err := manager.inspectContainer(ctx, entry.ID)
+	if err != nil {
+		logger.Debug().Err(err).Msg("tiering: failed to inspect room")
+		return
+	}
+
+	// rooms without limits are not right-sized
+	currentMemory := containerJson.HostConfig.Memory
+	currentNanoCPUs := containerJson.HostConfig.NanoCPUs
+	if currentMemory <= 0 && currentNanoCPUs <= 0 {
+		return
+	}
+
+	memory, cpu, read, err := manager.sampleUsage(ctx, entry.ID)
+	if err != nil {
+		logger.Debug().Err(err).Msg("tiering: failed to get room stats")
+		return
+	}
+
+	manager.tieringMu.Lock()
+	state, ok := manager.tiering[entry.UUID]
+	if !ok {
+		state = &tieringState{}
+		manager.tiering[entry.UUID] = state
+	}
+
+	// cpu usage is averaged between two samples, counter resets on restart
+	if !state.lastRead.IsZero() && read.After(state.lastRead) && cpu >= state.lastCPU {
+		nanoCPUs := float64(cpu-state.lastCPU) / float64(read.Sub(state.lastRead)) * 1e9
+		state.cpus = append(state.cpus, int64(nanoCPUs))
+		state.memory = append(state.memory, memory)
+	}
+	state.lastCPU, state.lastRead = cpu, read
+
+	// sliding window
+	window := manager.config.TieringWindow
+	if len(state.memory) > window {
+		state.memory = state.memory[len(state.memory)-window:]
+		state.cpus = state.cpus[len(state.cpus)-window:]
+	}
+
+	if len(state.memory) < window {
+		manager.tieringMu.Unlock()
+		return
+	}
+
+	tiering := types.RoomTiering{
+		ID:      entry.ID,
+		Name:    entry.Name,
+		Contact: entry.Contact,
+
+		Memory:       currentMemory,
+		NanoCPUs:     currentNanoCPUs,
+		PeakMemory:   maxSample(state.memory),
+		PeakNanoCPUs: maxSample(state.cpus),
+
+		Time: time.Now(),
+	}
+
+	tiering.NewMemory = tieringLimit(currentMemory, tiering.PeakMemory,
+		manager.config.TieringHeadroom, manager.config.TieringHysteresis, manager.config.TieringMinMemory, tieringMemoryStep)
+	tiering.NewNanoCPUs = tieringLimit(currentNanoCPUs, tiering.PeakNanoCPUs,
+		manager.config.TieringHeadroom, manager.config.TieringHysteresis, manager.config.TieringMinNanoCPUs, tieringCPUStep)
+
+	// limits are right-sized
+	if tiering.NewMemory == currentMemory && tiering.NewNanoCPUs == currentNanoCPUs {
+		state.suggestion = nil
+		manager.tieringMu.Unlock()
+		return
+	}
+
+	// notify only once about the same suggestion
+	if manager.config.TieringMode == "suggest" {
+		last := state.suggestion
+		if last != nil && last.NewMemory == tiering.NewMemory && last.NewNanoCPUs == tiering.NewNanoCPUs {
+			manager.tieringMu.Unlock()
+			return
+		}
+
+		state.suggestion = &tiering
+		manager.tieringMu.Unlock()
+
+		manager.events.sendAlert(types.RoomAlert{
+			Type:    types.RoomAlertTiering,
+			ID:      entry.ID,
+			Name:    entry.Name,
+			Time:    tiering.Time,
+			Message: fmt.Sprintf("room resource limits should be changed: %s", tieringDescribe(tiering)),
+			Contact: entry.Contact,
+		})
+		return
+	}
+
+	// after change, full window with new limits is required
+	state.memory, state.cpus = nil, nil
+	state.suggestion = nil
+	manager.tieringMu.Unlock()
+
+	resources := container.Resources{
+		Memory:   tiering.NewMemory,
+		NanoCPUs: tiering.NewNanoCPUs,
+	}
+
+	// swap must not be lower than memory, keep the same amount of swap
+	if swap := containerJson.HostConfig.MemorySwap; swap > 0 && tiering.NewMemory != currentMemory {
+		resources.MemorySwap = tiering.NewMemory + swap - currentMemory
+	}
+
+	_, err = manager.client.ContainerUpdate(ctx, entry.ID, container.UpdateConfig{
+		Resources: resources,
+	})
+	if err != nil {
+		logger.Err(err).Msg("tiering: failed to update room resources")
+		return
+	}
+
+	tiering.Applied = true
+
+	manager.tieringMu.Lock()
+	state.suggestion = &tiering
+	manager.tieringMu.Unlock()
+
+	manager.events.sendAlert(types.RoomAlert{
+		Type:    types.RoomAlertTiering,
+		ID:      entry.ID,
+		Name:    entry.Name,
+		Time:    tiering.Time,
+		Message: fmt.Sprintf("room resource limits were changed: %s", tieringDescribe(tiering)),
+		Contact: entry.Contact,
+	})
+}
+
+func tieringDescribe(tiering types.RoomTiering) string {
+	return fmt.Sprintf("memory %d MiB -> %d MiB (peak %d MiB), cpus %.2f -> %.2f (peak %.2f)",
+		tiering.Memory/1024/1024, tiering.NewMemory/1024/1024, tiering.PeakMemory/1024/1024,
+		float64(tiering.NanoCPUs)/1e9, float64(tiering.NewNanoCPUs)/1e9, float64(tiering.PeakNanoCPUs)/1e9)
+}
+
+// Tiering returns last suggested or applied limits of running rooms.
+func (manager *RoomManagerCtx) Tiering() []types.RoomTiering {
+	manager.tieringMu.Lock()
+	defer manager.tieringMu.Unlock()
+
+	result := []types.RoomTiering{}
+	for _, state := range manager.tiering {
+		if state.suggestion != nil {
+			result = append(result, *state.suggestion)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
@@ -0,0 +1,116 @@
+package room
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+var transferProfileEnvsV3 = []string{
+	"NEKO_MEMBER_MULTIUSER_USER_PROFILE",
+	"NEKO_MEMBER_MULTIUSER_ADMIN_PROFILE",
+}
+
+// effectiveTransfer returns transfer policy of the room, missing values are taken from config.
+func (manager *RoomManagerCtx) effectiveTransfer(settings types.RoomSettings) TransferLabels {
+	transfer := TransferLabels{
+		Clipboard: manager.config.TransferClipboard,
+		Files:     manager.config.TransferFiles,
+	}
+
+	if settings.Transfer != nil {
+		if settings.Transfer.Clipboard != nil {
+			transfer.Clipboard = *settings.Transfer.Clipboard
+		}
+		if settings.Transfer.Files != nil {
+			transfer.Files = *settings.Transfer.Files
+		}
+	}
+
+	return transfer
+}
+
+// transferEnvs returns copy of envs with transfer policy enforced, overriding
+// any values that were set by user.
+func transferEnvs(apiVersion int, transfer TransferLabels, envs map[string]string) (map[string]string, error) {
+	result := map[string]string{}
+	for key, val := range envs {
+		result[key] = val
+	}
+
+	switch apiVersion {
+	case 2:
+		if !transfer.Clipboard {
+			return nil, fmt.Errorf("clipboard cannot be disabled in neko api version 2")
+		}
+
+		result["NEKO_FILE_TRANSFER_ENABLED"] = strconv.FormatBool(transfer.Files)
+	case 3:
+		result["NEKO_FILETRANSFER_ENABLED"] = strconv.FormatBool(transfer.Files)
+
+		// clipboard access is part of member profile, other profile values are kept
+		for _, key := range transferProfileEnvsV3 {
+			profile := map[string]any{}
+			if val, ok := result[key]; ok && val != "" {
+				if err := json.Unmarshal([]byte(val), &profile); err != nil {
+					return nil, fmt.Errorf("invalid %s: %w", key, err)
+				}
+			}
+
+			profile["can_access_clipboard"] = transfer.Clipboard
+
+			// error can be ignored, because map decoded from json is always serializable
+			data, _ := json.Marshal(profile)
+			result[key] = string(data)
+		}
+	default:
+		return nil, fmt.Errorf("transfer policy is not supported for api version %d", apiVersion)
+	}
+
+	return result, nil
+}
+
+// extractTransfer removes environment variables managed by transfer policy from settings
+// envs. When policy matches global policy, it is omitted, so that the room follows it.
+func (manager *RoomManagerCtx) extractTransfer(settings *types.RoomSettings, labels *RoomLabels) {
+	if labels.Transfer == nil {
+		return
+	}
+
+	switch labels.ApiVersion {
+	case 2:
+		delete(settings.Envs, "NEKO_FILE_TRANSFER_ENABLED")
+	case 3:
+		delete(settings.Envs, "NEKO_FILETRANSFER_ENABLED")
+
+		for _, key := range transferProfileEnvsV3 {
+			profile := map[string]any{}
+			if err := json.Unmarshal([]byte(settings.Envs[key]), &profile); err != nil {
+				continue
+			}
+
+			delete(profile, "can_access_clipboard")
+			if len(profile) == 0 {
+				delete(settings.Envs, key)
+				continue
+			}
+
+			data, _ := json.Marshal(profile)
+			settings.Envs[key] = string(data)
+		}
+	}
+
+	transfer := types.RoomTransfer{}
+	if labels.Transfer.Clipboard != manager.config.TransferClipboard {
+		transfer.Clipboard = &labels.Transfer.Clipboard
+	}
+	if labels.Transfer.Files != manager.config.TransferFiles {
+		transfer.Files = &labels.Transfer.Files
+	}
+
+	if transfer.Clipboard != nil || transfer.Files != nil {
+		settings.Transfer = &transfer
+	}
+}
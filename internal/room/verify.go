@@ -0,0 +1,230 @@
+package room
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// verifyArchiveFile performs restore drill of archive: its settings must survive
+// round-trip and its storage must be extractable. Nothing is created in docker.
+func verifyArchiveFile(archivePath string) types.RoomArchiveVerification {
+	result := types.RoomArchiveVerification{
+		Archive:  path.Base(archivePath),
+		Verified: time.Now(),
+		Errors:   []string{},
+	}
+
+	if err := verifyArchive(archivePath, &result); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+func verifyArchive(archivePath string, result *types.RoomArchiveVerification) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("damaged archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil {
+		return fmt.Errorf("damaged archive: %w", err)
+	}
+
+	if header.Name != archiveSettingsFile {
+		return fmt.Errorf("damaged archive: %s not found", archiveSettingsFile)
+	}
+
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return fmt.Errorf("damaged archive: %w", err)
+	}
+
+	// settings written by other version might contain unknown fields, that would be lost
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var settings types.RoomSettings
+	if err := dec.Decode(&settings); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("settings can not be restored: %v", err))
+
+		// continue with lenient decoding, so that storage is verified as well
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("damaged archive: %w", err)
+		}
+	}
+
+	if settings.Name == "" {
+		result.Errors = append(result.Errors, "settings do not contain room name")
+	}
+
+	if settings.NekoImage == "" {
+		result.Errors = append(result.Errors, "settings do not contain neko image")
+	}
+
+	roundTrip, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	var restored types.RoomSettings
+	if err := json.Unmarshal(roundTrip, &restored); err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(settings, restored) {
+		result.Errors = append(result.Errors, "settings do not survive round-trip")
+	}
+
+	result.Room = settings.Name
+
+	// extract storage to temporary folder, same as restore would do
+	tmp, err := os.MkdirTemp("", "neko-rooms-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := utils.ExtractTarEntries(tr, tmp, archivePrivatePrefix); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unable to extract archive: %w", err)
+	}
+
+	return filepath.Walk(tmp, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			result.Files++
+			result.Size += info.Size()
+		}
+		return nil
+	})
+}
+
+func (manager *RoomManagerCtx) VerifyArchive(name string) (*types.RoomArchiveVerification, error) {
+	if !manager.config.StorageEnabled {
+		return nil, fmt.Errorf("archives cannot be verified, because storage is disabled or unavailable")
+	}
+
+	archivePath, err := manager.archivePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(archivePath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("archive not found")
+		}
+		return nil, err
+	}
+
+	result := verifyArchiveFile(archivePath)
+	manager.events.recordArchiveVerification(result)
+	return &result, nil
+}
+
+// watchArchives periodically verifies all archives.
+func (e *events) watchArchives() {
+	if e.config.ArchivesVerifyIntervalSec <= 0 || !e.config.StorageEnabled {
+		return
+	}
+
+	e.every(time.Duration(e.config.ArchivesVerifyIntervalSec)*time.Second, e.verifyArchives)
+}
+
+func (e *events) verifyArchives() {
+	dir := path.Join(e.config.StorageInternal, archivesStoragePath)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			e.logger.Err(err).Msg("archives: failed to list archives")
+		}
+		return
+	}
+
+	results := map[string]types.RoomArchiveVerification{}
+	damaged := 0
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), archiveFileNameSuffix) {
+			continue
+		}
+
+		// stop early on shutdown, verification can take long
+		if e.ctx.Err() != nil {
+			return
+		}
+
+		result := verifyArchiveFile(path.Join(dir, file.Name()))
+		results[result.Archive] = result
+
+		if !result.Valid {
+			damaged++
+			e.logger.Warn().
+				Str("archive", result.Archive).
+				Strs("errors", result.Errors).
+				Msg("archives: verification failed")
+		}
+	}
+
+	// results of removed archives are forgotten
+	e.archiveResultsMu.Lock()
+	e.archiveResults = results
+	e.archiveResultsMu.Unlock()
+
+	e.archivesTotal.Set(float64(len(results)))
+	e.archivesDamaged.Set(float64(damaged))
+	e.archivesVerified.SetToCurrentTime()
+
+	e.logger.Info().Int("total", len(results)).Int("damaged", damaged).Msg("archives: verification finished")
+}
+
+func (e *events) recordArchiveVerification(result types.RoomArchiveVerification) {
+	e.archiveResultsMu.Lock()
+	defer e.archiveResultsMu.Unlock()
+
+	e.archiveResults[result.Archive] = result
+}
+
+func (manager *RoomManagerCtx) ArchiveVerifications() []types.RoomArchiveVerification {
+	manager.events.archiveResultsMu.Lock()
+	defer manager.events.archiveResultsMu.Unlock()
+
+	results := make([]types.RoomArchiveVerification, 0, len(manager.events.archiveResults))
+	for _, result := range manager.events.archiveResults {
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Archive < results[j].Archive
+	})
+
+	return results
+}
@@ -0,0 +1,202 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+const (
+	// log lines sent with alert
+	watchdogLogLines = "50"
+	// timeout for stopping and recreating failed room
+	watchdogActionTimeout = 5 * time.Minute
+)
+
+// startWatchdog marks room as failed, if it does not become ready within timeout.
+func (e *events) startWatchdog(roomId string, labels map[string]string) {
+	if e.config.WatchdogTimeoutSec <= 0 {
+		return
+	}
+
+	e.watchdogsMu.Lock()
+	defer e.watchdogsMu.Unlock()
+
+	// watchdogs are not armed anymore, when shutting down
+	if e.ctx.Err() != nil {
+		return
+	}
+
+	e.stopWatchdog(roomId)
+	delete(e.failed, roomId)
+
+	e.wg.Add(1)
+	e.watchdogs[roomId] = time.AfterFunc(time.Duration(e.config.WatchdogTimeoutSec)*time.Second, func() {
+		defer e.wg.Done()
+		e.handleWatchdog(roomId, labels)
+	})
+}
+
+// stopWatchdog stops timer of a room, callback of stopped timer is not going
+// to be called anymore, so that it is done here. Lock must be held.
+func (e *events) stopWatchdog(roomId string) {
+	if timer, ok := e.watchdogs[roomId]; ok {
+		if timer.Stop() {
+			e.wg.Done()
+		}
+		delete(e.watchdogs, roomId)
+	}
+}
+
+// watchdogReady stops watchdog of a room, that became ready.
+func (e *events) watchdogReady(roomId string, labels map[string]string) {
+	e.watchdogsMu.Lock()
+	defer e.watchdogsMu.Unlock()
+
+	e.stopWatchdog(roomId)
+
+	// room can be retried again, when it fails next time
+	delete(e.retried, labels["m1k1o.neko_rooms.name"])
+}
+
+// cancelWatchdog stops watchdog of a room, that was stopped.
+func (e *events) cancelWatchdog(roomId string) {
+	e.watchdogsMu.Lock()
+	defer e.watchdogsMu.Unlock()
+
+	e.stopWatchdog(roomId)
+}
+
+// clearWatchdog resets watchdog state of a room.
+func (e *events) clearWatchdog(roomId string) {
+	e.watchdogsMu.Lock()
+	defer e.watchdogsMu.Unlock()
+
+	e.stopWatchdog(roomId)
+
+	delete(e.failed, roomId)
+}
+
+func (e *events) stopWatchdogs() {
+	e.watchdogsMu.Lock()
+	defer e.watchdogsMu.Unlock()
+
+	for roomId := range e.watchdogs {
+		e.stopWatchdog(roomId)
+	}
+}
+
+func (e *events) IsRoomFailed(roomId string) bool {
+	e.watchdogsMu.Lock()
+	defer e.watchdogsMu.Unlock()
+
+	_, ok := e.failed[roomId]
+	return ok
+}
+
+// shouldRetry returns true once for a room name, until it becomes ready.
+func (e *events) shouldRetry(name string) bool {
+	e.watchdogsMu.Lock()
+	defer e.watchdogsMu.Unlock()
+
+	if _, ok := e.retried[name]; ok {
+		return false
+	}
+
+	e.retried[name] = struct{}{}
+	return true
+}
+
+// handleWatchdog stops room, that did not become ready in time, marks it as failed
+// and sends alert with last log lines.
+func (e *events) handleWatchdog(roomId string, labels map[string]string) {
+	e.watchdogsMu.Lock()
+	_, ok := e.watchdogs[roomId]
+	delete(e.watchdogs, roomId)
+	e.watchdogsMu.Unlock()
+
+	// watchdog was stopped meanwhile or shutdown has begun
+	if !ok || e.ctx.Err() != nil || e.IsRoomReady(roomId) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(e.ctx, watchdogActionTimeout)
+	defer cancel()
+
+	// exited rooms are handled by crash loop detection
+	info, err := e.client.ContainerInspect(ctx, roomId)
+	if err != nil || !info.State.Running {
+		return
+	}
+
+	logs := []string{}
+	err = readContainerLogs(ctx, e.client, roomId, "", watchdogLogLines, func(timestamp time.Time, line string) {
+		logs = append(logs, line)
+	})
+	if err != nil {
+		e.logger.Err(err).Str("id", roomId).Msg("failed to read container logs")
+	}
+
+	e.watchdogsMu.Lock()
+	e.failed[roomId] = struct{}{}
+	e.watchdogsMu.Unlock()
+
+	err = e.client.ContainerStop(ctx, roomId, container.StopOptions{
+		Signal:  "SIGTERM",
+		Timeout: &e.config.StopTimeoutSec,
+	})
+	if err != nil {
+		e.logger.Err(err).Str("id", roomId).Msg("failed to stop failed room")
+	}
+
+	e.broadcast(types.RoomEvent{
+		ID:     roomId,
+		Action: types.RoomEventFailed,
+
+		ContainerLabels: labels,
+	})
+
+	e.sendAlert(types.RoomAlert{
+		Type:    types.RoomAlertWatchdog,
+		ID:      roomId,
+		Name:    labels["m1k1o.neko_rooms.name"],
+		Time:    time.Now(),
+		Message: fmt.Sprintf("room did not become ready within %ds after start, it was stopped", e.config.WatchdogTimeoutSec),
+		Logs:    logs,
+	})
+
+	// room is not recreated, when shutdown began meanwhile
+	if e.onFailed != nil && e.ctx.Err() == nil {
+		e.onFailed(roomId, labels)
+	}
+}
+
+// handleFailed recreates failed room once with a fresh container, if enabled.
+func (manager *RoomManagerCtx) handleFailed(roomId string, labels map[string]string) {
+	name := labels["m1k1o.neko_rooms.name"]
+	if !manager.config.WatchdogRetry || !manager.events.shouldRetry(name) {
+		return
+	}
+
+	logger := manager.logger.With().Str("id", roomId).Str("name", name).Logger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), watchdogActionTimeout)
+	defer cancel()
+
+	ID, err := manager.recreate(ctx, roomId, nil)
+	if err != nil {
+		logger.Err(err).Msg("watchdog: failed to recreate room")
+		return
+	}
+
+	if err := manager.Start(ctx, ID); err != nil {
+		logger.Err(err).Msg("watchdog: failed to start room")
+		return
+	}
+
+	logger.Info().Str("new_id", ID).Msg("watchdog: failed room recreated")
+}
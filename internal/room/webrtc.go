@@ -0,0 +1,299 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+	"github.com/m1k1o/neko-rooms/internal/types"
+)
+
+// prefix of webrtc metrics exposed by neko
+const webrtcMetricsPrefix = "neko_webrtc_"
+
+type webrtcStats struct {
+	config *config.Room
+
+	mu      sync.Mutex
+	history map[string][]types.RoomWebRTCStats // by room uuid, so that it survives recreates (and image upgrades)
+	labels  map[string]prometheus.Labels       // last exported labels by room uuid
+
+	peers      *prometheus.GaugeVec
+	bitrate    *prometheus.GaugeVec
+	packetLoss *prometheus.GaugeVec
+	rtt        *prometheus.GaugeVec
+}
+
+func newWebRTCStats(config *config.Room) *webrtcStats {
+	labels := []string{"room", "image"}
+
+	return &webrtcStats{
+		config: config,
+
+		history: map[string][]types.RoomWebRTCStats{},
+		labels:  map[string]prometheus.Labels{},
+
+		peers: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name:      "webrtc_peers",
+			Namespace: "neko_rooms",
+			Help:      "Number of webrtc peers of a room.",
+		}, labels),
+		bitrate: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name:      "webrtc_bitrate",
+			Namespace: "neko_rooms",
+			Help:      "Average receiver estimated maximum bitrate of webrtc peers of a room, in bits per second.",
+		}, labels),
+		packetLoss: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name:      "webrtc_packet_loss",
+			Namespace: "neko_rooms",
+			Help:      "Average packets lost reported by webrtc peers of a room.",
+		}, labels),
+		rtt: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name:      "webrtc_rtt_seconds",
+			Namespace: "neko_rooms",
+			Help:      "Average receiver report delay of webrtc peers of a room.",
+		}, labels),
+	}
+}
+
+// record appends stats to history of a room and exports them as metrics.
+func (s *webrtcStats) record(uuid string, name string, stats types.RoomWebRTCStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := append(s.history[uuid], stats)
+	if max := s.config.WebRTCStatsHistory; max > 0 && len(history) > max {
+		history = history[len(history)-max:]
+	}
+	s.history[uuid] = history
+
+	labels := prometheus.Labels{"room": name, "image": stats.Image}
+	if last, ok := s.labels[uuid]; ok && (last["room"] != name || last["image"] != stats.Image) {
+		s.deleteMetrics(last)
+	}
+	s.labels[uuid] = labels
+
+	var bitrate, packetLoss, rtt float64
+	for _, peer := range stats.Peers {
+		bitrate += peer.Bitrate
+		packetLoss += peer.PacketLoss
+		rtt += peer.RTT
+	}
+
+	if count := float64(len(stats.Peers)); count > 0 {
+		bitrate, packetLoss, rtt = bitrate/count, packetLoss/count, rtt/count
+	}
+
+	s.peers.With(labels).Set(float64(len(stats.Peers)))
+	s.bitrate.With(labels).Set(bitrate)
+	s.packetLoss.With(labels).Set(packetLoss)
+	s.rtt.With(labels).Set(rtt)
+}
+
+// forget removes metrics of rooms, that are not running anymore. History is kept.
+func (s *webrtcStats) forget(running map[string]struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uuid, labels := range s.labels {
+		if _, ok := running[uuid]; !ok {
+			s.deleteMetrics(labels)
+			delete(s.labels, uuid)
+		}
+	}
+}
+
+func (s *webrtcStats) deleteMetrics(labels prometheus.Labels) {
+	s.peers.Delete(labels)
+	s.bitrate.Delete(labels)
+	s.packetLoss.Delete(labels)
+	s.rtt.Delete(labels)
+}
+
+func (s *webrtcStats) History(uuid string) []types.RoomWebRTCStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]types.RoomWebRTCStats{}, s.history[uuid]...)
+}
+
+// watchWebRTCStats periodically collects webrtc stats of running rooms.
+func (e *events) watchWebRTCStats() {
+	if e.config.WebRTCStatsIntervalSec <= 0 || e.onWebRTCStatsPoll == nil {
+		return
+	}
+
+	e.every(time.Duration(e.config.WebRTCStatsIntervalSec)*time.Second, func() { e.onWebRTCStatsPoll(e.ctx) })
+}
+
+// metricValue returns value of a metric, summaries and histograms are averaged.
+func metricValue(metric *dto.Metric) float64 {
+	switch {
+	case metric.Gauge != nil:
+		return metric.Gauge.GetValue()
+	case metric.Counter != nil:
+		return metric.Counter.GetValue()
+	case metric.Untyped != nil:
+		return metric.Untyped.GetValue()
+	case metric.Summary != nil && metric.Summary.GetSampleCount() > 0:
+		return metric.Summary.GetSampleSum() / float64(metric.Summary.GetSampleCount())
+	case metric.Histogram != nil && metric.Histogram.GetSampleCount() > 0:
+		return metric.Histogram.GetSampleSum() / float64(metric.Histogram.GetSampleCount())
+	}
+	return 0
+}
+
+// parseWebRTCPeers returns webrtc metrics of neko grouped by session.
+func parseWebRTCPeers(output string) ([]types.RoomWebRTCPeer, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+
+	peers := map[string]*types.RoomWebRTCPeer{}
+
+	for name, family := range families {
+		if !strings.HasPrefix(name, webrtcMetricsPrefix) {
+			continue
+		}
+		name = strings.TrimPrefix(name, webrtcMetricsPrefix)
+
+		for _, metric := range family.GetMetric() {
+			var sessionId string
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "session_id" {
+					sessionId = label.GetValue()
+				}
+			}
+
+			// only metrics of participants
+			if sessionId == "" {
+				continue
+			}
+
+			peer, ok := peers[sessionId]
+			if !ok {
+				peer = &types.RoomWebRTCPeer{
+					SessionID: sessionId,
+					Metrics:   map[string]float64{},
+				}
+				peers[sessionId] = peer
+			}
+
+			peer.Metrics[name] = metricValue(metric)
+		}
+	}
+
+	result := []types.RoomWebRTCPeer{}
+	for _, peer := range peers {
+		peer.Bitrate = peer.Metrics["receiver_estimated_maximum_bitrate"]
+		peer.PacketLoss = peer.Metrics["receiver_report_packets_lost"]
+		peer.RTT = peer.Metrics["receiver_report_delay"]
+		peer.Jitter = peer.Metrics["receiver_report_jitter"]
+		result = append(result, *peer)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SessionID < result[j].SessionID
+	})
+
+	return result, nil
+}
+
+func (manager *RoomManagerCtx) GetWebRTCStats(ctx context.Context, id string) (*types.RoomWebRTCStats, error) {
+	id, err := manager.resolveId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := manager.inspectContainer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := manager.extractLabels(container.Config.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	if !labels.Profile.IsNeko() {
+		return nil, fmt.Errorf("webrtc stats are not supported for profile: %s", labels.Profile)
+	}
+
+	// only neko v3 exposes webrtc metrics
+	if labels.ApiVersion != 3 {
+		return nil, fmt.Errorf("webrtc stats are not supported for API version: %d", labels.ApiVersion)
+	}
+
+	output, err := manager.containerExec(ctx, id, []string{
+		"wget", "-q", "-O-", "http://127.0.0.1:8080/metrics",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	peers, err := parseWebRTCPeers(output)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.RoomWebRTCStats{
+		Image: container.Config.Image,
+		Time:  time.Now(),
+		Peers: peers,
+	}, nil
+}
+
+func (manager *RoomManagerCtx) GetWebRTCStatsHistory(ctx context.Context, id string) ([]types.RoomWebRTCStats, error) {
+	entry, err := manager.GetEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.webrtc.History(entry.UUID), nil
+}
+
+func (manager *RoomManagerCtx) pollWebRTCStats(ctx context.Context) {
+	entries, err := manager.List(ctx, nil)
+	if err != nil {
+		manager.logger.Err(err).Msg("webrtc stats: failed to list rooms")
+		return
+	}
+
+	running := map[string]struct{}{}
+	for _, entry := range entries {
+		if !entry.Running || !entry.Profile.IsNeko() {
+			continue
+		}
+
+		// stop early on shutdown
+		if ctx.Err() != nil {
+			return
+		}
+
+		statsCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		stats, err := manager.GetWebRTCStats(statsCtx, entry.ID)
+		cancel()
+
+		if err != nil {
+			// room could be starting, not respond or have metrics disabled
+			manager.logger.Debug().Err(err).Str("id", entry.ID).Msg("webrtc stats: failed to get room stats")
+			continue
+		}
+
+		running[entry.UUID] = struct{}{}
+		manager.webrtc.record(entry.UUID, entry.Name, *stats)
+	}
+
+	manager.webrtc.forget(running)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+const unixSocketPrefix = "unix:"
+
+// listen creates listener for address, addresses prefixed with unix: are
+// unix sockets, that get given file mode.
+func listen(addr string, socketMode fs.FileMode) (net.Listener, error) {
+	socketPath, ok := strings.CutPrefix(addr, unixSocketPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	// remove stale socket left by previous process
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(socketPath, socketMode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+func isUnixListener(listener net.Listener) bool {
+	_, ok := listener.(*net.UnixListener)
+	return ok
+}
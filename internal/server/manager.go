@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"io"
 	"io/fs"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -29,42 +31,40 @@ type ServerManagerCtx struct {
 	router *chi.Mux
 	server *http.Server
 	config *config.Server
+
+	adminServer *http.Server // when admin is served on separate listener
 }
 
 func New(ApiManager types.ApiManager, roomConfig *config.Room, config *config.Server, proxyHandler http.Handler) *ServerManagerCtx {
 	logger := log.With().Str("module", "server").Logger()
 
-	router := chi.NewRouter()
-	router.Use(middleware.RequestID) // Create a request ID for each request
+	router := newRouter(logger, config)
 
-	// get real users ip
-	if config.Proxy {
-		router.Use(middleware.RealIP)
-	}
-
-	// add http logger
-	router.Use(middleware.RequestLogger(&logformatter{logger}))
-	router.Use(middleware.Recoverer) // Recover from panics without crashing server
-
-	// Basic CORS
-	if config.CORS {
-		// for more ideas, see: https://developer.github.com/v3/#cross-origin-resource-sharing
-		router.Use(cors.Handler(cors.Options{
-			AllowOriginFunc: func(r *http.Request, origin string) bool {
-				return true
-			},
-			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-			AllowCredentials: true,
-			MaxAge:           300, // Maximum value not ignored by any of major browsers
-		}))
+	// admin can be served on separate listener, so that public one exposes only rooms
+	adminRouter := router
+	if config.Admin.Bind != "" {
+		adminRouter = newRouter(logger, config)
 	}
 
 	//
 	// admin page
 	//
 
-	protected := func(next http.Handler) http.Handler {
+	// serveAs serves request of authenticated user, header is used only when
+	// it is configured as trusted, otherwise it could be set by anyone
+	serveAs := func(next http.Handler, w http.ResponseWriter, r *http.Request, actor string) {
+		if actor == "" && config.Admin.UserHeader != "" {
+			actor = r.Header.Get(config.Admin.UserHeader)
+		}
+
+		if actor != "" {
+			r = r.WithContext(types.WithActor(r.Context(), actor))
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	adminAuth := func(next http.Handler) http.Handler {
 		// if proxy auth is enabled
 		if config.Admin.ProxyAuth != "" {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -151,7 +151,15 @@ func New(ApiManager types.ApiManager, roomConfig *config.Room, config *config.Se
 					_, _ = io.Copy(io.Discard, res.Body)
 				}
 
-				next.ServeHTTP(w, r)
+				// user is reported by auth service in its response
+				actor := ""
+				for _, header := range []string{"Remote-User", "X-Auth-Request-User", "X-Forwarded-User"} {
+					if actor = res.Header.Get(header); actor != "" {
+						break
+					}
+				}
+
+				serveAs(next, w, r, actor)
 			})
 		}
 
@@ -165,11 +173,31 @@ func New(ApiManager types.ApiManager, roomConfig *config.Room, config *config.Se
 					return
 				}
 
-				next.ServeHTTP(w, r)
+				serveAs(next, w, r, user)
 			})
 		}
 
-		return next
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveAs(next, w, r, "")
+		})
+	}
+
+	protected := func(next http.Handler) http.Handler {
+		auth := adminAuth(next)
+
+		// if demo rooms are enabled, demo token bypasses admin auth for demo endpoint only
+		if config.Admin.DemoToken == "" {
+			return auth
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isDemoRequest(r, config.Admin.DemoToken) {
+				next.ServeHTTP(w, r.WithContext(types.WithActor(r.Context(), "demo")))
+				return
+			}
+
+			auth.ServeHTTP(w, r)
+		})
 	}
 
 	// DEPRECATED: admin should not be served from the same path as rooms
@@ -188,7 +216,7 @@ func New(ApiManager types.ApiManager, roomConfig *config.Room, config *config.Se
 		}
 
 		// serve static files
-		router.Use(func(next http.Handler) http.Handler {
+		adminRouter.Use(func(next http.Handler) http.Handler {
 			// if static files are disabled
 			if config.Admin.Static == "" {
 				return next
@@ -211,9 +239,9 @@ func New(ApiManager types.ApiManager, roomConfig *config.Room, config *config.Se
 		})
 
 		// serve protected API
-		router.With(protected).Route("/api", ApiManager.Mount)
+		adminRouter.With(protected).Route("/api", ApiManager.Mount)
 	} else {
-		router.With(protected).Route(config.Admin.PathPrefix+"/", func(r chi.Router) {
+		adminRouter.With(protected).Route(config.Admin.PathPrefix+"/", func(r chi.Router) {
 			// serve protected API
 			r.Route("/api", ApiManager.Mount)
 
@@ -236,22 +264,25 @@ func New(ApiManager types.ApiManager, roomConfig *config.Room, config *config.Se
 		})
 	}
 
-	// mount pprof endpoint
+	// mount pprof endpoint, protected same as admin API
 	if config.PProf {
-		router.Mount("/debug", middleware.Profiler())
+		adminRouter.With(adminAuth).Mount("/debug", middleware.Profiler())
 		logger.Info().Msgf("with pprof endpoint")
 	}
 
 	// mount metrics prometheus endpoint
 	if config.Metrics {
-		router.Mount("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}))
+		adminRouter.Mount("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}))
 		logger.Info().Msgf("with metrics endpoint")
 	}
 
+	// public endpoints, room names can not start with "-"
+	router.Route("/-", ApiManager.MountPublic)
+
 	// handle all remaining paths with proxy
 	router.Handle("/*", proxyHandler)
 
-	return &ServerManagerCtx{
+	manager := &ServerManagerCtx{
 		logger: logger,
 		router: router,
 		server: &http.Server{
@@ -260,23 +291,102 @@ func New(ApiManager types.ApiManager, roomConfig *config.Room, config *config.Se
 		},
 		config: config,
 	}
+
+	if config.Admin.Bind != "" {
+		manager.adminServer = &http.Server{
+			Addr:    config.Admin.Bind,
+			Handler: adminRouter,
+		}
+	}
+
+	return manager
+}
+
+func newRouter(logger zerolog.Logger, config *config.Server) *chi.Mux {
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID) // Create a request ID for each request
+
+	// get real users ip
+	if config.Proxy {
+		router.Use(middleware.RealIP)
+	}
+
+	// add http logger
+	router.Use(middleware.RequestLogger(&logformatter{logger}))
+	router.Use(middleware.Recoverer) // Recover from panics without crashing server
+
+	// Basic CORS
+	if config.CORS {
+		// for more ideas, see: https://developer.github.com/v3/#cross-origin-resource-sharing
+		router.Use(cors.Handler(cors.Options{
+			AllowOriginFunc: func(r *http.Request, origin string) bool {
+				return true
+			},
+			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+			AllowCredentials: true,
+			MaxAge:           300, // Maximum value not ignored by any of major browsers
+		}))
+	}
+
+	return router
 }
 
 func (s *ServerManagerCtx) Start() {
-	if s.config.Cert != "" && s.config.Key != "" {
+	listener, err := systemdListener()
+	if err != nil {
+		s.logger.Panic().Err(err).Msg("unable to use systemd socket")
+	}
+
+	if listener == nil {
+		listener, err = listen(s.server.Addr, s.config.BindSocketMode)
+		if err != nil {
+			s.logger.Panic().Err(err).Msg("unable to listen")
+		}
+	} else {
+		s.logger.Info().Msg("using socket passed by systemd")
+	}
+
+	listeners := []net.Listener{listener}
+	for _, addr := range s.config.BindExtra {
+		listener, err := listen(addr, s.config.BindSocketMode)
+		if err != nil {
+			s.logger.Panic().Err(err).Str("addr", addr).Msg("unable to listen")
+		}
+		listeners = append(listeners, listener)
+	}
+
+	for _, listener := range listeners {
+		s.serve(s.server, listener)
+	}
+
+	if s.adminServer != nil {
+		listener, err := listen(s.adminServer.Addr, s.config.BindSocketMode)
+		if err != nil {
+			s.logger.Panic().Err(err).Msg("unable to listen for admin")
+		}
+
+		s.logger.Info().Msg("admin is served on separate listener")
+		s.serve(s.adminServer, listener)
+	}
+}
+
+// serve accepts connections on listener, unix sockets are always served without TLS.
+func (s *ServerManagerCtx) serve(server *http.Server, listener net.Listener) {
+	if s.config.Cert != "" && s.config.Key != "" && !isUnixListener(listener) {
 		go func() {
-			if err := s.server.ListenAndServeTLS(s.config.Cert, s.config.Key); err != http.ErrServerClosed {
+			if err := server.ServeTLS(listener, s.config.Cert, s.config.Key); err != http.ErrServerClosed {
 				s.logger.Panic().Err(err).Msg("unable to start https server")
 			}
 		}()
-		s.logger.Info().Msgf("https listening on %s", s.server.Addr)
+		s.logger.Info().Msgf("https listening on %s", listener.Addr())
 	} else {
 		go func() {
-			if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+			if err := server.Serve(listener); err != http.ErrServerClosed {
 				s.logger.Panic().Err(err).Msg("unable to start http server")
 			}
 		}()
-		s.logger.Info().Msgf("http listening on %s", s.server.Addr)
+		s.logger.Info().Msgf("http listening on %s", listener.Addr())
 	}
 }
 
@@ -284,5 +394,21 @@ func (s *ServerManagerCtx) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if s.adminServer != nil {
+		if err := s.adminServer.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+
 	return s.server.Shutdown(ctx)
 }
+
+// isDemoRequest checks, whether request creates demo room using demo token.
+func isDemoRequest(r *http.Request, token string) bool {
+	if r.Method != http.MethodPost || !strings.HasSuffix(path.Clean(r.URL.Path), "/api/demo") {
+		return false
+	}
+
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1
+}
@@ -0,0 +1,494 @@
+package setup
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+	dockerNames "github.com/docker/docker/daemon/names"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+const defaultStunServer = "stun.l.google.com:19302"
+
+type SetupManagerCtx struct {
+	logger zerolog.Logger
+	config *config.Setup
+	rooms  *config.Room
+	client *dockerClient.Client
+	code   string
+
+	mu    sync.Mutex
+	state types.SetupState
+}
+
+func New(client *dockerClient.Client, config *config.Setup, rooms *config.Room) (*SetupManagerCtx, error) {
+	code := config.Code
+	if code == "" {
+		var err error
+		code, err = utils.NewUID(12)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manager := &SetupManagerCtx{
+		logger: log.With().Str("module", "setup").Logger(),
+		config: config,
+		rooms:  rooms,
+		client: client,
+		code:   code,
+
+		state: types.SetupState{
+			Next:       types.SetupSteps[0],
+			ConfigFile: config.ConfigFile,
+		},
+	}
+
+	if config.Code == "" {
+		manager.logger.Warn().Str("code", code).Msg("setup mode enabled, authorize setup wizard using this code")
+	} else {
+		manager.logger.Warn().Msg("setup mode enabled, authorize setup wizard using configured code")
+	}
+
+	return manager, nil
+}
+
+func (manager *SetupManagerCtx) Authorize(code string) bool {
+	return subtle.ConstantTimeCompare([]byte(code), []byte(manager.code)) == 1
+}
+
+func (manager *SetupManagerCtx) State() types.SetupState {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	return manager.state
+}
+
+// begin checks, that all previous steps are completed and locks the state until
+// returned function is called with result of the step.
+func (manager *SetupManagerCtx) begin(step types.SetupStep) (func(err error), error) {
+	manager.mu.Lock()
+
+	if manager.state.Completed {
+		manager.mu.Unlock()
+		return nil, types.ErrSetupCompleted
+	}
+
+	for _, s := range types.SetupSteps {
+		if s == step {
+			break
+		}
+		if s == manager.state.Next {
+			manager.mu.Unlock()
+			return nil, types.ErrSetupStepOrder
+		}
+	}
+
+	return func(err error) {
+		defer manager.mu.Unlock()
+
+		if err != nil {
+			manager.state.Failed = step
+			manager.state.Error = err.Error()
+			manager.logger.Warn().Err(err).Str("step", string(step)).Msg("setup step failed")
+			return
+		}
+
+		manager.state.Failed = ""
+		manager.state.Error = ""
+
+		// repeated step invalidates following steps, they depend on its result
+		for i, s := range types.SetupSteps {
+			if s != step {
+				continue
+			}
+
+			if i+1 < len(types.SetupSteps) {
+				manager.state.Next = types.SetupSteps[i+1]
+				manager.reset(types.SetupSteps[i+1:])
+			} else {
+				manager.state.Next = ""
+			}
+		}
+
+		manager.logger.Info().Str("step", string(step)).Msg("setup step completed")
+	}, nil
+}
+
+func (manager *SetupManagerCtx) reset(steps []types.SetupStep) {
+	for _, step := range steps {
+		switch step {
+		case types.SetupStepNetwork:
+			manager.state.Network = nil
+		case types.SetupStepDomain:
+			manager.state.Domain = nil
+		case types.SetupStepReachability:
+			manager.state.Reachability = nil
+		case types.SetupStepAdmin:
+			manager.state.Admin = nil
+		}
+	}
+}
+
+func (manager *SetupManagerCtx) Docker(ctx context.Context) (*types.SetupDocker, error) {
+	done, err := manager.begin(types.SetupStepDocker)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manager.docker(ctx)
+	if err == nil {
+		manager.state.Docker = result
+	}
+
+	done(err)
+	return result, err
+}
+
+func (manager *SetupManagerCtx) docker(ctx context.Context) (*types.SetupDocker, error) {
+	if _, err := manager.client.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("docker is not reachable, make sure that docker socket is mounted: %w", err)
+	}
+
+	version, err := manager.client.ServerVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.SetupDocker{
+		Version:    version.Version,
+		ApiVersion: version.APIVersion,
+		Os:         version.Os,
+		Arch:       version.Arch,
+	}, nil
+}
+
+// Networks returns bridge networks, that rooms can be attached to.
+func (manager *SetupManagerCtx) Networks(ctx context.Context) ([]types.SetupNetworkInfo, error) {
+	networks, err := manager.client.NetworkList(ctx, dockerTypes.NetworkListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.SetupNetworkInfo{}
+	for _, network := range networks {
+		// default networks can not be used for routing by name
+		if network.Driver != "bridge" || network.Name == "bridge" {
+			continue
+		}
+
+		info, err := manager.networkInfo(ctx, network.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *info)
+	}
+
+	return result, nil
+}
+
+func (manager *SetupManagerCtx) networkInfo(ctx context.Context, id string) (*types.SetupNetworkInfo, error) {
+	// containers are listed only when network is inspected
+	network, err := manager.client.NetworkInspect(ctx, id, dockerTypes.NetworkInspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	info := &types.SetupNetworkInfo{
+		Name:   network.Name,
+		Driver: network.Driver,
+	}
+
+	for _, endpoint := range network.Containers {
+		if strings.Contains(endpoint.Name, "traefik") {
+			info.Traefik = true
+			break
+		}
+	}
+
+	return info, nil
+}
+
+func (manager *SetupManagerCtx) Network(ctx context.Context, request types.SetupNetworkRequest) (*types.SetupNetwork, error) {
+	done, err := manager.begin(types.SetupStepNetwork)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manager.network(ctx, request)
+	if err == nil {
+		manager.state.Network = result
+	}
+
+	done(err)
+	return result, err
+}
+
+func (manager *SetupManagerCtx) network(ctx context.Context, request types.SetupNetworkRequest) (*types.SetupNetwork, error) {
+	if !dockerNames.RestrictedNamePattern.MatchString(request.Name) {
+		return nil, fmt.Errorf("invalid network name, must match %s", dockerNames.RestrictedNameChars)
+	}
+
+	info, err := manager.networkInfo(ctx, request.Name)
+	if err == nil {
+		if info.Driver != "bridge" {
+			return nil, fmt.Errorf("network %s uses %s driver, only bridge networks are supported", info.Name, info.Driver)
+		}
+
+		return &types.SetupNetwork{
+			Name:    info.Name,
+			Traefik: info.Traefik,
+		}, nil
+	}
+
+	if !dockerClient.IsErrNotFound(err) {
+		return nil, err
+	}
+
+	if !request.Create {
+		return nil, fmt.Errorf("network %s does not exist", request.Name)
+	}
+
+	_, err = manager.client.NetworkCreate(ctx, request.Name, dockerTypes.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         "bridge",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manager.logger.Info().Str("network", request.Name).Msg("setup: network created")
+	return &types.SetupNetwork{
+		Name:    request.Name,
+		Created: true,
+	}, nil
+}
+
+func (manager *SetupManagerCtx) Domain(ctx context.Context, request types.SetupDomainRequest) (*types.SetupDomain, error) {
+	done, err := manager.begin(types.SetupStepDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manager.domain(ctx, request)
+	if err == nil {
+		manager.state.Domain = result
+	}
+
+	done(err)
+	return result, err
+}
+
+func (manager *SetupManagerCtx) domain(ctx context.Context, request types.SetupDomainRequest) (*types.SetupDomain, error) {
+	result := &types.SetupDomain{
+		Domain:       request.Domain,
+		Entrypoint:   request.Entrypoint,
+		Certresolver: request.Certresolver,
+		Addresses:    []string{},
+	}
+
+	if result.Entrypoint == "" {
+		result.Entrypoint = manager.rooms.Traefik.Entrypoint
+	}
+
+	// matches all domains, nothing to resolve
+	if result.Domain == "" || result.Domain == "*" {
+		return result, nil
+	}
+
+	host := strings.TrimPrefix(result.Domain, "*.")
+	if !validDomain(host) {
+		return nil, fmt.Errorf("invalid domain %s", result.Domain)
+	}
+
+	// wildcard is resolved for any subdomain
+	if host != result.Domain {
+		host = "room." + host
+	}
+
+	resolveCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupHost(resolveCtx, host)
+	if err != nil {
+		return nil, fmt.Errorf("domain %s can not be resolved, DNS record must point to this host: %w", host, err)
+	}
+
+	result.Addresses = addresses
+	return result, nil
+}
+
+func validDomain(domain string) bool {
+	if len(domain) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (manager *SetupManagerCtx) Reachability(ctx context.Context, request types.SetupReachabilityRequest) (*types.SetupReachability, error) {
+	done, err := manager.begin(types.SetupStepReachability)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manager.reachability(ctx, request)
+	if err == nil {
+		manager.state.Reachability = result
+	}
+
+	done(err)
+	return result, err
+}
+
+func (manager *SetupManagerCtx) reachability(ctx context.Context, request types.SetupReachabilityRequest) (*types.SetupReachability, error) {
+	server := request.StunServer
+	if server == "" {
+		server = defaultStunServer
+	}
+
+	for _, ip := range request.NAT1To1 {
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid nat1to1 address %s", ip)
+		}
+	}
+
+	stunCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	localPort, mapped, err := stunBinding(stunCtx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.SetupReachability{
+		PublicIP:   mapped.IP.String(),
+		MappedPort: mapped.Port,
+		LocalPort:  localPort,
+		BehindNAT:  !isLocalIP(mapped.IP),
+		NAT1To1:    request.NAT1To1,
+		Warnings:   []string{},
+	}
+
+	if len(result.NAT1To1) == 0 {
+		result.NAT1To1 = []string{result.PublicIP}
+	} else if in, _ := utils.ArrayIn(result.PublicIP, result.NAT1To1); !in {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("detected public IP %s is not in nat1to1, clients could be unable to connect", result.PublicIP))
+	}
+
+	if result.BehindNAT {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("host is behind NAT, UDP ports %d-%d must be forwarded to this host", manager.rooms.EprMin, manager.rooms.EprMax))
+	}
+
+	// port translating NAT does not keep forwarded ports
+	if result.MappedPort != result.LocalPort {
+		result.Warnings = append(result.Warnings, "NAT changes source ports, WebRTC could require TURN server")
+	}
+
+	// state is locked while step is running
+	domain := manager.state.Domain
+	if domain != nil && len(domain.Addresses) > 0 {
+		if in, _ := utils.ArrayIn(result.PublicIP, domain.Addresses); !in {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("domain %s does not resolve to detected public IP %s", domain.Domain, result.PublicIP))
+		}
+	}
+
+	return result, nil
+}
+
+// isLocalIP checks, whether IP is assigned to any interface of this host.
+func isLocalIP(ip net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Admin creates credentials of the first admin and writes configuration file,
+// it is the last step of setup.
+func (manager *SetupManagerCtx) Admin(ctx context.Context, request types.SetupAdminRequest) (*types.SetupAdmin, error) {
+	done, err := manager.begin(types.SetupStepAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manager.admin(request)
+	if err == nil {
+		manager.state.Admin = &types.SetupAdmin{Username: result.Username}
+		manager.state.Completed = true
+	}
+
+	done(err)
+	return result, err
+}
+
+func (manager *SetupManagerCtx) admin(request types.SetupAdminRequest) (*types.SetupAdmin, error) {
+	username := request.Username
+	if username == "" {
+		username = "admin"
+	}
+
+	if strings.ContainsAny(username, ":\r\n") {
+		return nil, fmt.Errorf("invalid username, must not contain colon or newlines")
+	}
+
+	password, err := utils.NewUID(32)
+	if err != nil {
+		return nil, err
+	}
+
+	state := manager.state
+	values := map[string]any{
+		"instance.network":     state.Network.Name,
+		"traefik.enabled":      true,
+		"traefik.domain":       state.Domain.Domain,
+		"traefik.entrypoint":   state.Domain.Entrypoint,
+		"traefik.certresolver": state.Domain.Certresolver,
+		"nat1to1":              state.Reachability.NAT1To1,
+		"admin.username":       username,
+		"admin.password":       password,
+	}
+
+	if err := config.WriteFile(manager.config.ConfigFile, values, true); err != nil {
+		return nil, fmt.Errorf("unable to write configuration file: %w", err)
+	}
+
+	manager.logger.Info().Str("config", manager.config.ConfigFile).Msg("setup completed, restart neko-rooms without setup mode to apply configuration")
+	return &types.SetupAdmin{
+		Username: username,
+		Password: password,
+	}, nil
+}
@@ -0,0 +1,154 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingResponse = 0x0101
+	stunMagicCookie     = 0x2112A442
+
+	stunMappedAddress    = 0x0001
+	stunXorMappedAddress = 0x0020
+)
+
+// stunBinding sends STUN binding request (RFC 5389) from UDP socket and returns
+// local port of the socket and address, that STUN server has seen it from.
+func stunBinding(ctx context.Context, server string) (int, *net.UDPAddr, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return 0, nil, err
+	}
+
+	request := make([]byte, 20)
+	binary.BigEndian.PutUint16(request[0:], stunBindingRequest)
+	binary.BigEndian.PutUint16(request[2:], 0)
+	binary.BigEndian.PutUint32(request[4:], stunMagicCookie)
+	if _, err := rand.Read(request[8:20]); err != nil {
+		return 0, nil, err
+	}
+
+	localPort := conn.LocalAddr().(*net.UDPAddr).Port
+
+	// UDP is lossy, request is repeated until response or deadline
+	response := make([]byte, 1500)
+	for {
+		if _, err := conn.Write(request); err != nil {
+			return 0, nil, err
+		}
+
+		if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+			return 0, nil, err
+		}
+
+		n, err := conn.Read(response)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() && time.Now().Before(deadline) {
+				continue
+			}
+			return 0, nil, fmt.Errorf("no response from STUN server, outgoing UDP could be blocked: %w", err)
+		}
+
+		// response to other transaction is ignored
+		if n < 20 || !bytes.Equal(response[8:20], request[8:20]) {
+			continue
+		}
+
+		addr, err := stunParseResponse(response[:n])
+		return localPort, addr, err
+	}
+}
+
+func stunParseResponse(msg []byte) (*net.UDPAddr, error) {
+	if binary.BigEndian.Uint16(msg[0:]) != stunBindingResponse {
+		return nil, fmt.Errorf("unexpected STUN message type %#04x", binary.BigEndian.Uint16(msg[0:]))
+	}
+
+	length := int(binary.BigEndian.Uint16(msg[2:]))
+	if len(msg) < 20+length {
+		return nil, fmt.Errorf("truncated STUN message")
+	}
+
+	var mapped *net.UDPAddr
+	attrs := msg[20 : 20+length]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:]))
+		if len(attrs) < 4+attrLen {
+			return nil, fmt.Errorf("truncated STUN attribute")
+		}
+		value := attrs[4 : 4+attrLen]
+
+		switch attrType {
+		case stunXorMappedAddress:
+			// preferred, it is not rewritten by NATs
+			return stunParseAddress(value, msg[4:20])
+		case stunMappedAddress:
+			mapped, _ = stunParseAddress(value, nil)
+		}
+
+		// attributes are padded to 4 bytes
+		attrLen = (attrLen + 3) &^ 3
+		if len(attrs) < 4+attrLen {
+			break
+		}
+		attrs = attrs[4+attrLen:]
+	}
+
+	if mapped == nil {
+		return nil, fmt.Errorf("STUN response does not contain mapped address")
+	}
+
+	return mapped, nil
+}
+
+// stunParseAddress parses (XOR-)MAPPED-ADDRESS, xor is magic cookie followed by
+// transaction id, nil for not xored address.
+func stunParseAddress(value []byte, xor []byte) (*net.UDPAddr, error) {
+	if len(value) < 4 {
+		return nil, fmt.Errorf("invalid STUN address")
+	}
+
+	var ip net.IP
+	switch value[1] {
+	case 0x01:
+		ip = make(net.IP, net.IPv4len)
+	case 0x02:
+		ip = make(net.IP, net.IPv6len)
+	default:
+		return nil, fmt.Errorf("invalid STUN address family")
+	}
+
+	if len(value) < 4+len(ip) {
+		return nil, fmt.Errorf("invalid STUN address")
+	}
+
+	port := binary.BigEndian.Uint16(value[2:])
+	copy(ip, value[4:4+len(ip)])
+
+	if xor != nil {
+		port ^= binary.BigEndian.Uint16(xor)
+		for i := range ip {
+			ip[i] ^= xor[i]
+		}
+	}
+
+	return &net.UDPAddr{IP: ip, Port: int(port)}, nil
+}
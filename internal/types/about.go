@@ -0,0 +1,52 @@
+package types
+
+import (
+	"time"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+)
+
+type About struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	GitBranch string `json:"git_branch"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	Profile   string `json:"profile,omitempty"` // active configuration profile
+
+	Backend       string   `json:"backend"`
+	DockerVersion string   `json:"docker_version,omitempty"` // empty when docker is unreachable
+	Features      []string `json:"features"`
+
+	Deprecations []config.Deprecation `json:"deprecations,omitempty"` // config items to be migrated
+}
+
+type RoomDiagnostics struct {
+	Listeners        int            `json:"listeners"` // event stream subscribers
+	LastEvent        *time.Time     `json:"last_event,omitempty"`
+	EventLagMs       int64          `json:"event_lag_ms"`       // between last docker event and its handling
+	BroadcastDelayMs int64          `json:"broadcast_delay_ms"` // delivering last event to listeners
+	Caches           map[string]int `json:"caches"`
+}
+
+type Diagnostics struct {
+	Time       time.Time         `json:"time"`
+	Uptime     float64           `json:"uptime"` // in seconds
+	Goroutines int               `json:"goroutines"`
+	Memory     DiagnosticsMemory `json:"memory"`
+	Caches     map[string]int    `json:"caches"`
+	Events     RoomDiagnostics   `json:"events"`
+	Stacks     string            `json:"stacks,omitempty"` // goroutine dump, only when requested
+}
+
+type DiagnosticsMemory struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"pause_total_ns"`
+	NextGC       uint64 `json:"next_gc"`
+}
@@ -1,9 +1,25 @@
 package types
 
 import (
+	"context"
+
 	"github.com/go-chi/chi/v5"
 )
 
 type ApiManager interface {
 	Mount(r chi.Router)
+	MountPublic(r chi.Router) // served without admin auth
+}
+
+type actorKey struct{}
+
+// WithActor returns context of request made by authenticated user.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns authenticated user of request context, empty if unknown.
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
 }
@@ -0,0 +1,72 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+// SecretEnvRegex matches names of environment variables, that contain secrets
+var SecretEnvRegex = regexp.MustCompile(`(?i)(PASS|SECRET|TOKEN|KEY)`)
+
+// envBuilder collects container environment variables. Variables are returned
+// in order of their first insertion, setting variable again overrides its value.
+type envBuilder struct {
+	blacklist []string
+	keys      []string
+	values    map[string]string
+}
+
+func newEnvBuilder(blacklist []string) *envBuilder {
+	return &envBuilder{
+		blacklist: blacklist,
+		keys:      []string{},
+		values:    map[string]string{},
+	}
+}
+
+func (b *envBuilder) Set(key, val string) *envBuilder {
+	if _, ok := b.values[key]; !ok {
+		b.keys = append(b.keys, key)
+	}
+	b.values[key] = val
+	return b
+}
+
+func (b *envBuilder) Setf(key, format string, a ...any) *envBuilder {
+	return b.Set(key, fmt.Sprintf(format, a...))
+}
+
+// SetIf sets variable only when condition is met.
+func (b *envBuilder) SetIf(cond bool, key, val string) *envBuilder {
+	if cond {
+		b.Set(key, val)
+	}
+	return b
+}
+
+// User adds user defined variables sorted by key, blacklisted variables are skipped.
+func (b *envBuilder) User(envs map[string]string) *envBuilder {
+	keys := make([]string, 0, len(envs))
+	for key := range envs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if in, _ := utils.ArrayIn(key, b.blacklist); !in {
+			b.Set(key, envs[key])
+		}
+	}
+	return b
+}
+
+func (b *envBuilder) Env() []string {
+	env := make([]string, 0, len(b.keys))
+	for _, key := range b.keys {
+		env = append(env, fmt.Sprintf("%s=%s", key, b.values[key]))
+	}
+	return env
+}
@@ -0,0 +1,161 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/m1k1o/neko-rooms/internal/config"
+)
+
+func TestEnvBuilder(t *testing.T) {
+	env := newEnvBuilder([]string{"PATH"}).
+		Set("A", "1").
+		Setf("B", "%d-%d", 2, 3).
+		SetIf(false, "C", "4").
+		SetIf(true, "D", "5").
+		Set("A", "6")
+
+	// user defined envs are sorted and blacklisted are skipped
+	env.User(map[string]string{
+		"Z":    "z",
+		"PATH": "/bin",
+		"B":    "b",
+		"M":    "m",
+	})
+
+	expected := []string{"A=6", "B=b", "D=5", "M=m", "Z=z"}
+	if got := env.Env(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Env() = %v, expected %v", got, expected)
+	}
+}
+
+func TestToEnvDeterministic(t *testing.T) {
+	settings := &RoomSettings{
+		UserPass:   "user",
+		AdminPass:  "admin",
+		Screen:     "1280x720@30",
+		VideoCodec: "VP8",
+		AudioCodec: "OPUS",
+		Envs: map[string]string{
+			"FOO":  "1",
+			"BAR":  "2",
+			"BAZ":  "3",
+			"QUX":  "4",
+			"QUUX": "5",
+			"TZ":   "UTC",
+		},
+	}
+
+	ports := PortSettings{
+		FrontendPort: 8080,
+		EprMin:       59000,
+		EprMax:       59010,
+	}
+
+	for _, apiVersion := range []int{2, 3} {
+		settings.ApiVersion = apiVersion
+
+		first, err := settings.ToEnv(&config.Room{}, ports)
+		if err != nil {
+			t.Fatalf("api v%d: ToEnv() failed: %v", apiVersion, err)
+		}
+
+		for i := 0; i < 10; i++ {
+			env, _ := settings.ToEnv(&config.Room{}, ports)
+			if !reflect.DeepEqual(env, first) {
+				t.Fatalf("api v%d: ToEnv() is not deterministic: %v != %v", apiVersion, env, first)
+			}
+		}
+	}
+}
+
+func TestToEnvV2(t *testing.T) {
+	settings := &RoomSettings{
+		ApiVersion:      2,
+		UserPass:        "user",
+		AdminPass:       "admin",
+		Screen:          "1280x720@30",
+		VideoMaxFPS:     25,
+		VideoCodec:      "H264",
+		AudioCodec:      "OPUS",
+		ImplicitControl: true,
+		Envs: map[string]string{
+			"NEKO_EPR": "1-2", // managed by neko-rooms
+			"TZ":       "UTC",
+		},
+	}
+
+	ports := PortSettings{
+		FrontendPort: 8080,
+		EprMin:       59000,
+		EprMax:       59000,
+	}
+
+	env, err := settings.ToEnv(&config.Room{Mux: true, NAT1To1IPs: []string{"10.0.0.1"}}, ports)
+	if err != nil {
+		t.Fatalf("ToEnv() failed: %v", err)
+	}
+
+	expected := []string{
+		"NEKO_BIND=:8080",
+		"NEKO_PROXY=true",
+		"NEKO_ICELITE=true",
+		"NEKO_UDPMUX=59000",
+		"NEKO_TCPMUX=59000",
+		"NEKO_NAT1TO1=10.0.0.1",
+		"NEKO_PASSWORD=user",
+		"NEKO_PASSWORD_ADMIN=admin",
+		"NEKO_IMPLICIT_CONTROL=true",
+		"NEKO_SCREEN=1280x720@30",
+		"NEKO_MAX_FPS=25",
+		"NEKO_VIDEO_CODEC=h264",
+		"TZ=UTC",
+	}
+
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("ToEnv() = %v, expected %v", env, expected)
+	}
+}
+
+func TestToEnvV3(t *testing.T) {
+	settings := &RoomSettings{
+		ApiVersion: 3,
+		UserPass:   "user",
+		AdminPass:  "admin",
+		Screen:     "1920x1080@60",
+		VideoCodec: "VP8",
+		AudioCodec: "G722",
+		Envs: map[string]string{
+			"NEKO_SERVER_BIND": ":1234", // managed by neko-rooms
+		},
+	}
+
+	ports := PortSettings{
+		FrontendPort: 8080,
+		EprMin:       59000,
+		EprMax:       59010,
+	}
+
+	env, err := settings.ToEnv(&config.Room{}, ports)
+	if err != nil {
+		t.Fatalf("ToEnv() failed: %v", err)
+	}
+
+	expected := []string{
+		"NEKO_SERVER_BIND=:8080",
+		"NEKO_SERVER_PROXY=true",
+		"NEKO_WEBRTC_ICELITE=true",
+		"NEKO_WEBRTC_EPR=59000-59010",
+		"NEKO_MEMBER_PROVIDER=multiuser",
+		"NEKO_MEMBER_MULTIUSER_USER_PASSWORD=user",
+		"NEKO_MEMBER_MULTIUSER_ADMIN_PASSWORD=admin",
+		"NEKO_SESSION_API_TOKEN=admin",
+		"NEKO_SESSION_IMPLICIT_HOSTING=false",
+		"NEKO_DESKTOP_SCREEN=1920x1080@60",
+		"NEKO_CAPTURE_AUDIO_CODEC=g722",
+	}
+
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("ToEnv() = %v, expected %v", env, expected)
+	}
+}
@@ -15,11 +15,26 @@ type BrowserPolicy struct {
 
 type BrowserPolicyContent struct {
 	Extensions     []BrowserPolicyExtension `json:"extensions"`
+	Bookmarks      []BrowserPolicyBookmark  `json:"bookmarks,omitempty"`
 	DeveloperTools bool                     `json:"developer_tools"`
 	PersistentData bool                     `json:"persistent_data"`
+	Homepage       string                   `json:"homepage,omitempty"` // opened on startup
+
+	Proxy *BrowserPolicyProxy `json:"-"` // for internal use, from room http proxy
+}
+
+type BrowserPolicyProxy struct {
+	Http    string
+	Https   string
+	NoProxy string
 }
 
 type BrowserPolicyExtension struct {
 	ID  string `json:"id"`
-	URL string `json:"url"`
+	URL string `json:"url"` // if empty, extension is installed from browser's store by its ID
+}
+
+type BrowserPolicyBookmark struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
 }
@@ -3,34 +3,89 @@ package types
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/m1k1o/neko-rooms/internal/config"
 )
 
 type RoomsConfig struct {
-	Connections    uint16   `json:"connections"`
-	NekoImages     []string `json:"neko_images"`
-	StorageEnabled bool     `json:"storage_enabled"`
-	UsesMux        bool     `json:"uses_mux"`
+	Connections     uint16   `json:"connections"`
+	NekoImages      []string `json:"neko_images"`
+	StorageEnabled  bool     `json:"storage_enabled"`
+	UsesMux         bool     `json:"uses_mux"`
+	ExecCommands    []string `json:"exec_commands"` // whitelisted commands, that can be run inside rooms
+	TerminalEnabled bool     `json:"terminal_enabled"`
+
+	Defaults RoomSettings `json:"defaults"` // used for values, that are not specified
 }
 
 type RoomEntry struct {
 	ID             string            `json:"id"`
+	UUID           string            `json:"uuid"` // stable across recreates
 	URL            string            `json:"url"`
 	Name           string            `json:"name"`
 	NekoImage      string            `json:"neko_image"`
+	Profile        RoomProfile       `json:"profile,omitempty"`
 	IsOutdated     bool              `json:"is_outdated"`
 	MaxConnections uint16            `json:"max_connections"` // 0 when using mux
 	Running        bool              `json:"running"`
+	Paused         bool              `json:"paused"` // frozen, keeps browser state
 	IsReady        bool              `json:"is_ready"`
+	CrashLooping   bool              `json:"crash_looping"`
+	Failed         bool              `json:"failed"`       // did not become ready in time after start
+	Broadcasting   bool              `json:"broadcasting"` // started using API
 	Status         string            `json:"status"`
 	Created        time.Time         `json:"created"`
 	Labels         map[string]string `json:"labels,omitempty"`
+	App            string            `json:"app,omitempty"` // app name, when running single app
+	Description    string            `json:"description,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Contact        string            `json:"contact,omitempty"`
+	Aliases        []string          `json:"aliases,omitempty"`  // additional room names, the room is accessible on
+	Transfer       *RoomTransfer     `json:"transfer,omitempty"` // effective policy enforced when the room was created
+	Expires        *time.Time        `json:"expires,omitempty"`  // room is removed (or stopped) afterwards
+	ExpireAction   RoomExpireAction  `json:"expire_action,omitempty"`
+	Demo           bool              `json:"demo,omitempty"`
+	Listed         bool              `json:"listed,omitempty"` // in public directory
+	Series         string            `json:"series,omitempty"`
+	SeriesExtra    bool              `json:"series_extra,omitempty"` // created by autoscaler
+	Template       string            `json:"template,omitempty"`     // created by deep link from this room
+	Priority       RoomPriority      `json:"priority,omitempty"`
+	LastActivity   *time.Time        `json:"last_activity,omitempty"` // when users were connected last time, if tracked
+	Notes          []RoomNote        `json:"notes,omitempty"`
 
 	ContainerLabels map[string]string `json:"-"` // for internal use
 }
 
+type RoomExpireAction string
+
+const (
+	RoomExpireRemove RoomExpireAction = "remove"
+	RoomExpireStop   RoomExpireAction = "stop"
+)
+
+type RoomBulkAction string
+
+const (
+	RoomBulkStart   RoomBulkAction = "start"
+	RoomBulkStop    RoomBulkAction = "stop"
+	RoomBulkRestart RoomBulkAction = "restart"
+	RoomBulkRemove  RoomBulkAction = "remove"
+)
+
+type RoomBulkRequest struct {
+	IDs    []string       `json:"ids"`
+	Action RoomBulkAction `json:"action"`
+}
+
+type RoomBulkResult struct {
+	ID     string `json:"id"`
+	Status int    `json:"status"` // http status of the same action on single room
+	Error  string `json:"error,omitempty"`
+}
+
 type MountType string
 
 const (
@@ -46,6 +101,12 @@ type RoomMount struct {
 	ContainerPath string    `json:"container_path"`
 }
 
+// seed archive, extracted to private mount when it is created
+type RoomSeed struct {
+	Archive       string `json:"archive"` // path to tar archive in templates storage
+	ContainerPath string `json:"container_path"`
+}
+
 type RoomResources struct {
 	CPUShares int64    `json:"cpu_shares"` // relative weight vs. other containers
 	NanoCPUs  int64    `json:"nano_cpus"`  // in units of 10^-9 CPUs
@@ -56,7 +117,9 @@ type RoomResources struct {
 }
 
 type RoomSettings struct {
-	ApiVersion int `json:"api_version"`
+	UUID       string      `json:"uuid,omitempty"` // generated when empty
+	ApiVersion int         `json:"api_version"`
+	Profile    RoomProfile `json:"profile,omitempty"` // empty for neko images
 
 	Name           string `json:"name"`
 	NekoImage      string `json:"neko_image"`
@@ -83,15 +146,125 @@ type RoomSettings struct {
 	Envs      map[string]string `json:"envs"`
 	Labels    map[string]string `json:"labels"`
 	Mounts    []RoomMount       `json:"mounts"`
+	Seeds     []RoomSeed        `json:"seeds,omitempty"`
 	Resources RoomResources     `json:"resources"`
 
-	Hostname string   `json:"hostname,omitempty"`
-	DNS      []string `json:"dns,omitempty"`
+	HttpProxy *RoomHttpProxy `json:"http_proxy,omitempty"` // global proxy from config when empty
+	Transfer  *RoomTransfer  `json:"transfer,omitempty"`   // global policy from config when empty
+
+	Hostname   string   `json:"hostname,omitempty"`   // container name when empty, FQDN is split to domainname
+	Domainname string   `json:"domainname,omitempty"` // not set when empty
+	DNS        []string `json:"dns,omitempty"`
+	DNSSearch  []string `json:"dns_search,omitempty"`
+	ExtraHosts []string `json:"extra_hosts,omitempty"` // in form of host:ip
 
 	BrowserPolicy *BrowserPolicy `json:"browser_policy,omitempty"`
+	App           *RoomApp       `json:"app,omitempty"`
+
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Contact     string   `json:"contact,omitempty"`
+
+	Aliases []string `json:"aliases,omitempty"`
+
+	Expires      *time.Time       `json:"expires,omitempty"`       // room is removed (or stopped) afterwards
+	TTL          int              `json:"ttl,omitempty"`           // in seconds, sets expires when room is created
+	ExpireAction RoomExpireAction `json:"expire_action,omitempty"` // remove when empty
+	Demo         bool             `json:"demo,omitempty"`          // created using demo token
+	Listed       bool             `json:"listed,omitempty"`        // in public directory
+
+	Series      string `json:"series,omitempty"`       // rooms of the same series are scaled by occupancy
+	SeriesExtra bool   `json:"series_extra,omitempty"` // created by autoscaler, removed when empty
+
+	Priority RoomPriority `json:"priority,omitempty"` // when starting rooms, normal when empty
+
+	IdleTimeout *int `json:"idle_timeout,omitempty"` // in seconds, overrides config, 0 disables idle shutdown
+
+	RestartPolicy string `json:"restart_policy,omitempty"` // always, unless-stopped (default), on-failure[:N] or no
+
+	Template string `json:"template,omitempty"` // name of room, that this room was cloned from by deep link
+
+	BrowserProfile string `json:"browser_profile,omitempty"` // cloned to private mount when created
+
+	Notes []RoomNote `json:"notes,omitempty"` // only when exported, restored when created
+}
+
+// admin note attached to a room
+type RoomNote struct {
+	ID     string    `json:"id"`
+	Time   time.Time `json:"time"`
+	Author string    `json:"author"`
+	Text   string    `json:"text"`
+}
+
+// rooms with higher priority are started first, when starts are staggered
+type RoomPriority string
+
+const (
+	RoomPriorityHigh   RoomPriority = "high"
+	RoomPriorityNormal RoomPriority = "normal"
+	RoomPriorityLow    RoomPriority = "low"
+)
+
+var RoomPriorities = []RoomPriority{
+	RoomPriorityHigh,
+	RoomPriorityNormal,
+	RoomPriorityLow,
+}
+
+type RoomHttpProxy struct {
+	Http    string `json:"http,omitempty"`
+	Https   string `json:"https,omitempty"`
+	NoProxy string `json:"no_proxy,omitempty"`
+}
+
+// clipboard and file transfer policy, nil values follow global policy from config
+type RoomTransfer struct {
+	Clipboard *bool `json:"clipboard,omitempty"`
+	Files     *bool `json:"files,omitempty"` // both upload and download
+}
+
+type QuickRoomRequest struct {
+	URL      string `json:"url"`
+	Lifetime int    `json:"lifetime,omitempty"` // in seconds, room is removed afterwards
+}
+
+type DirectoryRoom struct {
+	Name           string   `json:"name"`
+	Series         string   `json:"series,omitempty"` // only least full room of series is listed
+	Description    string   `json:"description,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Connections    uint32   `json:"connections"`
+	MaxConnections uint16   `json:"max_connections"` // 0 when not limited
+	JoinURL        string   `json:"join_url"`        // contains user password
+}
+
+type QuickRoom struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	URL       string     `json:"url"`
+	InviteURL string     `json:"invite_url"` // contains user password
+	Expires   *time.Time `json:"expires,omitempty"`
+}
+
+type RoomApp struct {
+	Name string   `json:"name"`
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
 }
 
 func (settings *RoomSettings) ToEnv(config *config.Room, ports PortSettings) ([]string, error) {
+	switch settings.Profile {
+	case "", NekoProfile:
+		// handled by api version
+	case LinuxserverProfile:
+		return settings.toEnvLinuxserver(), nil
+	case KasmProfile:
+		return settings.toEnvKasm(), nil
+	default:
+		return nil, fmt.Errorf("unsupported profile: %s", settings.Profile)
+	}
+
 	switch settings.ApiVersion {
 	case 2:
 		return settings.toEnvV2(config, ports), nil
@@ -103,6 +276,17 @@ func (settings *RoomSettings) ToEnv(config *config.Room, ports PortSettings) ([]
 }
 
 func (settings *RoomSettings) FromEnv(apiVersion int, envs []string) error {
+	switch settings.Profile {
+	case "", NekoProfile:
+		// handled by api version
+	case LinuxserverProfile:
+		return settings.fromEnvLinuxserver(envs)
+	case KasmProfile:
+		return settings.fromEnvKasm(envs)
+	default:
+		return fmt.Errorf("unsupported profile: %s", settings.Profile)
+	}
+
 	switch apiVersion {
 	case 2:
 		return settings.fromEnvV2(envs)
@@ -132,6 +316,24 @@ type RoomStats struct {
 
 	ControlProtection bool `json:"control_protection"`
 	ImplicitControl   bool `json:"implicit_control"`
+
+	OOMKills int `json:"oom_kills"` // since neko-rooms start
+}
+
+// webrtc statistics of a participant, as reported by neko metrics
+type RoomWebRTCPeer struct {
+	SessionID  string             `json:"session_id"`
+	Bitrate    float64            `json:"bitrate"`     // receiver estimated maximum, bits per second
+	PacketLoss float64            `json:"packet_loss"` // packets lost reported by receiver
+	RTT        float64            `json:"rtt"`         // receiver report delay, seconds
+	Jitter     float64            `json:"jitter"`
+	Metrics    map[string]float64 `json:"metrics"` // all reported webrtc metrics
+}
+
+type RoomWebRTCStats struct {
+	Image string           `json:"image"`
+	Time  time.Time        `json:"time"`
+	Peers []RoomWebRTCPeer `json:"peers"`
 }
 
 type RoomMember struct {
@@ -139,6 +341,20 @@ type RoomMember struct {
 	Name  string `json:"displayname"`
 	Admin bool   `json:"admin"`
 	Muted bool   `json:"muted"`
+
+	ConnectedSince *time.Time `json:"connected_since,omitempty"` // only api v3
+}
+
+type RoomSession struct {
+	RoomID   string `json:"room_id"`
+	RoomName string `json:"room_name"`
+	Owner    string `json:"owner,omitempty"` // room contact
+
+	ID             string     `json:"id"`
+	Name           string     `json:"displayname"`
+	Admin          bool       `json:"admin"`
+	HasControl     bool       `json:"has_control"`
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
 }
 
 type RoomEventAction string
@@ -149,6 +365,12 @@ const (
 	RoomEventReady     RoomEventAction = "ready"
 	RoomEventStopped   RoomEventAction = "stopped"
 	RoomEventDestroyed RoomEventAction = "destroyed"
+	RoomEventPaused    RoomEventAction = "paused"
+	RoomEventUnpaused  RoomEventAction = "unpaused"
+
+	RoomEventUnhealthy    RoomEventAction = "unhealthy"
+	RoomEventCrashLooping RoomEventAction = "crashlooping"
+	RoomEventFailed       RoomEventAction = "failed"
 )
 
 type RoomEvent struct {
@@ -158,23 +380,486 @@ type RoomEvent struct {
 	ContainerLabels map[string]string `json:"-"` // for internal use
 }
 
+type EventType string
+
+const (
+	EventRoom EventType = "room" // room lifecycle and health
+	EventPull EventType = "pull" // image pull progress
+)
+
+// Event of event stream, it is either room event or pull progress.
+type Event struct {
+	ID   string    `json:"id"` // epoch of the stream and sequence number, increasing with every event
+	Seq  uint64    `json:"-"`
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	RoomID string          `json:"room_id,omitempty"`
+	Name   string          `json:"name,omitempty"`
+	Action RoomEventAction `json:"action,omitempty"`
+
+	Pull *PullLayer `json:"pull,omitempty"`
+}
+
+type RoomAccess struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	IP       string    `json:"ip"`
+	Resource string    `json:"resource"`
+}
+
+type RoomCallbackStatus string
+
+const (
+	RoomCallbackCreated RoomCallbackStatus = "created" // room was not started
+	RoomCallbackReady   RoomCallbackStatus = "ready"
+	RoomCallbackFailed  RoomCallbackStatus = "failed"
+)
+
+// RoomCreateRequest is room settings with options of creation.
+type RoomCreateRequest struct {
+	RoomSettings
+
+	CallbackUrl string `json:"callback_url,omitempty"` // one-shot callback, when room becomes ready or fails
+}
+
+type RoomCallback struct {
+	Status   RoomCallbackStatus `json:"status"`
+	Error    string             `json:"error,omitempty"`
+	Entry    *RoomEntry         `json:"entry,omitempty"`
+	Settings *RoomSettings      `json:"settings,omitempty"`
+}
+
+type RoomLabelChange struct {
+	Key      string `json:"key"`
+	Current  string `json:"current,omitempty"`
+	Expected string `json:"expected,omitempty"`
+}
+
+type RoomMigration struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Changes []RoomLabelChange `json:"changes"`
+	Error   string            `json:"error,omitempty"`
+}
+
+type RoomSettingsChange struct {
+	Field    string `json:"field"`
+	Current  any    `json:"current"`
+	Proposed any    `json:"proposed"`
+	Recreate bool   `json:"recreate"`
+}
+
+type RoomSettingsDiff struct {
+	Changes  []RoomSettingsChange `json:"changes"`
+	Recreate bool                 `json:"recreate"`
+}
+
+type RoomAlertType string
+
+const (
+	RoomAlertCrashLoop RoomAlertType = "crashloop"
+	RoomAlertOOM       RoomAlertType = "oom"
+	RoomAlertWatchdog  RoomAlertType = "watchdog"
+	RoomAlertTiering   RoomAlertType = "tiering"
+	RoomAlertRule      RoomAlertType = "rule"
+	RoomAlertHook      RoomAlertType = "hook"
+	RoomAlertDoctor    RoomAlertType = "doctor"
+
+	RoomAlertDiskPressure RoomAlertType = "disk_pressure"
+)
+
+type RoomAlert struct {
+	Type    RoomAlertType `json:"type"`
+	ID      string        `json:"id,omitempty"`
+	Name    string        `json:"name,omitempty"`
+	Time    time.Time     `json:"time"`
+	Message string        `json:"message"`
+	Contact string        `json:"contact,omitempty"` // room owner, that should be notified
+	Logs    []string      `json:"logs,omitempty"`
+
+	Rule     string `json:"rule,omitempty"`     // kind of alert rule, that fired
+	Resolved bool   `json:"resolved,omitempty"` // condition of alert rule does not hold anymore
+}
+
+type RoomHookType string
+
+const (
+	RoomHookPreCreate       RoomHookType = "pre_create"
+	RoomHookPreCreateFailed RoomHookType = "pre_create_failed"
+	RoomHookPostStart       RoomHookType = "post_start"
+)
+
+type DoctorIssueType string
+
+const (
+	DoctorMissingRouter DoctorIssueType = "missing_router" // room is unreachable
+	DoctorRuleMismatch  DoctorIssueType = "rule_mismatch"  // router does not match room labels
+	DoctorRouterError   DoctorIssueType = "router_error"   // e.g. router defined multiple times
+	DoctorOrphanRouter  DoctorIssueType = "orphan_router"  // router without running room
+	DoctorDuplicateRule DoctorIssueType = "duplicate_rule" // more routers with the same rule
+	DoctorTraefikApi    DoctorIssueType = "traefik_api"    // routers could not be checked
+	DoctorMissingRoutes DoctorIssueType = "missing_routes" // room has no routing labels
+	DoctorPortCollision DoctorIssueType = "port_collision" // webrtc ports used by more rooms
+	DoctorPortRange     DoctorIssueType = "port_range"     // webrtc ports outside of configured range
+	DoctorVersionSkew   DoctorIssueType = "version_skew"   // labels or image do not match current version
+	DoctorCanaryMissing DoctorIssueType = "canary_missing" // canary room of rollout is gone or not using canary image
+	DoctorDiskPressure  DoctorIssueType = "disk_pressure"  // new rooms are not created
+)
+
+type DoctorSeverity string
+
+const (
+	DoctorCritical DoctorSeverity = "critical"
+	DoctorWarning  DoctorSeverity = "warning"
+	DoctorInfo     DoctorSeverity = "info"
+)
+
+// Priority returns lower number for more severe issues.
+func (s DoctorSeverity) Priority() int {
+	switch s {
+	case DoctorCritical:
+		return 0
+	case DoctorWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+type DoctorIssue struct {
+	Type       DoctorIssueType `json:"type"`
+	Severity   DoctorSeverity  `json:"severity"`
+	Router     string          `json:"router,omitempty"`
+	Room       string          `json:"room,omitempty"`
+	ID         string          `json:"id,omitempty"`
+	Message    string          `json:"message"`
+	Suggestion string          `json:"suggestion,omitempty"`
+	Repairable bool            `json:"repairable"` // by recreating the room
+	Error      string          `json:"error,omitempty"`
+}
+
+type Doctor struct {
+	Time   time.Time     `json:"time"`
+	Issues []DoctorIssue `json:"issues"`
+}
+
+type RoomCloneRequest struct {
+	Name    string `json:"name,omitempty"` // generated by name strategy, when empty
+	Volumes bool   `json:"volumes"`        // copy private storage
+	Start   bool   `json:"start"`
+
+	Template bool `json:"template,omitempty"` // remember source room as template of the new room
+}
+
+type RoomRenameRequest struct {
+	Name string `json:"name"`
+}
+
+type RoomImport struct {
+	Project     string        `json:"project"`
+	Service     string        `json:"service"`
+	ContainerID string        `json:"container_id"`
+	Running     bool          `json:"running"`
+	Settings    *RoomSettings `json:"settings,omitempty"` // proposed settings of managed room
+	Warnings    []string      `json:"warnings,omitempty"` // what is not converted
+	ID          string        `json:"id,omitempty"`       // of created room
+	Error       string        `json:"error,omitempty"`
+}
+
+type RoomImportRequest struct {
+	Project  string            `json:"project"`
+	Services []string          `json:"services,omitempty"` // all services, when empty
+	Names    map[string]string `json:"names,omitempty"`    // room names by service, service name is used by default
+	Remove   bool              `json:"remove"`             // remove original containers, otherwise they are only stopped
+}
+
+type RoomExecRequest struct {
+	Command string `json:"command"` // name of whitelisted command
+}
+
+type RoomExecResult struct {
+	Command  string  `json:"command"`
+	ExitCode int     `json:"exit_code"`
+	Output   string  `json:"output"`   // stdout and stderr combined
+	Duration float64 `json:"duration"` // in seconds
+}
+
+type RoomTerminalSize struct {
+	Cols uint `json:"cols"`
+	Rows uint `json:"rows"`
+}
+
+// interactive terminal attached to a shell inside the room
+type RoomTerminalSession struct {
+	Actor  string // recorded together with the session
+	Stdin  io.Reader
+	Stdout io.Writer
+	Resize <-chan RoomTerminalSize
+}
+
+// room context sent to lifecycle hooks
+type RoomHook struct {
+	Hook      RoomHookType      `json:"hook"`
+	ID        string            `json:"id,omitempty"` // not created yet in pre_create
+	UUID      string            `json:"uuid"`
+	Name      string            `json:"name"`
+	URL       string            `json:"url,omitempty"`
+	NekoImage string            `json:"neko_image"`
+	Contact   string            `json:"contact,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// state of configured alert rule condition
+type RoomAlertState struct {
+	Rule    string    `json:"rule"`
+	Subject string    `json:"subject,omitempty"` // room name or path, empty for host
+	ID      string    `json:"id,omitempty"`
+	Message string    `json:"message"`
+	Since   time.Time `json:"since"`  // condition holds since
+	Firing  bool      `json:"firing"` // condition holds long enough
+
+	Notified      *time.Time `json:"notified,omitempty"`
+	SilencedUntil *time.Time `json:"silenced_until,omitempty"`
+}
+
+type RoomAlertSilence struct {
+	Rule     string `json:"rule"`
+	Subject  string `json:"subject,omitempty"` // empty silences rule for all subjects
+	Duration int    `json:"duration"`          // in seconds
+}
+
+type RoomLogLine struct {
+	ID   string    `json:"id"`
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Line string    `json:"line"`
+}
+
+type RoomQueueItem struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Position int       `json:"position"`
+	Created  time.Time `json:"created"`
+}
+
+type RoomArchive struct {
+	Archive string    `json:"archive"` // file name in archives storage
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+}
+
+// result of restore drill of an archive
+type RoomArchiveVerification struct {
+	Archive  string    `json:"archive"`
+	Room     string    `json:"room,omitempty"`
+	Valid    bool      `json:"valid"`
+	Errors   []string  `json:"errors"`
+	Files    int       `json:"files"` // extracted from private storage
+	Size     int64     `json:"size"`  // extracted bytes
+	Verified time.Time `json:"verified"`
+}
+
+// right-sized resource limits by sustained usage of a room
+type RoomTiering struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Contact string `json:"contact,omitempty"`
+
+	Memory       int64 `json:"memory"`         // current limit
+	NanoCPUs     int64 `json:"nano_cpus"`      // current limit
+	PeakMemory   int64 `json:"peak_memory"`    // in the window
+	PeakNanoCPUs int64 `json:"peak_nano_cpus"` // in the window
+	NewMemory    int64 `json:"new_memory"`
+	NewNanoCPUs  int64 `json:"new_nano_cpus"`
+
+	Applied bool      `json:"applied"`
+	Time    time.Time `json:"time"`
+}
+
+// snapshot of room's private mount, that can be cloned to new rooms
+type BrowserProfile struct {
+	Name          string    `json:"name"`
+	Owner         string    `json:"owner"`
+	Room          string    `json:"room"` // name of the room it was saved from
+	ContainerPath string    `json:"container_path"`
+	Size          int64     `json:"size"`
+	Created       time.Time `json:"created"`
+}
+
+// docker image of room's container, that can be used as neko image of new rooms
+type RoomSnapshot struct {
+	Image   string    `json:"image"` // repository and tag
+	ID      string    `json:"id"`
+	Room    string    `json:"room"`   // name of the room it was taken from
+	Source  string    `json:"source"` // neko image of the room
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+}
+
+type RoomSnapshotRequest struct {
+	Tag string `json:"tag,omitempty"` // room name and time, when empty
+}
+
+type BrowserProfileRequest struct {
+	RoomID        string `json:"room_id"`
+	Name          string `json:"name"`
+	ContainerPath string `json:"container_path"` // must match private mount
+}
+
+type RoomBroadcast struct {
+	IsActive bool   `json:"is_active"`
+	URL      string `json:"url,omitempty"` // with stream key masked
+}
+
+type RoomBroadcastTarget struct {
+	URL string `json:"url"`
+	Key string `json:"key,omitempty"` // appended to url
+}
+
+type ComposeOptions struct {
+	IncludeStopped bool   // stopped rooms are exported as well
+	SecretsEnvFile bool   // secrets are replaced by env_file reference
+	PinDigests     bool   // images are pinned to their repo digests
+	Version        string // compose file version, empty for compose specification
+}
+
+type BootstrapFormat string
+
+const (
+	BootstrapCompose   BootstrapFormat = "compose"
+	BootstrapCloudInit BootstrapFormat = "cloud-init"
+)
+
+type RoomRolloutStatus string
+
+const (
+	RoomRolloutCanary     RoomRolloutStatus = "canary"
+	RoomRolloutPromoted   RoomRolloutStatus = "promoted"
+	RoomRolloutRolledBack RoomRolloutStatus = "rolled_back"
+)
+
+type RoomRolloutRequest struct {
+	FromImage string            `json:"from_image"`
+	Image     string            `json:"image"`   // canary image
+	Percent   int               `json:"percent"` // of rooms using source image
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+type RoomRolloutRoom struct {
+	UUID         string `json:"uuid"`
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	NekoImage    string `json:"neko_image,omitempty"`
+	Running      bool   `json:"running"`
+	IsReady      bool   `json:"is_ready"`
+	CrashLooping bool   `json:"crash_looping"`
+	OOMKills     int    `json:"oom_kills"`
+	Error        string `json:"error,omitempty"`
+}
+
+type RoomRollout struct {
+	ID        string            `json:"id"`
+	FromImage string            `json:"from_image"`
+	Image     string            `json:"image"`
+	Percent   int               `json:"percent"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Status    RoomRolloutStatus `json:"status"`
+	Created   time.Time         `json:"created"`
+	Rooms     []RoomRolloutRoom `json:"rooms"` // canary rooms
+}
+
 var ErrRoomNotFound = fmt.Errorf("room not found")
+var ErrRoomNameTaken = fmt.Errorf("room with this name already exists")
+var ErrRoomKeptStopped = fmt.Errorf("room is kept stopped")
+var ErrRolloutNotFound = fmt.Errorf("rollout not found")
+var ErrNotEnoughCapacity = fmt.Errorf("not enough capacity")
+var ErrProfileNotFound = fmt.Errorf("profile not found")
+var ErrProfileForbidden = fmt.Errorf("profile is owned by someone else")
+var ErrProfileQuota = fmt.Errorf("profile quota exceeded")
+var ErrSnapshotNotFound = fmt.Errorf("snapshot not found")
+var ErrArchiveNotFound = fmt.Errorf("archive not found")
+var ErrSnapshotInUse = fmt.Errorf("snapshot is used by rooms")
+var ErrNoteNotFound = fmt.Errorf("note not found")
+var ErrExecNotAllowed = fmt.Errorf("command is not whitelisted")
+var ErrExecTimeout = fmt.Errorf("command did not finish in time")
+var ErrTerminalDisabled = fmt.Errorf("terminal is disabled")
 
 type RoomManager interface {
 	Config() RoomsConfig
+	Features() []string
+	DockerVersion(ctx context.Context) (string, error)
+	Diagnostics() RoomDiagnostics
 	List(ctx context.Context, labels map[string]string) ([]RoomEntry, error)
-	ExportAsDockerCompose(ctx context.Context) ([]byte, error)
+	ExportAsDockerCompose(ctx context.Context, opts ComposeOptions) ([]byte, error)
+	Bootstrap(format BootstrapFormat) ([]byte, error)
+	Migrations(ctx context.Context) ([]RoomMigration, error)
+	Migrate(ctx context.Context) ([]RoomMigration, error)
+	SearchLogs(ctx context.Context, query string, since string) ([]RoomLogLine, error)
 
 	Create(ctx context.Context, settings RoomSettings) (string, error)
 	GetEntry(ctx context.Context, id string) (*RoomEntry, error)
 	GetEntryByName(ctx context.Context, name string) (*RoomEntry, error)
 	GetSettings(ctx context.Context, id string) (*RoomSettings, error)
+	UpdateResources(ctx context.Context, id string, resources RoomResources) error
 	GetStats(ctx context.Context, id string) (*RoomStats, error)
+	GetWebRTCStats(ctx context.Context, id string) (*RoomWebRTCStats, error)
+	GetWebRTCStatsHistory(ctx context.Context, id string) ([]RoomWebRTCStats, error)
 	Remove(ctx context.Context, id string) error
+	SetAliases(ctx context.Context, id string, aliases []string) (string, error)
+	Archive(ctx context.Context, id string) (*RoomArchive, error)
+	Restore(ctx context.Context, archive string) (string, error)
+	VerifyArchive(archive string) (*RoomArchiveVerification, error)
+	ArchiveVerifications() []RoomArchiveVerification
+	ListArchives() ([]RoomArchive, error)
+	OpenArchive(name string) (*os.File, string, error)
+
+	ExportRooms(ctx context.Context, w io.Writer) error
+	Backup(ctx context.Context, w io.Writer, storage bool) error
+	Tiering() []RoomTiering
+	Doctor(ctx context.Context) (*Doctor, error)
+	ComposeImports(ctx context.Context, project string) ([]RoomImport, error)
+	ImportCompose(ctx context.Context, request RoomImportRequest) ([]RoomImport, error)
+	DoctorRepair(ctx context.Context) ([]DoctorIssue, error)
+	Alerts() []RoomAlertState
+	SilenceAlert(silence RoomAlertSilence) error
+
+	Exec(ctx context.Context, id string, command string) (*RoomExecResult, error)
+	Terminal(ctx context.Context, id string, session RoomTerminalSession) error
+
+	GetNotes(ctx context.Context, id string) ([]RoomNote, error)
+	AddNote(ctx context.Context, id string, author string, text string) (*RoomNote, error)
+	RemoveNote(ctx context.Context, id string, noteId string) error
+
+	ListProfiles(owner string) ([]BrowserProfile, error)
+	GetProfile(name string) (*BrowserProfile, error)
+	SaveProfile(ctx context.Context, id string, request BrowserProfileRequest, owner string) (*BrowserProfile, error)
+	RemoveProfile(name string, owner string) error
+
+	ListSnapshots(ctx context.Context) ([]RoomSnapshot, error)
+	Snapshot(ctx context.Context, id string, request RoomSnapshotRequest) (*RoomSnapshot, error)
+	RemoveSnapshot(ctx context.Context, tag string) error
+
+	GetBroadcast(ctx context.Context, id string) (*RoomBroadcast, error)
+	StartBroadcast(ctx context.Context, id string, target RoomBroadcastTarget) error
+	StopBroadcast(ctx context.Context, id string) error
+
+	Rollouts(ctx context.Context) []RoomRollout
+	GetRollout(ctx context.Context, id string) (*RoomRollout, error)
+	CreateRollout(ctx context.Context, request RoomRolloutRequest) (*RoomRollout, error)
+	PromoteRollout(ctx context.Context, id string) (*RoomRollout, error)
+	RollbackRollout(ctx context.Context, id string) (*RoomRollout, error)
 
 	Start(ctx context.Context, id string) error
+	LazyStart(ctx context.Context, id string) error
 	Stop(ctx context.Context, id string) error
 	Restart(ctx context.Context, id string) error
+	Pause(ctx context.Context, id string) error
+	Clone(ctx context.Context, id string, request RoomCloneRequest) (string, error)
+	Rename(ctx context.Context, id string, name string) (string, error)
+	Unpause(ctx context.Context, id string) error
+
+	DiskPressure() bool
 
 	EventsLoopStart()
 	EventsLoopStop() error
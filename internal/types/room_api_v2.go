@@ -1,7 +1,6 @@
 package types
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -33,77 +32,58 @@ var blacklistedEnvsV2 = []string{
 }
 
 func (settings *RoomSettings) toEnvV2(config *config.Room, ports PortSettings) []string {
-	env := []string{
-		fmt.Sprintf("NEKO_BIND=:%d", ports.FrontendPort),
-		"NEKO_ICELITE=true",
-		"NEKO_PROXY=true",
-
-		// from settings
-		fmt.Sprintf("NEKO_PASSWORD=%s", settings.UserPass),
-		fmt.Sprintf("NEKO_PASSWORD_ADMIN=%s", settings.AdminPass),
-		fmt.Sprintf("NEKO_SCREEN=%s", settings.Screen),
-		fmt.Sprintf("NEKO_MAX_FPS=%d", settings.VideoMaxFPS),
-	}
-
-	if config.Mux {
-		env = append(env,
-			fmt.Sprintf("NEKO_UDPMUX=%d", ports.EprMin),
-			fmt.Sprintf("NEKO_TCPMUX=%d", ports.EprMin),
-		)
-	} else {
-		env = append(env,
-			fmt.Sprintf("NEKO_EPR=%d-%d", ports.EprMin, ports.EprMax),
-		)
-	}
-
-	// optional nat mapping
-	if len(config.NAT1To1IPs) > 0 {
-		env = append(env, fmt.Sprintf("NEKO_NAT1TO1=%s", strings.Join(config.NAT1To1IPs, ",")))
-	}
-
-	if settings.ControlProtection {
-		env = append(env, "NEKO_CONTROL_PROTECTION=true")
-	}
+	env := newEnvBuilder(blacklistedEnvsV2).
+		Setf("NEKO_BIND", ":%d", ports.FrontendPort).
+		Set("NEKO_PROXY", "true")
 
-	if settings.ImplicitControl {
-		env = append(env, "NEKO_IMPLICIT_CONTROL=true")
-	}
+	settings.webrtcEnvV2(env, config, ports)
+	settings.authEnvV2(env)
+	settings.screenEnvV2(env)
+	settings.videoEnvV2(env)
+	settings.audioEnvV2(env)
 
-	if settings.VideoCodec != "VP8" { // VP8 is default
-		env = append(env, fmt.Sprintf("NEKO_VIDEO_CODEC=%s", strings.ToLower(settings.VideoCodec)))
-	}
-
-	if settings.VideoBitrate != 0 {
-		env = append(env, fmt.Sprintf("NEKO_VIDEO_BITRATE=%d", settings.VideoBitrate))
-	}
+	return env.User(settings.Envs).Env()
+}
 
-	if settings.VideoPipeline != "" {
-		env = append(env, fmt.Sprintf("NEKO_VIDEO=%s", settings.VideoPipeline))
-	}
+func (settings *RoomSettings) webrtcEnvV2(env *envBuilder, config *config.Room, ports PortSettings) {
+	env.Set("NEKO_ICELITE", "true")
 
-	if settings.AudioCodec != "OPUS" { // OPUS is default
-		env = append(env, fmt.Sprintf("NEKO_AUDIO_CODEC=%s", strings.ToLower(settings.AudioCodec)))
+	if config.Mux {
+		env.Setf("NEKO_UDPMUX", "%d", ports.EprMin)
+		env.Setf("NEKO_TCPMUX", "%d", ports.EprMin)
+	} else {
+		env.Setf("NEKO_EPR", "%d-%d", ports.EprMin, ports.EprMax)
 	}
 
-	if settings.AudioBitrate != 0 {
-		env = append(env, fmt.Sprintf("NEKO_AUDIO_BITRATE=%d", settings.AudioBitrate))
-	}
+	// optional nat mapping
+	env.SetIf(len(config.NAT1To1IPs) > 0, "NEKO_NAT1TO1", strings.Join(config.NAT1To1IPs, ","))
+}
 
-	if settings.AudioPipeline != "" {
-		env = append(env, fmt.Sprintf("NEKO_AUDIO=%s", settings.AudioPipeline))
-	}
+func (settings *RoomSettings) authEnvV2(env *envBuilder) {
+	env.Set("NEKO_PASSWORD", settings.UserPass)
+	env.Set("NEKO_PASSWORD_ADMIN", settings.AdminPass)
+	env.SetIf(settings.ControlProtection, "NEKO_CONTROL_PROTECTION", "true")
+	env.SetIf(settings.ImplicitControl, "NEKO_IMPLICIT_CONTROL", "true")
+}
 
-	if settings.BroadcastPipeline != "" {
-		env = append(env, fmt.Sprintf("NEKO_BROADCAST_PIPELINE=%s", settings.BroadcastPipeline))
-	}
+func (settings *RoomSettings) screenEnvV2(env *envBuilder) {
+	env.Set("NEKO_SCREEN", settings.Screen)
+	env.Setf("NEKO_MAX_FPS", "%d", settings.VideoMaxFPS)
+}
 
-	for key, val := range settings.Envs {
-		if in, _ := utils.ArrayIn(key, blacklistedEnvsV2); !in {
-			env = append(env, fmt.Sprintf("%s=%s", key, val))
-		}
-	}
+func (settings *RoomSettings) videoEnvV2(env *envBuilder) {
+	// VP8 is default
+	env.SetIf(settings.VideoCodec != "VP8", "NEKO_VIDEO_CODEC", strings.ToLower(settings.VideoCodec))
+	env.SetIf(settings.VideoBitrate != 0, "NEKO_VIDEO_BITRATE", strconv.Itoa(settings.VideoBitrate))
+	env.SetIf(settings.VideoPipeline != "", "NEKO_VIDEO", settings.VideoPipeline)
+	env.SetIf(settings.BroadcastPipeline != "", "NEKO_BROADCAST_PIPELINE", settings.BroadcastPipeline)
+}
 
-	return env
+func (settings *RoomSettings) audioEnvV2(env *envBuilder) {
+	// OPUS is default
+	env.SetIf(settings.AudioCodec != "OPUS", "NEKO_AUDIO_CODEC", strings.ToLower(settings.AudioCodec))
+	env.SetIf(settings.AudioBitrate != 0, "NEKO_AUDIO_BITRATE", strconv.Itoa(settings.AudioBitrate))
+	env.SetIf(settings.AudioPipeline != "", "NEKO_AUDIO", settings.AudioPipeline)
 }
 
 func (settings *RoomSettings) fromEnvV2(envs []string) error {
@@ -1,7 +1,6 @@
 package types
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -39,80 +38,63 @@ var blacklistedEnvsV3 = []string{
 }
 
 func (settings *RoomSettings) toEnvV3(config *config.Room, ports PortSettings) []string {
-	env := []string{
-		fmt.Sprintf("NEKO_SERVER_BIND=:%d", ports.FrontendPort),
-		"NEKO_WEBRTC_ICELITE=true",
-		"NEKO_SERVER_PROXY=true",
-
-		// from settings
-		"NEKO_MEMBER_PROVIDER=multiuser",
-		fmt.Sprintf("NEKO_MEMBER_MULTIUSER_USER_PASSWORD=%s", settings.UserPass),
-		fmt.Sprintf("NEKO_MEMBER_MULTIUSER_ADMIN_PASSWORD=%s", settings.AdminPass),
-		fmt.Sprintf("NEKO_SESSION_API_TOKEN=%s", settings.AdminPass), // TODO: should be random and saved somewhere
-		fmt.Sprintf("NEKO_DESKTOP_SCREEN=%s", settings.Screen),
-		//fmt.Sprintf("NEKO_MAX_FPS=%d", settings.VideoMaxFPS), // TODO: not supported yet
-	}
+	env := newEnvBuilder(blacklistedEnvsV3).
+		Setf("NEKO_SERVER_BIND", ":%d", ports.FrontendPort).
+		Set("NEKO_SERVER_PROXY", "true")
 
-	if config.Mux {
-		env = append(env,
-			fmt.Sprintf("NEKO_WEBRTC_UDPMUX=%d", ports.EprMin),
-			fmt.Sprintf("NEKO_WEBRTC_TCPMUX=%d", ports.EprMin),
-		)
-	} else {
-		env = append(env,
-			fmt.Sprintf("NEKO_WEBRTC_EPR=%d-%d", ports.EprMin, ports.EprMax),
-		)
-	}
-
-	// optional nat mapping
-	if len(config.NAT1To1IPs) > 0 {
-		env = append(env, fmt.Sprintf("NEKO_WEBRTC_NAT1TO1=%s", strings.Join(config.NAT1To1IPs, ",")))
-	}
+	settings.webrtcEnvV3(env, config, ports)
+	settings.authEnvV3(env)
+	settings.screenEnvV3(env)
+	settings.videoEnvV3(env)
+	settings.audioEnvV3(env)
 
-	//if settings.ControlProtection {
-	//	env = append(env, "NEKO_CONTROL_PROTECTION=true") // TODO: not supported yet
-	//}
+	return env.User(settings.Envs).Env()
+}
 
-	// implicit control - enabled by default
-	if !settings.ImplicitControl {
-		env = append(env, "NEKO_SESSION_IMPLICIT_HOSTING=false")
-	}
+func (settings *RoomSettings) webrtcEnvV3(env *envBuilder, config *config.Room, ports PortSettings) {
+	env.Set("NEKO_WEBRTC_ICELITE", "true")
 
-	if settings.VideoCodec != "VP8" { // VP8 is default
-		env = append(env, fmt.Sprintf("NEKO_CAPTURE_VIDEO_CODEC=%s", strings.ToLower(settings.VideoCodec)))
+	if config.Mux {
+		env.Setf("NEKO_WEBRTC_UDPMUX", "%d", ports.EprMin)
+		env.Setf("NEKO_WEBRTC_TCPMUX", "%d", ports.EprMin)
+	} else {
+		env.Setf("NEKO_WEBRTC_EPR", "%d-%d", ports.EprMin, ports.EprMax)
 	}
 
-	//if settings.VideoBitrate != 0 {
-	//	env = append(env, fmt.Sprintf("NEKO_VIDEO_BITRATE=%d", settings.VideoBitrate)) // TODO: not supported yet
-	//}
-
-	if settings.VideoPipeline != "" {
-		env = append(env, fmt.Sprintf("NEKO_CAPTURE_VIDEO_PIPELINES=%s", settings.VideoPipeline)) // TOOD: multiple pipelines, as JSON
-	}
+	// optional nat mapping
+	env.SetIf(len(config.NAT1To1IPs) > 0, "NEKO_WEBRTC_NAT1TO1", strings.Join(config.NAT1To1IPs, ","))
+}
 
-	if settings.AudioCodec != "OPUS" { // OPUS is default
-		env = append(env, fmt.Sprintf("NEKO_CAPTURE_AUDIO_CODEC=%s", strings.ToLower(settings.AudioCodec)))
-	}
+func (settings *RoomSettings) authEnvV3(env *envBuilder) {
+	env.Set("NEKO_MEMBER_PROVIDER", "multiuser")
+	env.Set("NEKO_MEMBER_MULTIUSER_USER_PASSWORD", settings.UserPass)
+	env.Set("NEKO_MEMBER_MULTIUSER_ADMIN_PASSWORD", settings.AdminPass)
+	env.Set("NEKO_SESSION_API_TOKEN", settings.AdminPass) // TODO: should be random and saved somewhere
 
-	//if settings.AudioBitrate != 0 {
-	//	env = append(env, fmt.Sprintf("NEKO_AUDIO_BITRATE=%d", settings.AudioBitrate)) // TODO: not supported yet
-	//}
+	// TODO: control protection not supported yet
 
-	if settings.AudioPipeline != "" {
-		env = append(env, fmt.Sprintf("NEKO_CAPTURE_AUDIO_PIPELINE=%s", settings.AudioPipeline))
-	}
+	// implicit control - enabled by default
+	env.SetIf(!settings.ImplicitControl, "NEKO_SESSION_IMPLICIT_HOSTING", "false")
+}
 
-	if settings.BroadcastPipeline != "" {
-		env = append(env, fmt.Sprintf("NEKO_CAPTURE_BROADCAST_PIPELINE=%s", settings.BroadcastPipeline))
-	}
+func (settings *RoomSettings) screenEnvV3(env *envBuilder) {
+	env.Set("NEKO_DESKTOP_SCREEN", settings.Screen)
+	// TODO: max fps not supported yet
+}
 
-	for key, val := range settings.Envs {
-		if in, _ := utils.ArrayIn(key, blacklistedEnvsV3); !in {
-			env = append(env, fmt.Sprintf("%s=%s", key, val))
-		}
-	}
+func (settings *RoomSettings) videoEnvV3(env *envBuilder) {
+	// VP8 is default
+	env.SetIf(settings.VideoCodec != "VP8", "NEKO_CAPTURE_VIDEO_CODEC", strings.ToLower(settings.VideoCodec))
+	// TODO: video bitrate not supported yet
+	env.SetIf(settings.VideoPipeline != "", "NEKO_CAPTURE_VIDEO_PIPELINES", settings.VideoPipeline) // TOOD: multiple pipelines, as JSON
+	env.SetIf(settings.BroadcastPipeline != "", "NEKO_CAPTURE_BROADCAST_PIPELINE", settings.BroadcastPipeline)
+}
 
-	return env
+func (settings *RoomSettings) audioEnvV3(env *envBuilder) {
+	// OPUS is default
+	env.SetIf(settings.AudioCodec != "OPUS", "NEKO_CAPTURE_AUDIO_CODEC", strings.ToLower(settings.AudioCodec))
+	// TODO: audio bitrate not supported yet
+	env.SetIf(settings.AudioPipeline != "", "NEKO_CAPTURE_AUDIO_PIPELINE", settings.AudioPipeline)
 }
 
 func (settings *RoomSettings) fromEnvV3(envs []string) error {
@@ -0,0 +1,177 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/m1k1o/neko-rooms/internal/utils"
+)
+
+//
+// compatibility profiles for non-neko images
+//
+
+type RoomProfile string
+
+const (
+	// neko images, configured using api version specific envs
+	NekoProfile RoomProfile = "neko"
+	// linuxserver.io images (e.g. linuxserver/webtop)
+	LinuxserverProfile RoomProfile = "linuxserver"
+	// kasmweb workspace images (e.g. kasmweb/chrome)
+	KasmProfile RoomProfile = "kasm"
+)
+
+var RoomProfiles = []RoomProfile{
+	NekoProfile,
+	LinuxserverProfile,
+	KasmProfile,
+}
+
+// IsNeko returns true for neko images, empty profile is treated as neko.
+func (profile RoomProfile) IsNeko() bool {
+	return profile == "" || profile == NekoProfile
+}
+
+// FrontendPort returns port where the web client is served inside the container.
+func (profile RoomProfile) FrontendPort() uint16 {
+	switch profile {
+	case LinuxserverProfile:
+		return 3000
+	case KasmProfile:
+		return 6901
+	default:
+		return 8080
+	}
+}
+
+// FrontendScheme returns scheme of the web client inside the container.
+func (profile RoomProfile) FrontendScheme() string {
+	// kasm images serve its client only using self-signed certificate
+	if profile == KasmProfile {
+		return "https"
+	}
+
+	return "http"
+}
+
+var blacklistedEnvsLinuxserver = []string{
+	// ignore bunch of default envs
+	"PATH",
+	"HOME",
+	"LANGUAGE",
+	"LANG",
+	"TERM",
+	"S6_CMD_WAIT_FOR_SERVICES_MAXTIME",
+	"S6_VERBOSITY",
+	"S6_STAGE2_HOOK",
+	"VIRTUAL_ENV",
+	"DISPLAY",
+	"PERL5LIB",
+	"TITLE",
+	"START_DOCKER",
+	"PULSE_RUNTIME_PATH",
+	"NVIDIA_DRIVER_CAPABILITIES",
+	"LSIO_FIRST_PARTY",
+
+	// ignore bunch of envs managed by neko-rooms
+	"PUID",
+	"PGID",
+	"CUSTOM_USER",
+}
+
+func (settings *RoomSettings) toEnvLinuxserver() []string {
+	return newEnvBuilder(blacklistedEnvsLinuxserver).
+		Set("PUID", "1000").
+		Set("PGID", "1000").
+		// from settings
+		Set("CUSTOM_USER", "neko").
+		Set("PASSWORD", settings.UserPass).
+		User(settings.Envs).
+		Env()
+}
+
+func (settings *RoomSettings) fromEnvLinuxserver(envs []string) error {
+	settings.Envs = map[string]string{}
+
+	for _, env := range envs {
+		r := strings.SplitN(env, "=", 2)
+		key, val := r[0], r[1]
+
+		switch key {
+		case "PASSWORD":
+			settings.UserPass = val
+		default:
+			if in, _ := utils.ArrayIn(key, blacklistedEnvsLinuxserver); !in {
+				settings.Envs[key] = val
+			}
+		}
+	}
+
+	return nil
+}
+
+var blacklistedEnvsKasm = []string{
+	// ignore bunch of default envs
+	"PATH",
+	"HOME",
+	"LANG",
+	"LANGUAGE",
+	"LC_ALL",
+	"TERM",
+	"DISPLAY",
+	"VNC_PORT",
+	"NO_VNC_PORT",
+	"STARTUPDIR",
+	"INST_SCRIPTS",
+	"KASM_VNC_PATH",
+	"DEBIAN_FRONTEND",
+	"DISTRO",
+	"KASM_USER",
+	"SINGLE_APPLICATION",
+	"LD_LIBRARY_PATH",
+	"PULSE_RUNTIME_PATH",
+	"SDL_GAMECONTROLLERCONFIG",
+	"NVIDIA_DRIVER_CAPABILITIES",
+	"OMP_WAIT_POLICY",
+	"OPENCV_OPENCL_RUNTIME",
+	"SHELL",
+	"USER",
+
+	// ignore bunch of envs managed by neko-rooms
+	"VNC_RESOLUTION",
+}
+
+func (settings *RoomSettings) toEnvKasm() []string {
+	env := newEnvBuilder(blacklistedEnvsKasm).
+		Set("VNC_PW", settings.UserPass)
+
+	// kasm expects resolution without refresh rate
+	if settings.Screen != "" {
+		resolution := strings.SplitN(settings.Screen, "@", 2)[0]
+		env.Set("VNC_RESOLUTION", resolution)
+	}
+
+	return env.User(settings.Envs).Env()
+}
+
+func (settings *RoomSettings) fromEnvKasm(envs []string) error {
+	settings.Envs = map[string]string{}
+
+	for _, env := range envs {
+		r := strings.SplitN(env, "=", 2)
+		key, val := r[0], r[1]
+
+		switch key {
+		case "VNC_PW":
+			settings.UserPass = val
+		case "VNC_RESOLUTION":
+			settings.Screen = val
+		default:
+			if in, _ := utils.ArrayIn(key, blacklistedEnvsKasm); !in {
+				settings.Envs[key] = val
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package types
+
+import (
+	"context"
+	"fmt"
+)
+
+type SetupStep string
+
+const (
+	SetupStepDocker       SetupStep = "docker"
+	SetupStepNetwork      SetupStep = "network"
+	SetupStepDomain       SetupStep = "domain"
+	SetupStepReachability SetupStep = "reachability"
+	SetupStepAdmin        SetupStep = "admin"
+)
+
+// SetupSteps in order, in which they must be completed.
+var SetupSteps = []SetupStep{
+	SetupStepDocker,
+	SetupStepNetwork,
+	SetupStepDomain,
+	SetupStepReachability,
+	SetupStepAdmin,
+}
+
+type SetupState struct {
+	Next       SetupStep `json:"next,omitempty"` // empty when setup is completed
+	Completed  bool      `json:"completed"`
+	ConfigFile string    `json:"config_file"`
+
+	// last failed step
+	Failed SetupStep `json:"failed,omitempty"`
+	Error  string    `json:"error,omitempty"`
+
+	Docker       *SetupDocker       `json:"docker,omitempty"`
+	Network      *SetupNetwork      `json:"network,omitempty"`
+	Domain       *SetupDomain       `json:"domain,omitempty"`
+	Reachability *SetupReachability `json:"reachability,omitempty"`
+	Admin        *SetupAdmin        `json:"admin,omitempty"`
+}
+
+type SetupDocker struct {
+	Version    string `json:"version"`
+	ApiVersion string `json:"api_version"`
+	Os         string `json:"os"`
+	Arch       string `json:"arch"`
+}
+
+type SetupNetworkInfo struct {
+	Name    string `json:"name"`
+	Driver  string `json:"driver"`
+	Traefik bool   `json:"traefik"` // traefik container is attached
+}
+
+type SetupNetworkRequest struct {
+	Name   string `json:"name"`
+	Create bool   `json:"create"` // create network, when it does not exist
+}
+
+type SetupNetwork struct {
+	Name    string `json:"name"`
+	Created bool   `json:"created"`
+	Traefik bool   `json:"traefik"`
+}
+
+type SetupDomainRequest struct {
+	Domain       string `json:"domain"` // empty or '*' matches all, '*.domain.tld' for rooms as subdomains
+	Entrypoint   string `json:"entrypoint,omitempty"`
+	Certresolver string `json:"certresolver,omitempty"`
+}
+
+type SetupDomain struct {
+	Domain       string   `json:"domain"`
+	Entrypoint   string   `json:"entrypoint"`
+	Certresolver string   `json:"certresolver,omitempty"`
+	Addresses    []string `json:"addresses"` // domain resolves to
+}
+
+type SetupReachabilityRequest struct {
+	StunServer string   `json:"stun_server,omitempty"`
+	NAT1To1    []string `json:"nat1to1,omitempty"` // overrides detected public IP
+}
+
+type SetupReachability struct {
+	PublicIP   string   `json:"public_ip"`
+	MappedPort int      `json:"mapped_port"`
+	LocalPort  int      `json:"local_port"`
+	BehindNAT  bool     `json:"behind_nat"`
+	NAT1To1    []string `json:"nat1to1"`
+	Warnings   []string `json:"warnings"`
+}
+
+type SetupAdminRequest struct {
+	Username string `json:"username,omitempty"`
+}
+
+type SetupAdmin struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"` // returned only once, when it is created
+}
+
+var ErrSetupUnauthorized = fmt.Errorf("invalid setup code")
+var ErrSetupCompleted = fmt.Errorf("setup is already completed")
+var ErrSetupStepOrder = fmt.Errorf("previous setup steps must be completed first")
+
+type SetupManager interface {
+	Authorize(code string) bool
+	State() SetupState
+	Networks(ctx context.Context) ([]SetupNetworkInfo, error)
+
+	Docker(ctx context.Context) (*SetupDocker, error)
+	Network(ctx context.Context, request SetupNetworkRequest) (*SetupNetwork, error)
+	Domain(ctx context.Context, request SetupDomainRequest) (*SetupDomain, error)
+	Reachability(ctx context.Context, request SetupReachabilityRequest) (*SetupReachability, error)
+	Admin(ctx context.Context, request SetupAdminRequest) (*SetupAdmin, error)
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractTarEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeflag byte
+		expected string // path relative to destination, empty when skipped
+	}{
+		{"private/file.txt", tar.TypeReg, "file.txt"},
+		{"private/dir/file.txt", tar.TypeReg, "dir/file.txt"},
+		{"private/empty", tar.TypeDir, "empty"},
+		{"other/file.txt", tar.TypeReg, ""},
+		{"private/../../escaped.txt", tar.TypeReg, "escaped.txt"},
+		{"private/dir/../../../../escaped-nested.txt", tar.TypeReg, "escaped-nested.txt"},
+		{"private//absolute.txt", tar.TypeReg, "absolute.txt"},
+		{"private/..", tar.TypeReg, ""},
+		{"private/link", tar.TypeSymlink, ""},
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, tt := range tests {
+		header := &tar.Header{
+			Name:     tt.name,
+			Typeflag: tt.typeflag,
+			Mode:     0644,
+		}
+
+		var data []byte
+		switch tt.typeflag {
+		case tar.TypeReg:
+			data = []byte(tt.name)
+			header.Size = int64(len(data))
+		case tar.TypeDir:
+			header.Mode = 0755
+		case tar.TypeSymlink:
+			header.Linkname = "/etc/passwd"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write header %s: %v", tt.name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("failed to write data %s: %v", tt.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+
+	root := t.TempDir()
+	dst := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		t.Fatalf("failed to create destination: %v", err)
+	}
+
+	if err := ExtractTarEntries(tar.NewReader(&buf), dst, "private/"); err != nil {
+		t.Fatalf("ExtractTarEntries failed: %v", err)
+	}
+
+	expected := map[string]struct{}{}
+	for _, tt := range tests {
+		if tt.expected == "" {
+			continue
+		}
+		expected[tt.expected] = struct{}{}
+
+		info, err := os.Lstat(filepath.Join(dst, tt.expected))
+		if err != nil {
+			t.Errorf("%s should have been extracted to %s: %v", tt.name, tt.expected, err)
+			continue
+		}
+		if info.IsDir() != (tt.typeflag == tar.TypeDir) {
+			t.Errorf("%s has wrong type", tt.name)
+		}
+	}
+
+	// nothing must be written outside of destination
+	err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dst, file)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if _, ok := expected[filepath.ToSlash(rel)]; !ok {
+			t.Errorf("unexpected file %s", file)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk extracted files: %v", err)
+	}
+}
+
+func TestExtractTarEntriesLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+		link  string // link in destination pointing outside of it
+	}{
+		{"link to directory", "dir/file.txt", "dir"},
+		{"link to file", "file.txt", "file.txt"},
+		{"nested link", "a/dir/file.txt", "a/dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dst := filepath.Join(root, "dst")
+			outside := filepath.Join(root, "outside")
+			for _, dir := range []string{dst, outside, filepath.Dir(filepath.Join(dst, tt.link))} {
+				if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+					t.Fatalf("failed to create %s: %v", dir, err)
+				}
+			}
+
+			target := outside
+			if filepath.Base(tt.entry) == filepath.Base(tt.link) {
+				target = filepath.Join(outside, "file.txt")
+			}
+			if err := os.Symlink(target, filepath.Join(dst, tt.link)); err != nil {
+				t.Fatalf("failed to create link: %v", err)
+			}
+
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			data := []byte("data")
+			if err := tw.WriteHeader(&tar.Header{Name: tt.entry, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))}); err != nil {
+				t.Fatalf("failed to write header: %v", err)
+			}
+			if _, err := tw.Write(data); err != nil {
+				t.Fatalf("failed to write data: %v", err)
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatalf("failed to close tar: %v", err)
+			}
+
+			if err := ExtractTarEntries(tar.NewReader(&buf), dst, ""); err == nil {
+				t.Errorf("ExtractTarEntries should have failed for %s", tt.entry)
+			}
+
+			files, err := os.ReadDir(outside)
+			if err != nil {
+				t.Fatalf("failed to list outside: %v", err)
+			}
+			if len(files) > 0 {
+				t.Errorf("file was written outside of destination through %s", tt.link)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"syscall"
 )
 
 func ChownR(path string, uid, gid int) error {
@@ -13,3 +15,82 @@ func ChownR(path string, uid, gid int) error {
 		return err
 	})
 }
+
+// DiskUsage returns used disk space in percent for filesystem containing path, as shown by df.
+func DiskUsage(path string) (float64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+
+	used := stat.Blocks - stat.Bfree
+	total := used + stat.Bavail
+	if total == 0 {
+		return 0, nil
+	}
+
+	return float64(used) / float64(total) * 100, nil
+}
+
+// CopyDir recursively copies directory, preserving file modes, ownership and symlinks.
+func CopyDir(src, dst string) error {
+	return filepath.Walk(src, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(name)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := copyFile(name, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			// sockets, devices and pipes are skipped
+			return nil
+		}
+
+		// ownership can be preserved only by root
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && os.Geteuid() == 0 {
+			return os.Lchown(target, int(stat.Uid), int(stat.Gid))
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,36 @@
+package utils
+
+import "context"
+
+// Semaphore limits number of concurrent operations, waiting operations
+// acquire it in the order they arrived. Nil semaphore is unlimited.
+type Semaphore chan struct{}
+
+func NewSemaphore(limit int) Semaphore {
+	if limit <= 0 {
+		return nil
+	}
+
+	return make(Semaphore, limit)
+}
+
+func (s Semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s Semaphore) Release() {
+	if s == nil {
+		return
+	}
+
+	<-s
+}
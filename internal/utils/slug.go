@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+var slugAdjectives = []string{
+	"amber", "brave", "bright", "calm", "clever", "cosmic", "crisp", "curious",
+	"daring", "eager", "fancy", "gentle", "golden", "happy", "humble", "jolly",
+	"kind", "lively", "lucky", "mellow", "merry", "misty", "noble", "polite",
+	"proud", "quick", "quiet", "rapid", "rusty", "shiny", "silent", "silver",
+	"sleepy", "smooth", "snowy", "sunny", "swift", "tidy", "vivid", "witty",
+}
+
+var slugNouns = []string{
+	"badger", "beacon", "canyon", "cedar", "comet", "coral", "falcon", "fern",
+	"forest", "fox", "glacier", "harbor", "heron", "island", "lagoon", "lantern",
+	"lynx", "maple", "meadow", "meteor", "orbit", "otter", "owl", "panda",
+	"pebble", "pine", "planet", "prairie", "raven", "reef", "river", "robin",
+	"sparrow", "spruce", "summit", "thunder", "tiger", "tundra", "valley", "willow",
+}
+
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// NewSlug generates human readable name in form of adjective-noun-number.
+func NewSlug() (string, error) {
+	adjective, err := randomIndex(len(slugAdjectives))
+	if err != nil {
+		return "", err
+	}
+
+	noun, err := randomIndex(len(slugNouns))
+	if err != nil {
+		return "", err
+	}
+
+	number, err := randomIndex(100)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%s-%d", slugAdjectives[adjective], slugNouns[noun], number), nil
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"regexp"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+func formatUUID(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// NewUUID returns random (version 4) UUID.
+func NewUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := BytesGenerator(b); err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	return formatUUID(b), nil
+}
+
+// NameUUID returns name based (version 5) UUID, that is always the same for the same name.
+func NameUUID(name string) string {
+	h := sha1.Sum([]byte(name))
+	b := h[:16]
+
+	b[6] = (b[6] & 0x0f) | 0x50 // version 5
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	return formatUUID(b)
+}
+
+// IsUUID checks whether string is lowercase UUID.
+func IsUUID(s string) bool {
+	return uuidRegex.MatchString(s)
+}
@@ -1,15 +1,19 @@
 package neko_rooms
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 
 	"github.com/docker/docker/client"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
 	"github.com/m1k1o/neko-rooms/internal/api"
 	"github.com/m1k1o/neko-rooms/internal/config"
@@ -17,6 +21,9 @@ import (
 	"github.com/m1k1o/neko-rooms/internal/pull"
 	"github.com/m1k1o/neko-rooms/internal/room"
 	"github.com/m1k1o/neko-rooms/internal/server"
+	"github.com/m1k1o/neko-rooms/internal/setup"
+	"github.com/m1k1o/neko-rooms/internal/types"
+	"github.com/m1k1o/neko-rooms/internal/utils"
 )
 
 const Header = `&34
@@ -127,25 +134,105 @@ func (main *MainCtx) Start() {
 		main.logger.Info().Msg("successfully connected to docker client")
 	}
 
+	// shared by all managers, that perform heavy docker operations
+	dockerOps := utils.NewSemaphore(main.Configs.Room.DockerMaxOperations)
+
 	main.roomManager = room.New(
 		client,
 		main.Configs.Room,
+		dockerOps,
 	)
 	main.roomManager.EventsLoopStart()
 
+	if main.Configs.Room.MigrateOnStartup {
+		migrations, err := main.roomManager.Migrate(context.Background())
+		if err != nil {
+			main.logger.Err(err).Msg("unable to migrate rooms")
+		} else {
+			main.logger.Info().Int("rooms", len(migrations)).Msg("rooms migrated")
+		}
+	}
+
+	// hand-managed containers are only offered, they are converted using API or CLI
+	for _, project := range main.Configs.Room.ImportComposeProjects {
+		imports, err := main.roomManager.ComposeImports(context.Background(), project)
+		if err != nil {
+			main.logger.Err(err).Str("project", project).Msg("unable to scan compose project")
+			continue
+		}
+
+		for _, item := range imports {
+			main.logger.Info().
+				Str("project", item.Project).
+				Str("service", item.Service).
+				Str("container_id", item.ContainerID).
+				Strs("warnings", item.Warnings).
+				Msg("neko service can be imported as room, use POST /api/import/compose or neko_rooms import-compose")
+		}
+	}
+
 	main.pullManager = pull.New(
 		client,
-		main.Configs.Room.NekoImages,
+		main.Configs.Room,
+		dockerOps,
 	)
 
+	if main.Configs.Room.Prepull {
+		main.pullManager.StartPrepull()
+	}
+
+	// interface must stay nil, when setup mode is disabled
+	var setupManager types.SetupManager
+	if main.Configs.Server.Setup.Enabled {
+		setupManager, err = setup.New(
+			client,
+			&main.Configs.Server.Setup,
+			main.Configs.Room,
+		)
+		if err != nil {
+			main.logger.Panic().Err(err).Msg("unable to start setup mode")
+		}
+	}
+
 	main.apiManager = api.New(
 		main.roomManager,
 		main.pullManager,
+		setupManager,
+		&main.Configs.Server.Admin,
+		types.About{
+			Version:   fmt.Sprintf("%s.%s.%s", main.Version.Major, main.Version.Minor, main.Version.Patch),
+			GitCommit: main.Version.GitCommit,
+			GitBranch: main.Version.GitBranch,
+			BuildDate: main.Version.BuildDate,
+			GoVersion: main.Version.GoVersion,
+			Platform:  main.Version.Platform,
+			Profile:   main.Configs.Root.Profile,
+
+			Deprecations: main.Configs.Room.Deprecations,
+		},
 	)
 
+	about := main.apiManager.About(context.Background())
+	main.logger.Info().
+		Str("version", about.Version).
+		Str("git_commit", about.GitCommit).
+		Str("build_date", about.BuildDate).
+		Str("profile", about.Profile).
+		Str("backend", about.Backend).
+		Str("docker_version", about.DockerVersion).
+		Strs("features", about.Features).
+		Msg("starting neko-rooms")
+
+	if len(about.Deprecations) > 0 {
+		main.logger.Warn().
+			Int("deprecations", len(about.Deprecations)).
+			Msg("deprecated config items are in use, run migrate-config command to migrate them")
+	}
+
 	main.proxyManager = proxy.New(
 		main.roomManager,
 		main.Configs.Room.WaitEnabled,
+		main.Configs.Room.LazyStart,
 	)
 	main.proxyManager.Start()
 
@@ -179,11 +266,182 @@ func (main *MainCtx) ServeCommand(cmd *cobra.Command, args []string) {
 	main.Start()
 	main.logger.Info().Msg("neko_rooms ready")
 
+	// notify systemd, when running as notify service
+	if err := server.Notify("READY=1"); err != nil {
+		main.logger.Warn().Err(err).Msg("unable to notify systemd")
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	sig := <-quit
 
 	main.logger.Warn().Msgf("received %s, attempting graceful shutdown.", sig)
+	_ = server.Notify("STOPPING=1")
 	main.Shutdown()
 	main.logger.Info().Msg("shutdown complete")
 }
+
+// DoctorCommand runs doctor checks against local docker, without starting the server.
+func (main *MainCtx) DoctorCommand(cmd *cobra.Command, args []string) {
+	fix, _ := cmd.Flags().GetBool("fix")
+	asJson, _ := cmd.Flags().GetBool("json")
+
+	client, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		main.logger.Fatal().Err(err).Msg("unable to connect to docker client")
+	}
+
+	dockerOps := utils.NewSemaphore(main.Configs.Room.DockerMaxOperations)
+	roomManager := room.New(client, main.Configs.Room, dockerOps)
+
+	ctx := context.Background()
+
+	if fix {
+		repaired, err := roomManager.DoctorRepair(ctx)
+		if err != nil {
+			main.logger.Fatal().Err(err).Msg("unable to repair rooms")
+		}
+
+		for _, issue := range repaired {
+			if issue.Error != "" {
+				main.logger.Error().Str("room", issue.Room).Str("type", string(issue.Type)).Str("error", issue.Error).Msg("repair failed")
+			} else {
+				main.logger.Info().Str("room", issue.Room).Str("type", string(issue.Type)).Msg("repaired")
+			}
+		}
+	}
+
+	doctor, err := roomManager.Doctor(ctx)
+	if err != nil {
+		main.logger.Fatal().Err(err).Msg("unable to run doctor checks")
+	}
+
+	if asJson {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(doctor)
+	} else {
+		if len(doctor.Issues) == 0 {
+			fmt.Println("no issues found")
+		}
+
+		for _, issue := range doctor.Issues {
+			subject := issue.Room
+			if subject == "" {
+				subject = issue.Router
+			}
+
+			fmt.Printf("%-8s %-15s %s: %s\n", strings.ToUpper(string(issue.Severity)), issue.Type, subject, issue.Message)
+			if issue.Suggestion != "" {
+				fmt.Printf("%24s -> %s", "", issue.Suggestion)
+				if issue.Repairable {
+					fmt.Print(" (auto-fix: --fix)")
+				}
+				fmt.Println()
+			}
+		}
+	}
+
+	for _, issue := range doctor.Issues {
+		if issue.Severity == types.DoctorCritical {
+			os.Exit(1)
+		}
+	}
+}
+
+// ImportComposeCommand offers neko services of compose project for import and
+// converts them to rooms, when confirmed using --apply.
+func (main *MainCtx) ImportComposeCommand(cmd *cobra.Command, args []string) {
+	apply, _ := cmd.Flags().GetBool("apply")
+	remove, _ := cmd.Flags().GetBool("remove")
+	services, _ := cmd.Flags().GetStringSlice("services")
+	names, _ := cmd.Flags().GetStringToString("names")
+
+	client, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		main.logger.Fatal().Err(err).Msg("unable to connect to docker client")
+	}
+
+	dockerOps := utils.NewSemaphore(main.Configs.Room.DockerMaxOperations)
+	roomManager := room.New(client, main.Configs.Room, dockerOps)
+
+	ctx := context.Background()
+
+	var imports []types.RoomImport
+	if apply {
+		imports, err = roomManager.ImportCompose(ctx, types.RoomImportRequest{
+			Project:  args[0],
+			Services: services,
+			Names:    names,
+			Remove:   remove,
+		})
+	} else {
+		imports, err = roomManager.ComposeImports(ctx, args[0])
+	}
+	if err != nil {
+		main.logger.Fatal().Err(err).Msg("unable to import compose project")
+	}
+
+	if len(imports) == 0 {
+		fmt.Println("no neko services found")
+		return
+	}
+
+	for _, item := range imports {
+		fmt.Printf("service %s (container %s, running: %t)\n", item.Service, item.ContainerID, item.Running)
+		if item.Settings != nil {
+			name := item.Settings.Name
+			if val, ok := names[item.Service]; ok {
+				name = val
+			}
+			fmt.Printf("  room: %s, image: %s, max connections: %d\n", name, item.Settings.NekoImage, item.Settings.MaxConnections)
+		}
+		for _, warning := range item.Warnings {
+			fmt.Printf("  warning: %s\n", warning)
+		}
+		if item.ID != "" {
+			fmt.Printf("  imported as room %s\n", item.ID)
+		}
+		if item.Error != "" {
+			fmt.Printf("  error: %s\n", item.Error)
+		}
+	}
+
+	if !apply {
+		fmt.Println("\nrun again with --apply to convert services to rooms")
+	}
+}
+
+// MigrateConfigCommand writes config with deprecated items replaced and reports
+// them, running rooms are not affected.
+func (main *MainCtx) MigrateConfigCommand(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+
+	deprecations := main.Configs.Room.Deprecations
+	if len(deprecations) == 0 {
+		fmt.Fprintln(os.Stderr, "no deprecated config items found")
+	}
+
+	for _, deprecation := range deprecations {
+		fmt.Fprintf(os.Stderr, "%s -> %s: %s\n", deprecation.Key, deprecation.Replacement, deprecation.Message)
+	}
+
+	values := config.Settings()
+	main.Configs.Room.MigrateDeprecated(values)
+
+	if output == "" {
+		data, err := yaml.Marshal(config.Nested(values))
+		if err != nil {
+			main.logger.Fatal().Err(err).Msg("unable to encode config")
+		}
+
+		fmt.Print(string(data))
+		return
+	}
+
+	if err := config.WriteFile(output, values, false); err != nil {
+		main.logger.Fatal().Err(err).Msg("unable to write config")
+	}
+
+	fmt.Fprintf(os.Stderr, "migrated config written to %s\n", output)
+}